@@ -5,7 +5,10 @@ import (
 	"fmt"
 	"log"
 	"net"
+	"net/http"
+	"strings"
 
+	"github.com/go-webauthn/webauthn/webauthn"
 	"github.com/joho/godotenv"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
@@ -13,9 +16,16 @@ import (
 
 	pb "github.com/bekbek22/auth_service/api/proto"
 	"github.com/bekbek22/auth_service/config"
+	"github.com/bekbek22/auth_service/internal/audit"
+	"github.com/bekbek22/auth_service/internal/federation"
 	"github.com/bekbek22/auth_service/internal/handler"
+	"github.com/bekbek22/auth_service/internal/middleware"
+	"github.com/bekbek22/auth_service/internal/notifier"
+	"github.com/bekbek22/auth_service/internal/oauth"
+	"github.com/bekbek22/auth_service/internal/policy"
 	"github.com/bekbek22/auth_service/internal/repository"
 	"github.com/bekbek22/auth_service/internal/service"
+	"github.com/bekbek22/auth_service/internal/utils"
 )
 
 func main() {
@@ -28,6 +38,13 @@ func main() {
 	//Load Config
 	cfg := config.Load()
 
+	if err := middleware.SetTrustedProxies(strings.Split(cfg.TrustedProxies, ",")); err != nil {
+		log.Fatalf("Invalid TRUSTED_PROXIES: %v", err)
+	}
+	if cfg.RetentionMode != "anonymize" && cfg.RetentionMode != "purge" {
+		log.Fatalf("Invalid RETENTION_MODE %q: must be anonymize or purge", cfg.RetentionMode)
+	}
+
 	//Connect MongoDB
 	clientOptions := options.Client().ApplyURI(cfg.MongoURI)
 	client, err := mongo.Connect(cfg.Ctx, clientOptions)
@@ -38,18 +55,146 @@ func main() {
 	db := client.Database(cfg.MongoDBName)
 
 	userRepo := repository.NewUserRepository(db)
+	if err := userRepo.EnsureIndexes(cfg.Ctx); err != nil {
+		log.Fatalf("Failed to create user indexes: %v", err)
+	}
 	tokenRepo := repository.NewTokenRepository(db)
 	passwordresetRepo := repository.NewPasswordResetRepository(db)
-	authService := service.NewAuthService(userRepo, tokenRepo, passwordresetRepo, cfg)
+	magicLinkRepo := repository.NewMagicLinkRepository(db)
+	emailChangeRepo := repository.NewEmailChangeRepository(db)
+	sessionRepo := repository.NewSessionRepository(db)
+	refreshTokenRepo := repository.NewRefreshTokenRepository(db)
+	oauthCodeRepo := repository.NewOAuthCodeRepository(db)
+	if err := tokenRepo.EnsureIndexes(cfg.Ctx); err != nil {
+		log.Fatalf("Failed to create token indexes: %v", err)
+	}
+	clientRepo := repository.NewClientRepository(db)
+	identityRepo := repository.NewIdentityRepository(db)
+	if err := identityRepo.EnsureIndexes(cfg.Ctx); err != nil {
+		log.Fatalf("Failed to create identity indexes: %v", err)
+	}
+	federationStateRepo := repository.NewFederationStateRepository(db)
+	ceremonyRepo := repository.NewWebAuthnCeremonyRepository(db)
+	mfaChallengeRepo := repository.NewMFAChallengeRepository(db)
+	otpCodeRepo := repository.NewOTPCodeRepository(db)
+	serviceAccountRepo := repository.NewServiceAccountRepository(db)
+	apiKeyRepo := repository.NewAPIKeyRepository(db)
+	if err := apiKeyRepo.EnsureIndexes(cfg.Ctx); err != nil {
+		log.Fatalf("Failed to create api key indexes: %v", err)
+	}
+	patRepo := repository.NewPersonalAccessTokenRepository(db)
+	if err := patRepo.EnsureIndexes(cfg.Ctx); err != nil {
+		log.Fatalf("Failed to create personal access token indexes: %v", err)
+	}
+	tenantRepo := repository.NewTenantRepository(db)
+	if err := tenantRepo.EnsureIndexes(cfg.Ctx); err != nil {
+		log.Fatalf("Failed to create tenant indexes: %v", err)
+	}
+	orgRepo := repository.NewOrganizationRepository(db)
+	membershipRepo := repository.NewMembershipRepository(db)
+	if err := membershipRepo.EnsureIndexes(cfg.Ctx); err != nil {
+		log.Fatalf("Failed to create membership indexes: %v", err)
+	}
+	invitationRepo := repository.NewInvitationRepository(db)
+	if err := invitationRepo.EnsureIndexes(cfg.Ctx); err != nil {
+		log.Fatalf("Failed to create invitation indexes: %v", err)
+	}
+	groupRepo := repository.NewGroupRepository(db)
+	if err := groupRepo.EnsureIndexes(cfg.Ctx); err != nil {
+		log.Fatalf("Failed to create group indexes: %v", err)
+	}
+	groupMemberRepo := repository.NewGroupMemberRepository(db)
+	if err := groupMemberRepo.EnsureIndexes(cfg.Ctx); err != nil {
+		log.Fatalf("Failed to create group member indexes: %v", err)
+	}
+	policyRepo := repository.NewPolicyRepository(db)
+	policies := policy.NewEngine(policyRepo)
+	if err := policies.Reload(cfg.Ctx); err != nil {
+		log.Fatalf("Failed to load policies: %v", err)
+	}
+	go policies.Run(cfg.Ctx, cfg.PolicyReloadInterval)
+	revocations := repository.NewRevocationCache(tokenRepo, sessionRepo, cfg.RevocationCacheSize)
+	go revocations.Run(cfg.Ctx, cfg.RevocationRefreshInterval)
+	auditRepo := repository.NewAuditRepository(db)
+
+	//Audit events go to Mongo and optionally to a JSON-lines file
+	auditSinks := []audit.Sink{auditRepo}
+	if cfg.AuditLogFile != "" {
+		fileSink, err := audit.NewFileSink(cfg.AuditLogFile)
+		if err != nil {
+			log.Fatalf("Failed to open audit log file: %v", err)
+		}
+		defer fileSink.Close()
+		auditSinks = append(auditSinks, fileSink)
+	}
+	auditLogger := audit.NewLogger(auditSinks...)
+
+	//Passkeys are bound to the relying party ID and must come from a listed origin
+	passkeys, err := webauthn.New(&webauthn.Config{
+		RPID:          cfg.WebAuthnRPID,
+		RPDisplayName: cfg.WebAuthnRPName,
+		RPOrigins:     strings.Split(cfg.WebAuthnRPOrigins, ","),
+	})
+	if err != nil {
+		log.Fatalf("Invalid WebAuthn config: %v", err)
+	}
+
+	//Signs access and ID tokens; the public half is served as a JWKS
+	signingKey, err := utils.LoadSigningKey(cfg.SigningKeyFile)
+	if err != nil {
+		log.Fatalf("Failed to load signing key: %v", err)
+	}
+	if cfg.SigningKeyFile == "" {
+		log.Println("OIDC_SIGNING_KEY_FILE not set, using a temporary signing key")
+	}
+	tokens := utils.NewTokenIssuer(signingKey, cfg.Issuer, cfg.TokenAudience)
+
+	authService := service.NewAuthService(userRepo, tokenRepo, passwordresetRepo, magicLinkRepo, emailChangeRepo, sessionRepo, refreshTokenRepo, oauthCodeRepo, revocations, clientRepo, identityRepo, federationStateRepo, ceremonyRepo, mfaChallengeRepo, otpCodeRepo, serviceAccountRepo, apiKeyRepo, patRepo, tenantRepo, orgRepo, membershipRepo, invitationRepo, groupRepo, groupMemberRepo, policyRepo, policies, passkeys, tokens, auditRepo, auditLogger, notifier.NewLogNotifier(), cfg)
 	authHandler := handler.NewAuthHandler(authService)
 
+	//Anonymize or purge soft-deleted users past retention
+	go authService.RunRetentionJob(cfg.Ctx, cfg.RetentionInterval)
+
+	//OAuth 2.0 authorization server over HTTP
+	oauthServer := oauth.NewServer(
+		userRepo,
+		sessionRepo,
+		oauthCodeRepo,
+		refreshTokenRepo,
+		tokenRepo,
+		revocations,
+		oauth.NewRepositoryClientStore(clientRepo),
+		authService.RequiresSecondFactor,
+		authService.GroupRoles,
+		auditLogger,
+		signingKey,
+		tokens,
+		cfg,
+	)
+	mux := http.NewServeMux()
+	oauthServer.Routes(mux)
+
+	//Sign in with external OIDC providers
+	providers, err := federation.LoadProviders(cfg.FederationProviders, cfg.AppBaseURL)
+	if err != nil {
+		log.Fatalf("Invalid FEDERATION_PROVIDERS: %v", err)
+	}
+	federation.NewHandler(providers, federationStateRepo, authService).Routes(mux)
+	go func() {
+		fmt.Printf("HTTP server is running on port %s\n", cfg.HTTPPort)
+		if err := http.ListenAndServe(":"+cfg.HTTPPort, mux); err != nil {
+			log.Fatalf("Failed to serve HTTP: %v", err)
+		}
+	}()
+
 	//Create gRPC Server
 	listener, err := net.Listen("tcp", ":"+cfg.GRPCPort)
 	if err != nil {
 		log.Fatalf("Failed to listen on port %s: %v", cfg.GRPCPort, err)
 	}
 
-	grpcServer := grpc.NewServer()
+	authInterceptor := middleware.NewAuthInterceptor(tokens, handler.PublicMethods, handler.SensitiveMethods, handler.ImpersonationMethods, handler.RequiredScopes, authService.VerifyToken, authService.AuthenticateKey, authService.RecordImpersonatedCall)
+	grpcServer := grpc.NewServer(grpc.UnaryInterceptor(authInterceptor.Unary()))
 	pb.RegisterAuthServiceServer(grpcServer, authHandler)
 
 	fmt.Printf("gRPC server is running on port %s\n", cfg.GRPCPort)
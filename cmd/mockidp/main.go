@@ -0,0 +1,28 @@
+// cmd/mockidp runs the mock OpenID Connect provider for trying federated login
+// locally. It signs in whoever is named by the login_hint parameter without
+// asking for a password, so it must never be exposed outside a dev machine.
+package main
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+
+	"github.com/bekbek22/auth_service/internal/federation/mockidp"
+)
+
+func main() {
+	port := os.Getenv("MOCK_IDP_PORT")
+	if port == "" {
+		port = "9090"
+	}
+
+	idp, err := mockidp.New("http://localhost:" + port)
+	if err != nil {
+		log.Fatalf("Failed to generate key: %v", err)
+	}
+
+	fmt.Printf("Mock IdP is running on %s\n", idp.Issuer)
+	log.Fatal(http.ListenAndServe(":"+port, idp.Handler()))
+}
@@ -2,15 +2,69 @@ package config
 
 import (
 	"context"
+	"log"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 )
 
 type Config struct {
 	MongoURI    string
 	MongoDBName string
 	GRPCPort    string
-	JWTSecret   string
+	HTTPPort    string
+	AppBaseURL  string
 	Ctx         context.Context
+
+	// Comma separated IPs or CIDRs of proxies whose X-Forwarded-For is trusted
+	TrustedProxies string
+
+	// Soft-deleted users are anonymized or purged once this window has passed
+	DeletedUserRetention time.Duration
+	RetentionMode        string // "anonymize" or "purge"
+	RetentionInterval    time.Duration
+
+	// Optional JSON-lines file that receives a copy of every audit event
+	AuditLogFile string
+
+	// In-memory cache of revoked tokens/sessions checked on every RPC
+	RevocationCacheSize       int
+	RevocationRefreshInterval time.Duration
+
+	// How often authorization policies are reloaded from Mongo
+	PolicyReloadInterval time.Duration
+
+	// How long IntrospectToken/ValidateToken results are reused
+	IntrospectionCacheTTL time.Duration
+
+	// OpenID Connect issuer and the RSA key (PEM file) that signs access and ID tokens
+	Issuer         string
+	SigningKeyFile string
+
+	// aud every access token carries; services verifying tokens expect it
+	TokenAudience string
+
+	// Token lifetimes. ACCESS_TOKEN_TTL_BY_ROLE overrides the access token
+	// lifetime per role, e.g. "admin=15m,service=1h"
+	AccessTokenTTL       time.Duration
+	AccessTokenTTLByRole map[string]time.Duration
+	RefreshTokenTTL      time.Duration
+	PasswordResetTTL     time.Duration
+	MagicLinkTTL         time.Duration
+	OTPCodeTTL           time.Duration
+	EmailVerificationTTL time.Duration
+	MFAChallengeTTL      time.Duration
+	ImpersonationTTL     time.Duration
+	InvitationTTL        time.Duration
+
+	// JSON array of external OIDC providers users can sign in with
+	FederationProviders string
+
+	// WebAuthn relying party; origins are comma separated
+	WebAuthnRPID      string
+	WebAuthnRPName    string
+	WebAuthnRPOrigins string
 }
 
 func Load() *Config {
@@ -18,9 +72,76 @@ func Load() *Config {
 		MongoURI:    getEnv("MONGO_URI", "mongodb://localhost:27017"),
 		MongoDBName: getEnv("MONGO_DB_NAME", "auth_db"),
 		GRPCPort:    getEnv("GRPC_PORT", "50051"),
-		JWTSecret:   getEnv("JWT_SECRET", "supersecret"),
+		HTTPPort:    getEnv("HTTP_PORT", "8080"),
+		AppBaseURL:  getEnv("APP_BASE_URL", "http://localhost:8080"),
 		Ctx:         context.Background(),
+
+		TrustedProxies: getEnv("TRUSTED_PROXIES", ""),
+
+		DeletedUserRetention: getEnvDuration("DELETED_USER_RETENTION", 30*24*time.Hour),
+		RetentionMode:        getEnv("RETENTION_MODE", "anonymize"),
+		RetentionInterval:    getEnvInterval("RETENTION_INTERVAL", time.Hour),
+
+		AuditLogFile: getEnv("AUDIT_LOG_FILE", ""),
+
+		RevocationCacheSize:       getEnvInt("REVOCATION_CACHE_SIZE", 100000),
+		RevocationRefreshInterval: getEnvInterval("REVOCATION_REFRESH_INTERVAL", 10*time.Second),
+
+		PolicyReloadInterval: getEnvInterval("POLICY_RELOAD_INTERVAL", 30*time.Second),
+
+		IntrospectionCacheTTL: getEnvDuration("INTROSPECTION_CACHE_TTL", 5*time.Second),
+
+		Issuer:         getEnv("OIDC_ISSUER", getEnv("APP_BASE_URL", "http://localhost:8080")),
+		SigningKeyFile: getEnv("OIDC_SIGNING_KEY_FILE", ""),
+		TokenAudience:  getEnv("TOKEN_AUDIENCE", "auth_service"),
+
+		AccessTokenTTL:       getEnvDuration("ACCESS_TOKEN_TTL", 24*time.Hour),
+		AccessTokenTTLByRole: getEnvDurationMap("ACCESS_TOKEN_TTL_BY_ROLE"),
+		RefreshTokenTTL:      getEnvDuration("REFRESH_TOKEN_TTL", 30*24*time.Hour),
+		PasswordResetTTL:     getEnvDuration("PASSWORD_RESET_TTL", 15*time.Minute),
+		MagicLinkTTL:         getEnvDuration("MAGIC_LINK_TTL", 10*time.Minute),
+		OTPCodeTTL:           getEnvDuration("OTP_CODE_TTL", 5*time.Minute),
+		EmailVerificationTTL: getEnvDuration("EMAIL_VERIFICATION_TTL", 24*time.Hour),
+		MFAChallengeTTL:      getEnvDuration("MFA_CHALLENGE_TTL", 5*time.Minute),
+		ImpersonationTTL:     getEnvDuration("IMPERSONATION_TTL", 15*time.Minute),
+		InvitationTTL:        getEnvDuration("INVITATION_TTL", 7*24*time.Hour),
+
+		FederationProviders: getEnv("FEDERATION_PROVIDERS", ""),
+
+		WebAuthnRPID:      getEnv("WEBAUTHN_RP_ID", "localhost"),
+		WebAuthnRPName:    getEnv("WEBAUTHN_RP_NAME", "Auth Service"),
+		WebAuthnRPOrigins: getEnv("WEBAUTHN_RP_ORIGINS", getEnv("APP_BASE_URL", "http://localhost:8080")),
+	}
+}
+
+// AccessTokenTTLFor returns the lifetime of an access token for a user with
+// roles, issued to a client whose own lifetime is clientTTL (0 if it has
+// none). The shortest override wins, so neither a client nor a second role
+// can lengthen e.g. admins' tokens.
+func (c *Config) AccessTokenTTLFor(roles []string, clientTTL time.Duration) time.Duration {
+	ttl := time.Duration(0)
+	for _, role := range roles {
+		if d, ok := c.AccessTokenTTLByRole[role]; ok && (ttl == 0 || d < ttl) {
+			ttl = d
+		}
+	}
+	if clientTTL > 0 && (ttl == 0 || clientTTL < ttl) {
+		ttl = clientTTL
+	}
+	if ttl == 0 {
+		return c.AccessTokenTTL
 	}
+	return ttl
+}
+
+// MaxAccessTokenTTL is the longest an access token issued to a client whose
+// own lifetime is clientTTL can live, whatever the user's roles.
+func (c *Config) MaxAccessTokenTTL(clientTTL time.Duration) time.Duration {
+	ttl := max(c.AccessTokenTTL, clientTTL)
+	for _, d := range c.AccessTokenTTLByRole {
+		ttl = max(ttl, d)
+	}
+	return ttl
 }
 
 func getEnv(key, defaultVal string) string {
@@ -30,3 +151,58 @@ func getEnv(key, defaultVal string) string {
 	}
 	return val
 }
+
+func getEnvDuration(key string, defaultVal time.Duration) time.Duration {
+	val := os.Getenv(key)
+	if val == "" {
+		return defaultVal
+	}
+	d, err := time.ParseDuration(val)
+	if err != nil {
+		log.Printf("invalid duration for %s=%q, using %s", key, val, defaultVal)
+		return defaultVal
+	}
+	return d
+}
+
+// getEnvInterval is getEnvDuration for the period of a background job, which
+// must be positive.
+func getEnvInterval(key string, defaultVal time.Duration) time.Duration {
+	d := getEnvDuration(key, defaultVal)
+	if d <= 0 {
+		log.Printf("invalid interval for %s=%q, using %s", key, os.Getenv(key), defaultVal)
+		return defaultVal
+	}
+	return d
+}
+
+// getEnvDurationMap parses "key=duration" pairs separated by commas.
+func getEnvDurationMap(key string) map[string]time.Duration {
+	m := make(map[string]time.Duration)
+	for _, pair := range strings.Split(os.Getenv(key), ",") {
+		if strings.TrimSpace(pair) == "" {
+			continue
+		}
+		k, v, _ := strings.Cut(pair, "=")
+		d, err := time.ParseDuration(strings.TrimSpace(v))
+		if err != nil || d <= 0 {
+			log.Printf("invalid duration in %s: %q, ignoring", key, pair)
+			continue
+		}
+		m[strings.TrimSpace(k)] = d
+	}
+	return m
+}
+
+func getEnvInt(key string, defaultVal int) int {
+	val := os.Getenv(key)
+	if val == "" {
+		return defaultVal
+	}
+	n, err := strconv.Atoi(val)
+	if err != nil {
+		log.Printf("invalid integer for %s=%q, using %d", key, val, defaultVal)
+		return defaultVal
+	}
+	return n
+}
@@ -0,0 +1,140 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/bekbek22/auth_service/internal/middleware"
+	"github.com/bekbek22/auth_service/internal/model"
+)
+
+const (
+	OutcomeSuccess = "success"
+	OutcomeFailure = "failure"
+)
+
+const (
+	ActionRegister             = "register"
+	ActionLogin                = "login"
+	ActionLogout               = "logout"
+	ActionPasswordResetRequest = "password_reset_request"
+	ActionPasswordReset        = "password_reset"
+	ActionEmailChangeRequest   = "email_change_request"
+	ActionEmailChangeConfirm   = "email_change_confirm"
+	ActionEmailChangeRevert    = "email_change_revert"
+	ActionAccountDelete        = "account_delete"
+	ActionDataExport           = "data_export"
+	ActionSessionRevoke        = "session_revoke"
+	ActionOAuthAuthorize       = "oauth_authorize"
+	ActionTokenIssue           = "token_issue"
+	ActionTokenRevoke          = "token_revoke"
+	ActionClientCreate         = "client_create"
+	ActionClientUpdate         = "client_update"
+	ActionClientDelete         = "client_delete"
+	ActionClientSecretRotate   = "client_secret_rotate"
+	ActionIdentityLink         = "identity_link"
+	ActionIdentityUnlink       = "identity_unlink"
+	ActionMFAChallenge         = "mfa_challenge"
+	ActionPasskeyRegister      = "passkey_register"
+	ActionMagicLinkRequest     = "magic_link_request"
+	ActionOTPEnable            = "otp_enable"
+	ActionOTPDisable           = "otp_disable"
+	ActionServiceAccountCreate = "service_account_create"
+	ActionAPIKeyCreate         = "api_key_create"
+	ActionAPIKeyRotate         = "api_key_rotate"
+	ActionAPIKeyRevoke         = "api_key_revoke"
+	ActionPATCreate            = "pat_create"
+	ActionPATRevoke            = "pat_revoke"
+	ActionImpersonationStart   = "impersonation_start"
+	ActionImpersonatedCall     = "impersonated_call"
+	ActionTenantCreate         = "tenant_create"
+	ActionTenantUpdate         = "tenant_update"
+	ActionTenantDelete         = "tenant_delete"
+	ActionOrgCreate            = "org_create"
+	ActionOrgDelete            = "org_delete"
+	ActionOrgMemberUpdate      = "org_member_update"
+	ActionOrgMemberRemove      = "org_member_remove"
+	ActionOrgInvite            = "org_invite"
+	ActionOrgInviteRevoke      = "org_invite_revoke"
+	ActionOrgInviteAccept      = "org_invite_accept"
+	ActionGroupCreate          = "group_create"
+	ActionGroupUpdate          = "group_update"
+	ActionGroupDelete          = "group_delete"
+	ActionGroupMemberAdd       = "group_member_add"
+	ActionGroupMemberRemove    = "group_member_remove"
+	ActionPolicyCreate         = "policy_create"
+	ActionPolicyUpdate         = "policy_update"
+	ActionPolicyDelete         = "policy_delete"
+)
+
+// Sink stores audit events. Implementations must only ever append.
+type Sink interface {
+	Write(ctx context.Context, event *model.AuditEvent) error
+}
+
+// Logger fans events out to every configured sink.
+type Logger struct {
+	sinks []Sink
+}
+
+func NewLogger(sinks ...Sink) *Logger {
+	return &Logger{sinks: sinks}
+}
+
+// Record stamps the event with time and client info and writes it to all
+// sinks. Events caused by an impersonated call also name the impersonator.
+// Failures are logged, never returned, so auditing can't break a request.
+func (l *Logger) Record(ctx context.Context, event model.AuditEvent) {
+	event.Timestamp = time.Now().Unix()
+	if event.IP == "" && event.UserAgent == "" {
+		event.IP, event.UserAgent = middleware.ClientInfoFromContext(ctx)
+	}
+	if claims, ok := middleware.ClaimsFromContext(ctx); ok && claims.Impersonated() {
+		details := make(map[string]string, len(event.Details)+1)
+		for k, v := range event.Details {
+			details[k] = v
+		}
+		details["impersonator_id"] = claims.Actor.Subject
+		event.Details = details
+	}
+
+	for _, sink := range l.sinks {
+		if err := sink.Write(ctx, &event); err != nil {
+			log.Printf("audit: failed to write %s event: %v", event.Action, err)
+		}
+	}
+}
+
+// FileSink appends events as JSON lines to a local file.
+type FileSink struct {
+	mu   sync.Mutex
+	file *os.File
+}
+
+func NewFileSink(path string) (*FileSink, error) {
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return nil, err
+	}
+	return &FileSink{file: f}, nil
+}
+
+func (s *FileSink) Write(ctx context.Context, event *model.AuditEvent) error {
+	line, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, err = s.file.Write(append(line, '\n'))
+	return err
+}
+
+func (s *FileSink) Close() error {
+	return s.file.Close()
+}
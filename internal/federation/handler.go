@@ -0,0 +1,160 @@
+package federation
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/bekbek22/auth_service/internal/middleware"
+	"github.com/bekbek22/auth_service/internal/model"
+	"github.com/bekbek22/auth_service/internal/repository"
+	"github.com/bekbek22/auth_service/internal/service"
+	"github.com/bekbek22/auth_service/internal/utils"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const stateTTL = 10 * time.Minute
+
+// Handler serves the browser redirects of federated login.
+type Handler struct {
+	providers map[string]*Provider
+	states    repository.IFederationStateRepository
+	service   service.IAuthService
+}
+
+func NewHandler(providers map[string]*Provider, states repository.IFederationStateRepository, s service.IAuthService) *Handler {
+	return &Handler{
+		providers: providers,
+		states:    states,
+		service:   s,
+	}
+}
+
+// Routes registers the federated login endpoints on mux.
+func (h *Handler) Routes(mux *http.ServeMux) {
+	mux.HandleFunc("GET /federation/{provider}/login", h.handleLogin)
+	mux.HandleFunc("GET /federation/{provider}/callback", h.handleCallback)
+}
+
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(body)
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, map[string]string{"error": message})
+}
+
+// handleLogin redirects the browser to the provider. With a link_ticket from
+// LinkLoginMethod the external account is linked instead of signed in.
+func (h *Handler) handleLogin(w http.ResponseWriter, r *http.Request) {
+	provider, ok := h.providers[r.PathValue("provider")]
+	if !ok {
+		writeError(w, http.StatusNotFound, "unknown provider")
+		return
+	}
+
+	var linkUserID primitive.ObjectID
+	if raw := r.URL.Query().Get("link_ticket"); raw != "" {
+		ticket, err := h.states.Consume(r.Context(), utils.HashToken(raw))
+		if err != nil || ticket.Nonce != "" || ticket.LinkUserID.IsZero() || ticket.Provider != provider.Name() {
+			writeError(w, http.StatusBadRequest, "invalid or expired link ticket")
+			return
+		}
+		linkUserID = ticket.LinkUserID
+	}
+
+	state, err1 := utils.GenerateOpaqueToken(32)
+	nonce, err2 := utils.GenerateOpaqueToken(32)
+	verifier, err3 := utils.GenerateOpaqueToken(48)
+	if err1 != nil || err2 != nil || err3 != nil {
+		writeError(w, http.StatusInternalServerError, "failed to start login")
+		return
+	}
+
+	err := h.states.Save(r.Context(), &model.FederationState{
+		StateHash:    utils.HashToken(state),
+		Provider:     provider.Name(),
+		Nonce:        nonce,
+		CodeVerifier: verifier,
+		DeviceName:   r.URL.Query().Get("device_name"),
+		LinkUserID:   linkUserID,
+		ExpiresAt:    time.Now().Add(stateTTL).Unix(),
+	})
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to start login")
+		return
+	}
+
+	authURL, err := provider.AuthCodeURL(r.Context(), state, nonce, verifier)
+	if err != nil {
+		writeError(w, http.StatusBadGateway, "provider unavailable")
+		return
+	}
+	http.Redirect(w, r, authURL, http.StatusFound)
+}
+
+// handleCallback finishes the login and responds with our access token, or
+// finishes linking when the redirect was started with a link ticket.
+func (h *Handler) handleCallback(w http.ResponseWriter, r *http.Request) {
+	provider, ok := h.providers[r.PathValue("provider")]
+	if !ok {
+		writeError(w, http.StatusNotFound, "unknown provider")
+		return
+	}
+
+	q := r.URL.Query()
+	if e := q.Get("error"); e != "" {
+		writeError(w, http.StatusUnauthorized, "provider returned error: "+e)
+		return
+	}
+
+	state, err := h.states.Consume(r.Context(), utils.HashToken(q.Get("state")))
+	if err != nil || state.Nonce == "" || state.Provider != provider.Name() {
+		writeError(w, http.StatusBadRequest, "invalid or expired state")
+		return
+	}
+
+	identity, err := provider.Exchange(r.Context(), q.Get("code"), state.CodeVerifier, state.Nonce)
+	if err != nil {
+		writeError(w, http.StatusUnauthorized, err.Error())
+		return
+	}
+
+	ctx := middleware.WithHTTPClientInfo(r)
+
+	if !state.LinkUserID.IsZero() {
+		if err := h.service.LinkExternalIdentity(ctx, state.LinkUserID, provider.Name(), provider.Tenant(), identity.Subject, identity.Email); err != nil {
+			writeError(w, http.StatusConflict, err.Error())
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]string{
+			"message":  "Login method linked",
+			"provider": provider.Name(),
+		})
+		return
+	}
+
+	result, err := h.service.LoginWithExternalIdentity(ctx, provider.Name(), provider.Tenant(), identity.Subject, identity.Email, identity.EmailVerified, identity.Name, state.DeviceName)
+	if err != nil {
+		writeError(w, http.StatusUnauthorized, err.Error())
+		return
+	}
+
+	// Same shape as LoginResponse: a second factor is completed over gRPC
+	if result.MFARequired {
+		writeJSON(w, http.StatusOK, map[string]interface{}{
+			"mfa_required": true,
+			"mfa_token":    result.MFAToken,
+			"mfa_methods":  result.MFAMethods,
+		})
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"access_token": result.Token,
+		"expires_in":   result.ExpiresIn,
+		"token_type":   "Bearer",
+	})
+}
@@ -0,0 +1,259 @@
+package federation
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+
+	"github.com/bekbek22/auth_service/internal/federation/mockidp"
+	"github.com/bekbek22/auth_service/internal/model"
+	"github.com/bekbek22/auth_service/internal/service"
+	"github.com/bekbek22/auth_service/internal/utils"
+	"github.com/golang-jwt/jwt/v5"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	testClientID    = "auth-service"
+	testRedirectURL = "http://auth.test/federation/mock/callback"
+)
+
+// memoryStates keeps federation states in memory.
+type memoryStates struct {
+	mu     sync.Mutex
+	states map[string]*model.FederationState
+}
+
+func (m *memoryStates) Save(ctx context.Context, state *model.FederationState) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.states[state.StateHash] = state
+	return nil
+}
+
+func (m *memoryStates) Consume(ctx context.Context, stateHash string) (*model.FederationState, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	state, ok := m.states[stateHash]
+	if !ok {
+		return nil, errors.New("not found")
+	}
+	delete(m.states, stateHash)
+	return state, nil
+}
+
+type externalLogin struct {
+	provider, subject, email string
+	emailVerified            bool
+	deviceName               string
+}
+
+// fakeAccounts records federated logins and fails them with err, if set. The
+// rules deciding which logins succeed are tested in the service package.
+type fakeAccounts struct {
+	service.IAuthService
+
+	err    error
+	logins []externalLogin
+}
+
+func (f *fakeAccounts) LoginWithExternalIdentity(ctx context.Context, provider, tenant, subject, email string, emailVerified bool, name, deviceName string) (*service.LoginResult, error) {
+	f.logins = append(f.logins, externalLogin{provider, subject, email, emailVerified, deviceName})
+	if f.err != nil {
+		return nil, f.err
+	}
+	return &service.LoginResult{Token: "token-for-" + subject, ExpiresIn: 60}, nil
+}
+
+func (f *fakeAccounts) LinkExternalIdentity(ctx context.Context, userID primitive.ObjectID, provider, tenant, subject, email string) error {
+	return nil
+}
+
+type testEnv struct {
+	idp      *mockidp.IdP
+	accounts *fakeAccounts
+	mux      *http.ServeMux
+}
+
+func newTestEnv(t *testing.T) *testEnv {
+	t.Helper()
+	idp, err := mockidp.New("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := httptest.NewServer(idp.Handler())
+	t.Cleanup(srv.Close)
+	idp.Issuer = srv.URL
+
+	provider := NewProvider(ProviderConfig{Name: "mock", Issuer: srv.URL, ClientID: testClientID, ClientSecret: "secret"}, testRedirectURL)
+	accounts := &fakeAccounts{}
+	states := &memoryStates{states: map[string]*model.FederationState{}}
+
+	mux := http.NewServeMux()
+	NewHandler(map[string]*Provider{"mock": provider}, states, accounts).Routes(mux)
+	return &testEnv{idp: idp, accounts: accounts, mux: mux}
+}
+
+// authorize starts a login as email and returns the query the IdP redirects
+// back to us with.
+func (e *testEnv) authorize(t *testing.T, email string) url.Values {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	e.mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/federation/mock/login?device_name=laptop", nil))
+	if rec.Code != http.StatusFound {
+		t.Fatalf("login: got status %d: %s", rec.Code, rec.Body)
+	}
+
+	authURL, err := url.Parse(rec.Header().Get("Location"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	q := authURL.Query()
+	if q.Get("redirect_uri") != testRedirectURL || q.Get("code_challenge_method") != "S256" || q.Get("nonce") == "" {
+		t.Fatalf("unexpected authorization request: %s", authURL)
+	}
+	q.Set("login_hint", email)
+	authURL.RawQuery = q.Encode()
+
+	client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }}
+	resp, err := client.Get(authURL.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	callback, err := url.Parse(resp.Header.Get("Location"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return callback.Query()
+}
+
+func (e *testEnv) callback(q url.Values) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	e.mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/federation/mock/callback?"+q.Encode(), nil))
+	return rec
+}
+
+func TestCallbackSignsIn(t *testing.T) {
+	env := newTestEnv(t)
+
+	rec := env.callback(env.authorize(t, "bob@example.com"))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("got status %d: %s", rec.Code, rec.Body)
+	}
+	var body map[string]interface{}
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatal(err)
+	}
+	if body["access_token"] != "token-for-mock|bob@example.com" {
+		t.Fatalf("unexpected response: %s", rec.Body)
+	}
+
+	want := externalLogin{"mock", "mock|bob@example.com", "bob@example.com", true, "laptop"}
+	if len(env.accounts.logins) != 1 || env.accounts.logins[0] != want {
+		t.Fatalf("got logins %+v, want %+v", env.accounts.logins, want)
+	}
+}
+
+func TestCallbackRejectsBadState(t *testing.T) {
+	env := newTestEnv(t)
+	q := env.authorize(t, "bob@example.com")
+
+	forged := url.Values{"code": {q.Get("code")}, "state": {"forged"}}
+	if rec := env.callback(forged); rec.Code != http.StatusBadRequest {
+		t.Fatalf("forged state: got status %d, want 400", rec.Code)
+	}
+
+	if rec := env.callback(q); rec.Code != http.StatusOK {
+		t.Fatalf("got status %d: %s", rec.Code, rec.Body)
+	}
+	if rec := env.callback(q); rec.Code != http.StatusBadRequest {
+		t.Fatalf("replayed state: got status %d, want 400", rec.Code)
+	}
+	if len(env.accounts.logins) != 1 {
+		t.Fatalf("got %d logins, want 1", len(env.accounts.logins))
+	}
+}
+
+func TestCallbackRejectsBadIDToken(t *testing.T) {
+	otherKey, err := utils.LoadSigningKey("")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		editClaims func(jwt.MapClaims)
+		signWith   *utils.SigningKey
+	}{
+		{name: "nonce mismatch", editClaims: func(c jwt.MapClaims) { c["nonce"] = "replayed" }},
+		{name: "missing nonce", editClaims: func(c jwt.MapClaims) { delete(c, "nonce") }},
+		{name: "other audience", editClaims: func(c jwt.MapClaims) { c["aud"] = "another-client" }},
+		{name: "other issuer", editClaims: func(c jwt.MapClaims) { c["iss"] = "https://evil.example.com" }},
+		{name: "expired", editClaims: func(c jwt.MapClaims) { c["exp"] = 1 }},
+		{name: "unpublished key", signWith: otherKey},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := newTestEnv(t)
+			env.idp.EditClaims = tt.editClaims
+			env.idp.SignWith = tt.signWith
+
+			rec := env.callback(env.authorize(t, "bob@example.com"))
+			if rec.Code != http.StatusUnauthorized {
+				t.Fatalf("got status %d, want 401: %s", rec.Code, rec.Body)
+			}
+			if len(env.accounts.logins) != 0 {
+				t.Fatalf("user was signed in: %+v", env.accounts.logins)
+			}
+		})
+	}
+}
+
+func TestCallbackPassesEmailVerification(t *testing.T) {
+	tests := []struct {
+		name     string
+		verified interface{}
+		want     bool
+	}{
+		{name: "verified", verified: true, want: true},
+		{name: "verified as string", verified: "true", want: true},
+		{name: "unverified", verified: false, want: false},
+		{name: "missing", verified: nil, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := newTestEnv(t)
+			env.idp.EditClaims = func(c jwt.MapClaims) {
+				if tt.verified == nil {
+					delete(c, "email_verified")
+				} else {
+					c["email_verified"] = tt.verified
+				}
+			}
+
+			rec := env.callback(env.authorize(t, "bob@example.com"))
+			if rec.Code != http.StatusOK {
+				t.Fatalf("got status %d: %s", rec.Code, rec.Body)
+			}
+			if len(env.accounts.logins) != 1 || env.accounts.logins[0].emailVerified != tt.want {
+				t.Fatalf("got logins %+v, want email_verified %v", env.accounts.logins, tt.want)
+			}
+		})
+	}
+}
+
+func TestCallbackReportsRefusedLogin(t *testing.T) {
+	env := newTestEnv(t)
+	env.accounts.err = errors.New("provider did not verify the email")
+
+	rec := env.callback(env.authorize(t, "bob@example.com"))
+	if rec.Code != http.StatusUnauthorized {
+		t.Fatalf("got status %d, want 401: %s", rec.Code, rec.Body)
+	}
+}
@@ -0,0 +1,131 @@
+// Package mockidp is a minimal OpenID Connect provider for trying federated
+// login locally and for tests. It signs in whoever is named by the login_hint
+// parameter without asking for a password, so it must never be exposed
+// outside a dev machine.
+package mockidp
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/bekbek22/auth_service/internal/utils"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// DefaultEmail is who signs in when the authorization request has no login_hint.
+const DefaultEmail = "alice@example.com"
+
+type pendingCode struct {
+	clientID string
+	nonce    string
+	email    string
+}
+
+// IdP serves discovery, JWKS, authorization and token endpoints. Set Issuer
+// to the URL it is served at before the first request.
+type IdP struct {
+	Issuer string
+
+	// EditClaims, if set, changes the claims of each ID token before it is
+	// signed, e.g. to test how relying parties handle bad tokens.
+	EditClaims func(claims jwt.MapClaims)
+	// SignWith, if set, signs ID tokens with a key other than the published one.
+	SignWith *utils.SigningKey
+
+	key *utils.SigningKey
+
+	mu    sync.Mutex
+	codes map[string]pendingCode
+}
+
+func New(issuer string) (*IdP, error) {
+	key, err := utils.LoadSigningKey("")
+	if err != nil {
+		return nil, err
+	}
+	return &IdP{Issuer: issuer, key: key, codes: map[string]pendingCode{}}, nil
+}
+
+func writeJSON(w http.ResponseWriter, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(body)
+}
+
+func (p *IdP) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, map[string]interface{}{
+			"issuer":                 p.Issuer,
+			"authorization_endpoint": p.Issuer + "/authorize",
+			"token_endpoint":         p.Issuer + "/token",
+			"jwks_uri":               p.Issuer + "/jwks",
+		})
+	})
+	mux.HandleFunc("GET /jwks", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, p.key.JWKS())
+	})
+	mux.HandleFunc("GET /authorize", p.handleAuthorize)
+	mux.HandleFunc("POST /token", p.handleToken)
+	return mux
+}
+
+func (p *IdP) handleAuthorize(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	email := q.Get("login_hint")
+	if email == "" {
+		email = DefaultEmail
+	}
+	code, _ := utils.GenerateOpaqueToken(16)
+
+	p.mu.Lock()
+	p.codes[code] = pendingCode{clientID: q.Get("client_id"), nonce: q.Get("nonce"), email: email}
+	p.mu.Unlock()
+
+	redirect := q.Get("redirect_uri") + "?" + url.Values{"code": {code}, "state": {q.Get("state")}}.Encode()
+	http.Redirect(w, r, redirect, http.StatusFound)
+}
+
+func (p *IdP) handleToken(w http.ResponseWriter, r *http.Request) {
+	p.mu.Lock()
+	pending, ok := p.codes[r.PostFormValue("code")]
+	delete(p.codes, r.PostFormValue("code"))
+	p.mu.Unlock()
+	if !ok {
+		w.WriteHeader(http.StatusBadRequest)
+		writeJSON(w, map[string]string{"error": "invalid_grant"})
+		return
+	}
+
+	now := time.Now()
+	claims := jwt.MapClaims{
+		"iss":            p.Issuer,
+		"sub":            "mock|" + pending.email,
+		"aud":            pending.clientID,
+		"iat":            now.Unix(),
+		"exp":            now.Add(5 * time.Minute).Unix(),
+		"nonce":          pending.nonce,
+		"email":          pending.email,
+		"email_verified": true,
+		"name":           pending.email,
+	}
+	if p.EditClaims != nil {
+		p.EditClaims(claims)
+	}
+	key := p.key
+	if p.SignWith != nil {
+		key = p.SignWith
+	}
+	idToken, err := key.Sign(claims)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, map[string]string{
+		"access_token": "mock",
+		"token_type":   "Bearer",
+		"id_token":     idToken,
+	})
+}
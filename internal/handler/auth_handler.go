@@ -2,9 +2,11 @@ package handler
 
 import (
 	"context"
+	"slices"
 
 	pb "github.com/bekbek22/auth_service/api/proto"
 	"github.com/bekbek22/auth_service/internal/middleware"
+	"github.com/bekbek22/auth_service/internal/repository"
 	"github.com/bekbek22/auth_service/internal/service"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
@@ -17,9 +19,209 @@ type IAuthHandler interface {
 	ListUsers(ctx context.Context, req *pb.ListUsersRequest) (*pb.ListUsersResponse, error)
 	GetProfile(ctx context.Context, req *pb.GetProfileRequest) (*pb.GetProfileResponse, error)
 	UpdateProfile(ctx context.Context, req *pb.UpdateProfileRequest) (*pb.UpdateProfileResponse, error)
+	ConfirmEmailChange(ctx context.Context, req *pb.ConfirmEmailChangeRequest) (*pb.ConfirmEmailChangeResponse, error)
+	RevertEmailChange(ctx context.Context, req *pb.RevertEmailChangeRequest) (*pb.RevertEmailChangeResponse, error)
 	DeleteProfile(ctx context.Context, req *pb.DeleteProfileRequest) (*pb.DeleteProfileResponse, error)
+	ExportMyData(ctx context.Context, req *pb.ExportMyDataRequest) (*pb.ExportMyDataResponse, error)
 	RequestPasswordReset(ctx context.Context, req *pb.RequestPasswordResetRequest) (*pb.RequestPasswordResetResponse, error)
 	ResetPassword(ctx context.Context, req *pb.ResetPasswordRequest) (*pb.ResetPasswordResponse, error)
+	ListAuditEvents(ctx context.Context, req *pb.ListAuditEventsRequest) (*pb.ListAuditEventsResponse, error)
+	ListMySessions(ctx context.Context, req *pb.ListMySessionsRequest) (*pb.ListMySessionsResponse, error)
+	RevokeSession(ctx context.Context, req *pb.RevokeSessionRequest) (*pb.RevokeSessionResponse, error)
+	CreateClient(ctx context.Context, req *pb.CreateClientRequest) (*pb.CreateClientResponse, error)
+	GetClient(ctx context.Context, req *pb.GetClientRequest) (*pb.OAuthClient, error)
+	ListClients(ctx context.Context, req *pb.ListClientsRequest) (*pb.ListClientsResponse, error)
+	UpdateClient(ctx context.Context, req *pb.UpdateClientRequest) (*pb.OAuthClient, error)
+	DeleteClient(ctx context.Context, req *pb.DeleteClientRequest) (*pb.DeleteClientResponse, error)
+	RotateClientSecret(ctx context.Context, req *pb.RotateClientSecretRequest) (*pb.RotateClientSecretResponse, error)
+	ListLoginMethods(ctx context.Context, req *pb.ListLoginMethodsRequest) (*pb.ListLoginMethodsResponse, error)
+	LinkLoginMethod(ctx context.Context, req *pb.LinkLoginMethodRequest) (*pb.LinkLoginMethodResponse, error)
+	UnlinkLoginMethod(ctx context.Context, req *pb.UnlinkLoginMethodRequest) (*pb.UnlinkLoginMethodResponse, error)
+	BeginPasskeyRegistration(ctx context.Context, req *pb.BeginPasskeyRegistrationRequest) (*pb.BeginPasskeyRegistrationResponse, error)
+	FinishPasskeyRegistration(ctx context.Context, req *pb.FinishPasskeyRegistrationRequest) (*pb.FinishPasskeyRegistrationResponse, error)
+	BeginPasskeyLogin(ctx context.Context, req *pb.BeginPasskeyLoginRequest) (*pb.BeginPasskeyLoginResponse, error)
+	FinishPasskeyLogin(ctx context.Context, req *pb.FinishPasskeyLoginRequest) (*pb.LoginResponse, error)
+	RequestMagicLink(ctx context.Context, req *pb.RequestMagicLinkRequest) (*pb.RequestMagicLinkResponse, error)
+	ConsumeMagicLink(ctx context.Context, req *pb.ConsumeMagicLinkRequest) (*pb.LoginResponse, error)
+	EnrollOTP(ctx context.Context, req *pb.EnrollOTPRequest) (*pb.EnrollOTPResponse, error)
+	ConfirmOTPEnrollment(ctx context.Context, req *pb.ConfirmOTPEnrollmentRequest) (*pb.ConfirmOTPEnrollmentResponse, error)
+	DisableOTP(ctx context.Context, req *pb.DisableOTPRequest) (*pb.DisableOTPResponse, error)
+	SendLoginOTP(ctx context.Context, req *pb.SendLoginOTPRequest) (*pb.SendLoginOTPResponse, error)
+	VerifyLoginOTP(ctx context.Context, req *pb.VerifyLoginOTPRequest) (*pb.LoginResponse, error)
+	CreateServiceAccount(ctx context.Context, req *pb.CreateServiceAccountRequest) (*pb.ServiceAccount, error)
+	ListServiceAccounts(ctx context.Context, req *pb.ListServiceAccountsRequest) (*pb.ListServiceAccountsResponse, error)
+	CreateAPIKey(ctx context.Context, req *pb.CreateAPIKeyRequest) (*pb.CreateAPIKeyResponse, error)
+	ListAPIKeys(ctx context.Context, req *pb.ListAPIKeysRequest) (*pb.ListAPIKeysResponse, error)
+	RotateAPIKey(ctx context.Context, req *pb.RotateAPIKeyRequest) (*pb.CreateAPIKeyResponse, error)
+	RevokeAPIKey(ctx context.Context, req *pb.RevokeAPIKeyRequest) (*pb.RevokeAPIKeyResponse, error)
+	CreatePersonalAccessToken(ctx context.Context, req *pb.CreatePersonalAccessTokenRequest) (*pb.CreatePersonalAccessTokenResponse, error)
+	ListPersonalAccessTokens(ctx context.Context, req *pb.ListPersonalAccessTokensRequest) (*pb.ListPersonalAccessTokensResponse, error)
+	RevokePersonalAccessToken(ctx context.Context, req *pb.RevokePersonalAccessTokenRequest) (*pb.RevokePersonalAccessTokenResponse, error)
+	IntrospectToken(ctx context.Context, req *pb.IntrospectTokenRequest) (*pb.TokenInfo, error)
+	ValidateToken(ctx context.Context, req *pb.ValidateTokenRequest) (*pb.TokenInfo, error)
+	ImpersonateUser(ctx context.Context, req *pb.ImpersonateUserRequest) (*pb.LoginResponse, error)
+	CreateTenant(ctx context.Context, req *pb.CreateTenantRequest) (*pb.Tenant, error)
+	GetTenant(ctx context.Context, req *pb.GetTenantRequest) (*pb.Tenant, error)
+	ListTenants(ctx context.Context, req *pb.ListTenantsRequest) (*pb.ListTenantsResponse, error)
+	UpdateTenant(ctx context.Context, req *pb.UpdateTenantRequest) (*pb.Tenant, error)
+	DeleteTenant(ctx context.Context, req *pb.DeleteTenantRequest) (*pb.DeleteTenantResponse, error)
+	CreateOrganization(ctx context.Context, req *pb.CreateOrganizationRequest) (*pb.Organization, error)
+	ListMyOrganizations(ctx context.Context, req *pb.ListMyOrganizationsRequest) (*pb.ListMyOrganizationsResponse, error)
+	DeleteOrganization(ctx context.Context, req *pb.DeleteOrganizationRequest) (*pb.DeleteOrganizationResponse, error)
+	ListOrganizationMembers(ctx context.Context, req *pb.ListOrganizationMembersRequest) (*pb.ListOrganizationMembersResponse, error)
+	UpdateMemberRole(ctx context.Context, req *pb.UpdateMemberRoleRequest) (*pb.UpdateMemberRoleResponse, error)
+	RemoveMember(ctx context.Context, req *pb.RemoveMemberRequest) (*pb.RemoveMemberResponse, error)
+	InviteMember(ctx context.Context, req *pb.InviteMemberRequest) (*pb.Invitation, error)
+	ListInvitations(ctx context.Context, req *pb.ListInvitationsRequest) (*pb.ListInvitationsResponse, error)
+	RevokeInvitation(ctx context.Context, req *pb.RevokeInvitationRequest) (*pb.RevokeInvitationResponse, error)
+	AcceptInvitation(ctx context.Context, req *pb.AcceptInvitationRequest) (*pb.AcceptInvitationResponse, error)
+	SwitchOrganization(ctx context.Context, req *pb.SwitchOrganizationRequest) (*pb.LoginResponse, error)
+	CreateGroup(ctx context.Context, req *pb.CreateGroupRequest) (*pb.Group, error)
+	ListGroups(ctx context.Context, req *pb.ListGroupsRequest) (*pb.ListGroupsResponse, error)
+	UpdateGroup(ctx context.Context, req *pb.UpdateGroupRequest) (*pb.Group, error)
+	DeleteGroup(ctx context.Context, req *pb.DeleteGroupRequest) (*pb.DeleteGroupResponse, error)
+	AddGroupMember(ctx context.Context, req *pb.AddGroupMemberRequest) (*pb.AddGroupMemberResponse, error)
+	RemoveGroupMember(ctx context.Context, req *pb.RemoveGroupMemberRequest) (*pb.RemoveGroupMemberResponse, error)
+	ListGroupMembers(ctx context.Context, req *pb.ListGroupMembersRequest) (*pb.ListGroupMembersResponse, error)
+	GetEffectivePermissions(ctx context.Context, req *pb.GetEffectivePermissionsRequest) (*pb.GetEffectivePermissionsResponse, error)
+	CheckPermission(ctx context.Context, req *pb.CheckPermissionRequest) (*pb.PermissionDecision, error)
+	CheckPermissions(ctx context.Context, req *pb.CheckPermissionsRequest) (*pb.CheckPermissionsResponse, error)
+	CreatePolicy(ctx context.Context, req *pb.CreatePolicyRequest) (*pb.Policy, error)
+	ListPolicies(ctx context.Context, req *pb.ListPoliciesRequest) (*pb.ListPoliciesResponse, error)
+	UpdatePolicy(ctx context.Context, req *pb.UpdatePolicyRequest) (*pb.Policy, error)
+	DeletePolicy(ctx context.Context, req *pb.DeletePolicyRequest) (*pb.DeletePolicyResponse, error)
+}
+
+// PublicMethods are the RPCs the auth interceptor lets through without a token.
+var PublicMethods = []string{
+	pb.AuthService_Register_FullMethodName,
+	pb.AuthService_Login_FullMethodName,
+	pb.AuthService_Logout_FullMethodName,
+	pb.AuthService_RequestPasswordReset_FullMethodName,
+	pb.AuthService_ResetPassword_FullMethodName,
+	pb.AuthService_ConfirmEmailChange_FullMethodName,
+	pb.AuthService_RevertEmailChange_FullMethodName,
+	pb.AuthService_BeginPasskeyLogin_FullMethodName,
+	pb.AuthService_FinishPasskeyLogin_FullMethodName,
+	pb.AuthService_RequestMagicLink_FullMethodName,
+	pb.AuthService_ConsumeMagicLink_FullMethodName,
+	pb.AuthService_SendLoginOTP_FullMethodName,
+	pb.AuthService_VerifyLoginOTP_FullMethodName,
+	pb.AuthService_AcceptInvitation_FullMethodName,
+}
+
+// SensitiveMethods can't be called with a personal access token: they change
+// how the user signs in, hand out credentials or delete data, so a leaked
+// token could take over the account.
+var SensitiveMethods = []string{
+	pb.AuthService_UpdateProfile_FullMethodName,
+	pb.AuthService_DeleteProfile_FullMethodName,
+	pb.AuthService_ExportMyData_FullMethodName,
+	pb.AuthService_LinkLoginMethod_FullMethodName,
+	pb.AuthService_UnlinkLoginMethod_FullMethodName,
+	pb.AuthService_BeginPasskeyRegistration_FullMethodName,
+	pb.AuthService_FinishPasskeyRegistration_FullMethodName,
+	pb.AuthService_EnrollOTP_FullMethodName,
+	pb.AuthService_ConfirmOTPEnrollment_FullMethodName,
+	pb.AuthService_DisableOTP_FullMethodName,
+	pb.AuthService_CreatePersonalAccessToken_FullMethodName,
+	pb.AuthService_ImpersonateUser_FullMethodName,
+	pb.AuthService_DeleteOrganization_FullMethodName,
+}
+
+// ImpersonationMethods are the only RPCs an impersonation token can call. They
+// read what the user sees, which is enough to reproduce an issue; nothing
+// that changes the user's account or organizations is allowed.
+var ImpersonationMethods = []string{
+	pb.AuthService_Logout_FullMethodName,
+	pb.AuthService_GetProfile_FullMethodName,
+	pb.AuthService_ListMySessions_FullMethodName,
+	pb.AuthService_ListLoginMethods_FullMethodName,
+	pb.AuthService_ListPersonalAccessTokens_FullMethodName,
+	pb.AuthService_ListMyOrganizations_FullMethodName,
+	pb.AuthService_ListOrganizationMembers_FullMethodName,
+	pb.AuthService_ListInvitations_FullMethodName,
+	pb.AuthService_GetEffectivePermissions_FullMethodName,
+	pb.AuthService_CheckPermission_FullMethodName,
+	pb.AuthService_CheckPermissions_FullMethodName,
+}
+
+// RequiredScopes lists, for each RPC, the scopes that let a scoped credential
+// call it: a login token needs one of its login scopes, an API key or a
+// personal access token one of its key scopes. RPCs missing here can't be
+// called with a scoped credential at all.
+var RequiredScopes = map[string][]string{
+	pb.AuthService_GetProfile_FullMethodName:                {service.ScopeProfileRead, service.ScopeAccount},
+	pb.AuthService_ExportMyData_FullMethodName:              {service.ScopeProfileRead, service.ScopeAccount},
+	pb.AuthService_ListMySessions_FullMethodName:            {service.ScopeProfileRead, service.ScopeAccount},
+	pb.AuthService_ListLoginMethods_FullMethodName:          {service.ScopeProfileRead, service.ScopeAccount},
+	pb.AuthService_ListPersonalAccessTokens_FullMethodName:  {service.ScopeProfileRead, service.ScopeAccount},
+	pb.AuthService_GetEffectivePermissions_FullMethodName:   {service.ScopeProfileRead, service.ScopeAccount},
+	pb.AuthService_UpdateProfile_FullMethodName:             {service.ScopeProfileWrite, service.ScopeAccount},
+	pb.AuthService_DeleteProfile_FullMethodName:             {service.ScopeProfileWrite, service.ScopeAccount},
+	pb.AuthService_RevokeSession_FullMethodName:             {service.ScopeProfileWrite, service.ScopeAccount},
+	pb.AuthService_LinkLoginMethod_FullMethodName:           {service.ScopeProfileWrite, service.ScopeAccount},
+	pb.AuthService_UnlinkLoginMethod_FullMethodName:         {service.ScopeProfileWrite, service.ScopeAccount},
+	pb.AuthService_BeginPasskeyRegistration_FullMethodName:  {service.ScopeProfileWrite, service.ScopeAccount},
+	pb.AuthService_FinishPasskeyRegistration_FullMethodName: {service.ScopeProfileWrite, service.ScopeAccount},
+	pb.AuthService_EnrollOTP_FullMethodName:                 {service.ScopeProfileWrite, service.ScopeAccount},
+	pb.AuthService_ConfirmOTPEnrollment_FullMethodName:      {service.ScopeProfileWrite, service.ScopeAccount},
+	pb.AuthService_DisableOTP_FullMethodName:                {service.ScopeProfileWrite, service.ScopeAccount},
+	pb.AuthService_CreatePersonalAccessToken_FullMethodName: {service.ScopeProfileWrite, service.ScopeAccount},
+	pb.AuthService_RevokePersonalAccessToken_FullMethodName: {service.ScopeProfileWrite, service.ScopeAccount},
+
+	pb.AuthService_ListUsers_FullMethodName:       {service.ScopeUsersList, service.ScopeUsersRead},
+	pb.AuthService_ImpersonateUser_FullMethodName: {service.PermUsersImpersonate},
+	pb.AuthService_ListAuditEvents_FullMethodName: {service.ScopeAuditRead},
+
+	pb.AuthService_GetClient_FullMethodName:          {service.ScopeClientsRead},
+	pb.AuthService_ListClients_FullMethodName:        {service.ScopeClientsRead},
+	pb.AuthService_CreateClient_FullMethodName:       {service.ScopeClientsWrite},
+	pb.AuthService_UpdateClient_FullMethodName:       {service.ScopeClientsWrite},
+	pb.AuthService_DeleteClient_FullMethodName:       {service.ScopeClientsWrite},
+	pb.AuthService_RotateClientSecret_FullMethodName: {service.ScopeClientsWrite},
+
+	pb.AuthService_IntrospectToken_FullMethodName: {service.ScopeTokensIntrospect},
+	pb.AuthService_ValidateToken_FullMethodName:   {service.ScopeTokensIntrospect},
+
+	pb.AuthService_ListServiceAccounts_FullMethodName:  {service.ScopeServiceAccountsRead},
+	pb.AuthService_ListAPIKeys_FullMethodName:          {service.ScopeServiceAccountsRead},
+	pb.AuthService_CreateServiceAccount_FullMethodName: {service.ScopeServiceAccountsWrite},
+	pb.AuthService_CreateAPIKey_FullMethodName:         {service.ScopeServiceAccountsWrite},
+	pb.AuthService_RotateAPIKey_FullMethodName:         {service.ScopeServiceAccountsWrite},
+	pb.AuthService_RevokeAPIKey_FullMethodName:         {service.ScopeServiceAccountsWrite},
+
+	pb.AuthService_GetTenant_FullMethodName:    {service.ScopeTenantsRead},
+	pb.AuthService_ListTenants_FullMethodName:  {service.ScopeTenantsRead},
+	pb.AuthService_CreateTenant_FullMethodName: {service.ScopeTenantsWrite},
+	pb.AuthService_UpdateTenant_FullMethodName: {service.ScopeTenantsWrite},
+	pb.AuthService_DeleteTenant_FullMethodName: {service.ScopeTenantsWrite},
+
+	pb.AuthService_ListMyOrganizations_FullMethodName:     {service.ScopeOrganizationsRead, service.ScopeAccount},
+	pb.AuthService_ListOrganizationMembers_FullMethodName: {service.ScopeOrganizationsRead, service.ScopeAccount},
+	pb.AuthService_ListInvitations_FullMethodName:         {service.ScopeOrganizationsRead, service.ScopeAccount},
+	pb.AuthService_SwitchOrganization_FullMethodName:      {service.ScopeOrganizationsRead, service.ScopeAccount},
+	pb.AuthService_CreateOrganization_FullMethodName:      {service.ScopeOrganizationsWrite, service.ScopeAccount},
+	pb.AuthService_DeleteOrganization_FullMethodName:      {service.ScopeOrganizationsWrite, service.ScopeAccount},
+	pb.AuthService_UpdateMemberRole_FullMethodName:        {service.ScopeOrganizationsWrite, service.ScopeAccount},
+	pb.AuthService_RemoveMember_FullMethodName:            {service.ScopeOrganizationsWrite, service.ScopeAccount},
+	pb.AuthService_InviteMember_FullMethodName:            {service.ScopeOrganizationsWrite, service.ScopeAccount},
+	pb.AuthService_RevokeInvitation_FullMethodName:        {service.ScopeOrganizationsWrite, service.ScopeAccount},
+
+	pb.AuthService_ListGroups_FullMethodName:        {service.ScopeGroupsRead},
+	pb.AuthService_ListGroupMembers_FullMethodName:  {service.ScopeGroupsRead},
+	pb.AuthService_CreateGroup_FullMethodName:       {service.ScopeGroupsWrite},
+	pb.AuthService_UpdateGroup_FullMethodName:       {service.ScopeGroupsWrite},
+	pb.AuthService_DeleteGroup_FullMethodName:       {service.ScopeGroupsWrite},
+	pb.AuthService_AddGroupMember_FullMethodName:    {service.ScopeGroupsWrite},
+	pb.AuthService_RemoveGroupMember_FullMethodName: {service.ScopeGroupsWrite},
+
+	pb.AuthService_CheckPermission_FullMethodName:  {service.ScopeAuthzCheck, service.ScopeAccount},
+	pb.AuthService_CheckPermissions_FullMethodName: {service.ScopeAuthzCheck, service.ScopeAccount},
+	pb.AuthService_ListPolicies_FullMethodName:     {service.ScopePoliciesRead},
+	pb.AuthService_CreatePolicy_FullMethodName:     {service.ScopePoliciesWrite},
+	pb.AuthService_UpdatePolicy_FullMethodName:     {service.ScopePoliciesWrite},
+	pb.AuthService_DeletePolicy_FullMethodName:     {service.ScopePoliciesWrite},
 }
 
 type AuthHandler struct {
@@ -31,6 +233,120 @@ func NewAuthHandler(s *service.AuthService) *AuthHandler {
 	return &AuthHandler{service: s}
 }
 
+// userIDFromContext returns the caller's user ID from the claims stored by the
+// auth interceptor.
+func userIDFromContext(ctx context.Context) (string, error) {
+	claims, ok := middleware.ClaimsFromContext(ctx)
+	if !ok {
+		return "", status.Errorf(codes.Unauthenticated, "missing token")
+	}
+	if p, _ := middleware.PrincipalFromContext(ctx); p.Kind == middleware.PrincipalServiceAccount {
+		return "", status.Errorf(codes.PermissionDenied, "not available to service accounts")
+	}
+	if scopes, ok := personalTokenScopes(ctx); ok && !slices.Contains(scopes, service.ScopeAccount) {
+		return "", status.Errorf(codes.PermissionDenied, "token lacks scope %s", service.ScopeAccount)
+	}
+
+	return claims.Subject, nil
+}
+
+// personalTokenScopes returns the scopes of the personal access token the
+// call was made with; ok is false for any other credential.
+func personalTokenScopes(ctx context.Context) ([]string, bool) {
+	claims, _ := middleware.ClaimsFromContext(ctx)
+	if claims == nil || claims.PersonalTokenID == "" {
+		return nil, false
+	}
+	return claims.Scopes(), true
+}
+
+// requireAdmin lets platform admins through: admins of the default tenant,
+// directly or through a group. Tenant admins only manage their own tenant's
+// users; see requireTenantAdmin.
+func requireAdmin(ctx context.Context) error {
+	claims, ok := middleware.ClaimsFromContext(ctx)
+	if !ok {
+		return status.Errorf(codes.Unauthenticated, "missing token")
+	}
+
+	if !claims.HasRole("admin") {
+		return status.Errorf(codes.PermissionDenied, "admin access only")
+	}
+	if claims.TenantID != "" {
+		return status.Errorf(codes.PermissionDenied, "platform admin access only")
+	}
+	if _, ok := personalTokenScopes(ctx); ok {
+		return status.Errorf(codes.PermissionDenied, "not available to personal access tokens")
+	}
+	return nil
+}
+
+// requireTenantAdmin lets admins of any tenant through and returns the tenant
+// they administer, "" for platform admins.
+func requireTenantAdmin(ctx context.Context) (string, error) {
+	claims, ok := middleware.ClaimsFromContext(ctx)
+	if !ok {
+		return "", status.Errorf(codes.Unauthenticated, "missing token")
+	}
+
+	if !claims.HasRole("admin") {
+		return "", status.Errorf(codes.PermissionDenied, "admin access only")
+	}
+	if _, ok := personalTokenScopes(ctx); ok {
+		return "", status.Errorf(codes.PermissionDenied, "not available to personal access tokens")
+	}
+	return claims.TenantID, nil
+}
+
+// requireTenantPermission is requireTenantAdmin for users whose roles grant
+// perm, such as support staff.
+func requireTenantPermission(ctx context.Context, perm string) (string, error) {
+	claims, ok := middleware.ClaimsFromContext(ctx)
+	if !ok {
+		return "", status.Errorf(codes.Unauthenticated, "missing token")
+	}
+
+	if p, _ := middleware.PrincipalFromContext(ctx); p.Kind == middleware.PrincipalServiceAccount {
+		return "", status.Errorf(codes.PermissionDenied, "not available to service accounts")
+	}
+	if !service.HasPermission(claims, perm) {
+		return "", status.Errorf(codes.PermissionDenied, "requires permission %s", perm)
+	}
+	if _, ok := personalTokenScopes(ctx); ok {
+		return "", status.Errorf(codes.PermissionDenied, "not available to personal access tokens")
+	}
+	return claims.TenantID, nil
+}
+
+// requireAdminOrScope lets platform users whose roles grant scope through,
+// as well as service accounts and such users' personal access tokens that
+// were granted scope.
+func requireAdminOrScope(ctx context.Context, scope string) error {
+	claims, ok := middleware.ClaimsFromContext(ctx)
+	if !ok {
+		return status.Errorf(codes.Unauthenticated, "missing token")
+	}
+
+	var granted []string
+	if p, _ := middleware.PrincipalFromContext(ctx); p.Kind == middleware.PrincipalServiceAccount {
+		granted = claims.Scopes()
+	} else if scopes, ok := personalTokenScopes(ctx); ok {
+		if !service.HasPermission(claims, scope) || claims.TenantID != "" {
+			return status.Errorf(codes.PermissionDenied, "platform admin access only")
+		}
+		granted = scopes
+	} else if service.HasPermission(claims, scope) && claims.TenantID == "" {
+		return nil
+	} else {
+		return requireAdmin(ctx)
+	}
+
+	if !slices.Contains(granted, scope) {
+		return status.Errorf(codes.PermissionDenied, "credential lacks scope %s", scope)
+	}
+	return nil
+}
+
 func (h *AuthHandler) Register(ctx context.Context, req *pb.RegisterRequest) (*pb.RegisterResponse, error) {
 	err := h.service.Register(ctx, req.Name, req.Email, req.Password)
 	if err != nil {
@@ -40,14 +356,27 @@ func (h *AuthHandler) Register(ctx context.Context, req *pb.RegisterRequest) (*p
 }
 
 func (c *AuthHandler) Login(ctx context.Context, req *pb.LoginRequest) (*pb.LoginResponse, error) {
-	token, err := c.service.Login(ctx, req.Email, req.Password)
+	result, err := c.service.Login(ctx, req.Email, req.Password, req.DeviceName, req.ClientId, req.Scopes)
 	if err != nil {
 		return nil, status.Errorf(codes.Unauthenticated, "Login failed: %v", err)
 	}
 
-	return &pb.LoginResponse{
-		AccessToken: token,
-	}, nil
+	return toLoginResponse(result), nil
+}
+
+func toLoginResponse(result *service.LoginResult) *pb.LoginResponse {
+	resp := &pb.LoginResponse{
+		AccessToken: result.Token,
+		MfaRequired: result.MFARequired,
+		MfaToken:    result.MFAToken,
+		MfaMethods:  result.MFAMethods,
+	}
+	if result.Token != "" {
+		resp.ExpiresIn = result.ExpiresIn
+		resp.TokenType = "Bearer"
+		resp.Scope = result.Scope
+	}
+	return resp
 }
 
 func (h *AuthHandler) Logout(ctx context.Context, req *pb.LogoutRequest) (*pb.LogoutResponse, error) {
@@ -58,21 +387,35 @@ func (h *AuthHandler) Logout(ctx context.Context, req *pb.LogoutRequest) (*pb.Lo
 	return &pb.LogoutResponse{Message: "Logged out successfully"}, nil
 }
 
-func (h *AuthHandler) ListUsers(ctx context.Context, req *pb.ListUsersRequest) (*pb.ListUsersResponse, error) {
-	//Extract & Validate JWT
-	tokenStr, err := middleware.ExtractTokenFromContext(ctx)
-	if err != nil {
-		return nil, status.Errorf(codes.Unauthenticated, "missing or invalid token: %v", err)
+// listUsersTenant returns the tenant whose users the caller may list. Tenant
+// admins and support staff only see their own; platform admins and service
+// accounts see the default tenant unless they name another.
+func listUsersTenant(ctx context.Context, requested string) (string, error) {
+	claims, ok := middleware.ClaimsFromContext(ctx)
+	if !ok {
+		return "", status.Errorf(codes.Unauthenticated, "missing token")
 	}
 
-	claims, err := middleware.ValidateJWT(tokenStr, h.service.Cfg.JWTSecret)
-	if err != nil {
-		return nil, status.Errorf(codes.Unauthenticated, "token invalid: %v", err)
+	if claims.TenantID != "" && service.HasPermission(claims, service.ScopeUsersRead) {
+		if scopes, ok := personalTokenScopes(ctx); ok && !slices.Contains(scopes, service.ScopeUsersRead) {
+			return "", status.Errorf(codes.PermissionDenied, "credential lacks scope %s", service.ScopeUsersRead)
+		}
+		if requested != "" && requested != claims.TenantID {
+			return "", status.Errorf(codes.PermissionDenied, "tenant admins can only list their own tenant")
+		}
+		return claims.TenantID, nil
 	}
 
-	//Check role
-	if role, ok := claims["role"].(string); !ok || role != "admin" {
-		return nil, status.Errorf(codes.PermissionDenied, "admin access only")
+	if err := requireAdminOrScope(ctx, service.ScopeUsersRead); err != nil {
+		return "", err
+	}
+	return requested, nil
+}
+
+func (h *AuthHandler) ListUsers(ctx context.Context, req *pb.ListUsersRequest) (*pb.ListUsersResponse, error) {
+	tenantID, err := listUsersTenant(ctx, req.TenantId)
+	if err != nil {
+		return nil, err
 	}
 
 	// Query users
@@ -83,7 +426,7 @@ func (h *AuthHandler) ListUsers(ctx context.Context, req *pb.ListUsersRequest) (
 		req.Limit = 10
 	}
 
-	users, total, err := h.service.ListUsers(ctx, req.Name, req.Email, req.Page, req.Limit)
+	users, total, err := h.service.ListUsers(ctx, tenantID, req.Name, req.Email, req.Page, req.Limit)
 	if err != nil {
 		return nil, status.Errorf(codes.Internal, "failed to list users: %v", err)
 	}
@@ -91,10 +434,11 @@ func (h *AuthHandler) ListUsers(ctx context.Context, req *pb.ListUsersRequest) (
 	var items []*pb.UserItem
 	for _, u := range users {
 		items = append(items, &pb.UserItem{
-			Id:    u.ID.Hex(),
-			Name:  u.Name,
-			Email: u.Email,
-			Role:  u.Role,
+			Id:       u.ID.Hex(),
+			Name:     u.Name,
+			Email:    u.Email,
+			Role:     u.Role,
+			TenantId: u.TenantID,
 		})
 	}
 
@@ -105,19 +449,9 @@ func (h *AuthHandler) ListUsers(ctx context.Context, req *pb.ListUsersRequest) (
 }
 
 func (h *AuthHandler) GetProfile(ctx context.Context, req *pb.GetProfileRequest) (*pb.GetProfileResponse, error) {
-	tokenStr, err := middleware.ExtractTokenFromContext(ctx)
+	userID, err := userIDFromContext(ctx)
 	if err != nil {
-		return nil, status.Errorf(codes.Unauthenticated, "missing token")
-	}
-
-	claims, err := middleware.ValidateJWT(tokenStr, h.service.Cfg.JWTSecret)
-	if err != nil {
-		return nil, status.Errorf(codes.Unauthenticated, "invalid token")
-	}
-
-	userID, ok := claims["user_id"].(string)
-	if !ok {
-		return nil, status.Errorf(codes.Internal, "missing user_id in token")
+		return nil, err
 	}
 
 	user, err := h.service.GetProfile(ctx, userID)
@@ -126,54 +460,59 @@ func (h *AuthHandler) GetProfile(ctx context.Context, req *pb.GetProfileRequest)
 	}
 
 	return &pb.GetProfileResponse{
-		Id:    user.ID.Hex(),
-		Name:  user.Name,
-		Email: user.Email,
-		Role:  user.Role,
+		Id:       user.ID.Hex(),
+		Name:     user.Name,
+		Email:    user.Email,
+		Role:     user.Role,
+		TenantId: user.TenantID,
 	}, nil
 }
 
 func (h *AuthHandler) UpdateProfile(ctx context.Context, req *pb.UpdateProfileRequest) (*pb.UpdateProfileResponse, error) {
-	//Extract token & get user_id
-	tokenStr, err := middleware.ExtractTokenFromContext(ctx)
-	if err != nil {
-		return nil, status.Errorf(codes.Unauthenticated, "missing token")
-	}
-
-	claims, err := middleware.ValidateJWT(tokenStr, h.service.Cfg.JWTSecret)
+	userID, err := userIDFromContext(ctx)
 	if err != nil {
-		return nil, status.Errorf(codes.Unauthenticated, "invalid token")
-	}
-
-	userID, ok := claims["user_id"].(string)
-	if !ok {
-		return nil, status.Errorf(codes.Internal, "missing user_id in token")
+		return nil, err
 	}
 
-	err = h.service.UpdateProfile(ctx, userID, req.Name, req.Email)
+	pending, err := h.service.UpdateProfile(ctx, userID, req.Name, req.Email)
 	if err != nil {
 		return nil, status.Errorf(codes.InvalidArgument, "update failed: %v", err)
 	}
 
+	message := "Profile updated successfully"
+	if pending {
+		message = "Profile updated, check your new email to confirm the change"
+	}
 	return &pb.UpdateProfileResponse{
-		Message: "Profile updated successfully",
+		Message:            message,
+		EmailChangePending: pending,
 	}, nil
 }
 
-func (h *AuthHandler) DeleteProfile(ctx context.Context, req *pb.DeleteProfileRequest) (*pb.DeleteProfileResponse, error) {
-	tokenStr, err := middleware.ExtractTokenFromContext(ctx)
+func (h *AuthHandler) ConfirmEmailChange(ctx context.Context, req *pb.ConfirmEmailChangeRequest) (*pb.ConfirmEmailChangeResponse, error) {
+	err := h.service.ConfirmEmailChange(ctx, req.Token)
 	if err != nil {
-		return nil, status.Errorf(codes.Unauthenticated, "missing token")
+		return nil, status.Errorf(codes.InvalidArgument, "confirm failed: %v", err)
 	}
+	return &pb.ConfirmEmailChangeResponse{
+		Message: "Email changed successfully",
+	}, nil
+}
 
-	claims, err := middleware.ValidateJWT(tokenStr, h.service.Cfg.JWTSecret)
+func (h *AuthHandler) RevertEmailChange(ctx context.Context, req *pb.RevertEmailChangeRequest) (*pb.RevertEmailChangeResponse, error) {
+	err := h.service.RevertEmailChange(ctx, req.Token)
 	if err != nil {
-		return nil, status.Errorf(codes.Unauthenticated, "invalid token")
+		return nil, status.Errorf(codes.InvalidArgument, "revert failed: %v", err)
 	}
+	return &pb.RevertEmailChangeResponse{
+		Message: "Email change reverted",
+	}, nil
+}
 
-	userID, ok := claims["user_id"].(string)
-	if !ok {
-		return nil, status.Errorf(codes.Internal, "missing user_id in token")
+func (h *AuthHandler) DeleteProfile(ctx context.Context, req *pb.DeleteProfileRequest) (*pb.DeleteProfileResponse, error) {
+	userID, err := userIDFromContext(ctx)
+	if err != nil {
+		return nil, err
 	}
 
 	err = h.service.DeleteProfile(ctx, userID)
@@ -186,6 +525,22 @@ func (h *AuthHandler) DeleteProfile(ctx context.Context, req *pb.DeleteProfileRe
 	}, nil
 }
 
+func (h *AuthHandler) ExportMyData(ctx context.Context, req *pb.ExportMyDataRequest) (*pb.ExportMyDataResponse, error) {
+	userID, err := userIDFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := h.service.ExportMyData(ctx, userID)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to export data: %v", err)
+	}
+
+	return &pb.ExportMyDataResponse{
+		Data: string(data),
+	}, nil
+}
+
 func (h *AuthHandler) RequestPasswordReset(ctx context.Context, req *pb.RequestPasswordResetRequest) (*pb.RequestPasswordResetResponse, error) {
 	token, err := h.service.RequestPasswordReset(ctx, req.Email)
 	if err != nil {
@@ -205,3 +560,46 @@ func (h *AuthHandler) ResetPassword(ctx context.Context, req *pb.ResetPasswordRe
 		Message: "Password reset successfully",
 	}, nil
 }
+
+func (h *AuthHandler) ListAuditEvents(ctx context.Context, req *pb.ListAuditEventsRequest) (*pb.ListAuditEventsResponse, error) {
+	if err := requireAdminOrScope(ctx, service.ScopeAuditRead); err != nil {
+		return nil, err
+	}
+
+	if req.Limit <= 0 || req.Limit > 100 {
+		req.Limit = 50
+	}
+
+	filter := repository.AuditFilter{
+		ActorID:   req.ActorId,
+		SubjectID: req.SubjectId,
+		Action:    req.Action,
+		Outcome:   req.Outcome,
+		Since:     req.Since,
+		Until:     req.Until,
+	}
+	events, next, err := h.service.ListAuditEvents(ctx, filter, req.Cursor, int64(req.Limit))
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "failed to list audit events: %v", err)
+	}
+
+	items := make([]*pb.AuditEventItem, 0, len(events))
+	for _, e := range events {
+		items = append(items, &pb.AuditEventItem{
+			Id:        e.ID.Hex(),
+			ActorId:   e.ActorID,
+			SubjectId: e.SubjectID,
+			Action:    e.Action,
+			Outcome:   e.Outcome,
+			Ip:        e.IP,
+			UserAgent: e.UserAgent,
+			Details:   e.Details,
+			Timestamp: e.Timestamp,
+		})
+	}
+
+	return &pb.ListAuditEventsResponse{
+		Events:     items,
+		NextCursor: next,
+	}, nil
+}
@@ -0,0 +1,198 @@
+package handler
+
+import (
+	"context"
+
+	pb "github.com/bekbek22/auth_service/api/proto"
+	"github.com/bekbek22/auth_service/internal/middleware"
+	"github.com/bekbek22/auth_service/internal/model"
+	"github.com/bekbek22/auth_service/internal/policy"
+	"github.com/bekbek22/auth_service/internal/service"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func toPolicy(p *model.Policy) *pb.Policy {
+	conditions := &pb.PolicyConditions{
+		SameTenant: p.Conditions.SameTenant,
+		Owner:      p.Conditions.Owner,
+	}
+	if tw := p.Conditions.TimeOfDay; tw != nil {
+		conditions.TimeOfDay = &pb.TimeWindow{Start: tw.Start, End: tw.End, Timezone: tw.Timezone}
+	}
+	return &pb.Policy{
+		Id:          p.ID.Hex(),
+		Name:        p.Name,
+		Description: p.Description,
+		Effect:      p.Effect,
+		Roles:       p.Roles,
+		Actions:     p.Actions,
+		Resources:   p.Resources,
+		Conditions:  conditions,
+		CreatedAt:   p.CreatedAt,
+		UpdatedAt:   p.UpdatedAt,
+	}
+}
+
+func fromPolicy(p *pb.Policy) model.Policy {
+	if p == nil {
+		return model.Policy{}
+	}
+	result := model.Policy{
+		Name:        p.Name,
+		Description: p.Description,
+		Effect:      p.Effect,
+		Roles:       p.Roles,
+		Actions:     p.Actions,
+		Resources:   p.Resources,
+	}
+	if c := p.Conditions; c != nil {
+		result.Conditions.SameTenant = c.SameTenant
+		result.Conditions.Owner = c.Owner
+		if tw := c.TimeOfDay; tw != nil {
+			result.Conditions.TimeOfDay = &model.TimeWindow{Start: tw.Start, End: tw.End, Timezone: tw.Timezone}
+		}
+	}
+	return result
+}
+
+func fromPolicyResource(r *pb.PolicyResource) policy.Resource {
+	if r == nil {
+		return policy.Resource{}
+	}
+	return policy.Resource{Type: r.Type, ID: r.Id, TenantID: r.TenantId, OwnerID: r.OwnerId}
+}
+
+func toDecision(d policy.Decision) *pb.PermissionDecision {
+	return &pb.PermissionDecision{
+		Allowed:  d.Allowed,
+		PolicyId: d.PolicyID,
+		Reason:   d.Reason,
+	}
+}
+
+// permissionSubject resolves who a check is about: the caller when subjectID
+// is empty, otherwise any user, which takes the authz:check scope.
+func (h *AuthHandler) permissionSubject(ctx context.Context, subjectID string) (policy.Subject, error) {
+	if subjectID == "" {
+		claims, ok := middleware.ClaimsFromContext(ctx)
+		if !ok {
+			return policy.Subject{}, status.Errorf(codes.Unauthenticated, "missing token")
+		}
+		return service.SubjectFromClaims(claims), nil
+	}
+
+	if err := requireAdminOrScope(ctx, service.ScopeAuthzCheck); err != nil {
+		return policy.Subject{}, err
+	}
+	subject, err := h.service.ResolveSubject(ctx, subjectID)
+	if err != nil {
+		return policy.Subject{}, status.Errorf(codes.NotFound, "check permission failed: %v", err)
+	}
+	return subject, nil
+}
+
+func (h *AuthHandler) CheckPermission(ctx context.Context, req *pb.CheckPermissionRequest) (*pb.PermissionDecision, error) {
+	subject, err := h.permissionSubject(ctx, req.SubjectId)
+	if err != nil {
+		return nil, err
+	}
+
+	decisions, err := h.service.CheckPermissions(ctx, subject, []service.PermissionCheck{{
+		Action:   req.Action,
+		Resource: fromPolicyResource(req.Resource),
+	}})
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "check permission failed: %v", err)
+	}
+	return toDecision(decisions[0]), nil
+}
+
+func (h *AuthHandler) CheckPermissions(ctx context.Context, req *pb.CheckPermissionsRequest) (*pb.CheckPermissionsResponse, error) {
+	subject, err := h.permissionSubject(ctx, req.SubjectId)
+	if err != nil {
+		return nil, err
+	}
+
+	checks := make([]service.PermissionCheck, 0, len(req.Checks))
+	for _, c := range req.Checks {
+		checks = append(checks, service.PermissionCheck{
+			Action:   c.Action,
+			Resource: fromPolicyResource(c.Resource),
+		})
+	}
+	decisions, err := h.service.CheckPermissions(ctx, subject, checks)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "check permissions failed: %v", err)
+	}
+
+	items := make([]*pb.PermissionDecision, 0, len(decisions))
+	for _, d := range decisions {
+		items = append(items, toDecision(d))
+	}
+	return &pb.CheckPermissionsResponse{
+		Decisions: items,
+	}, nil
+}
+
+func (h *AuthHandler) CreatePolicy(ctx context.Context, req *pb.CreatePolicyRequest) (*pb.Policy, error) {
+	actorID, err := adminID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	p, err := h.service.CreatePolicy(ctx, actorID, fromPolicy(req.Policy))
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "create policy failed: %v", err)
+	}
+	return toPolicy(p), nil
+}
+
+func (h *AuthHandler) ListPolicies(ctx context.Context, req *pb.ListPoliciesRequest) (*pb.ListPoliciesResponse, error) {
+	if err := requireAdmin(ctx); err != nil {
+		return nil, err
+	}
+
+	policies, err := h.service.ListPolicies(ctx)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to list policies: %v", err)
+	}
+
+	items := make([]*pb.Policy, 0, len(policies))
+	for i := range policies {
+		items = append(items, toPolicy(&policies[i]))
+	}
+	return &pb.ListPoliciesResponse{
+		Policies: items,
+	}, nil
+}
+
+func (h *AuthHandler) UpdatePolicy(ctx context.Context, req *pb.UpdatePolicyRequest) (*pb.Policy, error) {
+	actorID, err := adminID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if req.Policy == nil {
+		return nil, status.Errorf(codes.InvalidArgument, "policy is required")
+	}
+
+	p, err := h.service.UpdatePolicy(ctx, actorID, req.Policy.Id, fromPolicy(req.Policy))
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "update policy failed: %v", err)
+	}
+	return toPolicy(p), nil
+}
+
+func (h *AuthHandler) DeletePolicy(ctx context.Context, req *pb.DeletePolicyRequest) (*pb.DeletePolicyResponse, error) {
+	actorID, err := adminID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := h.service.DeletePolicy(ctx, actorID, req.PolicyId); err != nil {
+		return nil, status.Errorf(codes.NotFound, "delete policy failed: %v", err)
+	}
+	return &pb.DeletePolicyResponse{
+		Message: "Policy deleted",
+	}, nil
+}
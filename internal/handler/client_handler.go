@@ -0,0 +1,152 @@
+package handler
+
+import (
+	"context"
+
+	pb "github.com/bekbek22/auth_service/api/proto"
+	"github.com/bekbek22/auth_service/internal/model"
+	"github.com/bekbek22/auth_service/internal/service"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func toClientItem(c *model.Client) *pb.OAuthClient {
+	return &pb.OAuthClient{
+		ClientId:     c.ClientID,
+		Name:         c.Name,
+		RedirectUris: c.RedirectURIs,
+		GrantTypes:   c.GrantTypes,
+		Scopes:       c.Scopes,
+		Confidential: c.SecretHash != "",
+		CreatedAt:    c.CreatedAt,
+		UpdatedAt:    c.UpdatedAt,
+
+		AccessTokenTtl:  c.AccessTokenTTL,
+		RefreshTokenTtl: c.RefreshTokenTTL,
+		TenantId:        c.TenantID,
+	}
+}
+
+// adminID checks the caller is a platform admin and returns their user ID for
+// auditing.
+func adminID(ctx context.Context) (string, error) {
+	if err := requireAdmin(ctx); err != nil {
+		return "", err
+	}
+	return userIDFromContext(ctx)
+}
+
+func (h *AuthHandler) CreateClient(ctx context.Context, req *pb.CreateClientRequest) (*pb.CreateClientResponse, error) {
+	actorID, err := adminID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	client, secret, err := h.service.CreateClient(ctx, actorID, service.ClientInput{
+		Name:         req.Name,
+		RedirectURIs: req.RedirectUris,
+		GrantTypes:   req.GrantTypes,
+		Scopes:       req.Scopes,
+
+		AccessTokenTTL:  req.AccessTokenTtl,
+		RefreshTokenTTL: req.RefreshTokenTtl,
+		TenantID:        req.TenantId,
+	}, req.Confidential)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "create client failed: %v", err)
+	}
+
+	return &pb.CreateClientResponse{
+		Client:       toClientItem(client),
+		ClientSecret: secret,
+	}, nil
+}
+
+func (h *AuthHandler) GetClient(ctx context.Context, req *pb.GetClientRequest) (*pb.OAuthClient, error) {
+	if err := requireAdminOrScope(ctx, service.ScopeClientsRead); err != nil {
+		return nil, err
+	}
+
+	client, err := h.service.GetClient(ctx, req.ClientId)
+	if err != nil {
+		return nil, status.Errorf(codes.NotFound, "client not found")
+	}
+	return toClientItem(client), nil
+}
+
+func (h *AuthHandler) ListClients(ctx context.Context, req *pb.ListClientsRequest) (*pb.ListClientsResponse, error) {
+	if err := requireAdminOrScope(ctx, service.ScopeClientsRead); err != nil {
+		return nil, err
+	}
+
+	if req.Page < 1 {
+		req.Page = 1
+	}
+	if req.Limit <= 0 {
+		req.Limit = 10
+	}
+
+	clients, total, err := h.service.ListClients(ctx, req.Page, req.Limit)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to list clients: %v", err)
+	}
+
+	items := make([]*pb.OAuthClient, 0, len(clients))
+	for i := range clients {
+		items = append(items, toClientItem(&clients[i]))
+	}
+	return &pb.ListClientsResponse{
+		Clients: items,
+		Total:   total,
+	}, nil
+}
+
+func (h *AuthHandler) UpdateClient(ctx context.Context, req *pb.UpdateClientRequest) (*pb.OAuthClient, error) {
+	actorID, err := adminID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	client, err := h.service.UpdateClient(ctx, actorID, req.ClientId, service.ClientInput{
+		Name:         req.Name,
+		RedirectURIs: req.RedirectUris,
+		GrantTypes:   req.GrantTypes,
+		Scopes:       req.Scopes,
+
+		AccessTokenTTL:  req.AccessTokenTtl,
+		RefreshTokenTTL: req.RefreshTokenTtl,
+	})
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "update client failed: %v", err)
+	}
+	return toClientItem(client), nil
+}
+
+func (h *AuthHandler) DeleteClient(ctx context.Context, req *pb.DeleteClientRequest) (*pb.DeleteClientResponse, error) {
+	actorID, err := adminID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := h.service.DeleteClient(ctx, actorID, req.ClientId); err != nil {
+		return nil, status.Errorf(codes.NotFound, "delete client failed: %v", err)
+	}
+	return &pb.DeleteClientResponse{
+		Message: "Client deleted",
+	}, nil
+}
+
+func (h *AuthHandler) RotateClientSecret(ctx context.Context, req *pb.RotateClientSecretRequest) (*pb.RotateClientSecretResponse, error) {
+	actorID, err := adminID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	secret, err := h.service.RotateClientSecret(ctx, actorID, req.ClientId)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "rotate secret failed: %v", err)
+	}
+	return &pb.RotateClientSecretResponse{
+		ClientSecret: secret,
+	}, nil
+}
@@ -0,0 +1,189 @@
+package handler
+
+import (
+	"context"
+
+	pb "github.com/bekbek22/auth_service/api/proto"
+	"github.com/bekbek22/auth_service/internal/model"
+	"github.com/bekbek22/auth_service/internal/service"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func toGroup(g *model.Group) *pb.Group {
+	return &pb.Group{
+		Id:          g.ID.Hex(),
+		Name:        g.Name,
+		Description: g.Description,
+		Roles:       g.Roles,
+		TenantId:    g.TenantID,
+		CreatedAt:   g.CreatedAt,
+		UpdatedAt:   g.UpdatedAt,
+	}
+}
+
+// groupAdmin checks the caller is an admin and returns their user ID and the
+// tenant whose groups they manage.
+func groupAdmin(ctx context.Context) (string, string, error) {
+	tenantID, err := requireTenantAdmin(ctx)
+	if err != nil {
+		return "", "", err
+	}
+	actorID, err := userIDFromContext(ctx)
+	if err != nil {
+		return "", "", err
+	}
+	return actorID, tenantID, nil
+}
+
+func (h *AuthHandler) CreateGroup(ctx context.Context, req *pb.CreateGroupRequest) (*pb.Group, error) {
+	actorID, tenantID, err := groupAdmin(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	group, err := h.service.CreateGroup(ctx, actorID, tenantID, service.GroupInput{
+		Name:        req.Name,
+		Description: req.Description,
+		Roles:       req.Roles,
+	})
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "create group failed: %v", err)
+	}
+	return toGroup(group), nil
+}
+
+func (h *AuthHandler) ListGroups(ctx context.Context, req *pb.ListGroupsRequest) (*pb.ListGroupsResponse, error) {
+	tenantID, err := requireTenantAdmin(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	groups, err := h.service.ListGroups(ctx, tenantID)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to list groups: %v", err)
+	}
+
+	items := make([]*pb.Group, 0, len(groups))
+	for i := range groups {
+		items = append(items, toGroup(&groups[i]))
+	}
+	return &pb.ListGroupsResponse{
+		Groups: items,
+	}, nil
+}
+
+func (h *AuthHandler) UpdateGroup(ctx context.Context, req *pb.UpdateGroupRequest) (*pb.Group, error) {
+	actorID, tenantID, err := groupAdmin(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	group, err := h.service.UpdateGroup(ctx, actorID, tenantID, req.GroupId, service.GroupInput{
+		Name:        req.Name,
+		Description: req.Description,
+		Roles:       req.Roles,
+	})
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "update group failed: %v", err)
+	}
+	return toGroup(group), nil
+}
+
+func (h *AuthHandler) DeleteGroup(ctx context.Context, req *pb.DeleteGroupRequest) (*pb.DeleteGroupResponse, error) {
+	actorID, tenantID, err := groupAdmin(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := h.service.DeleteGroup(ctx, actorID, tenantID, req.GroupId); err != nil {
+		return nil, status.Errorf(codes.NotFound, "delete group failed: %v", err)
+	}
+	return &pb.DeleteGroupResponse{
+		Message: "Group deleted",
+	}, nil
+}
+
+func (h *AuthHandler) AddGroupMember(ctx context.Context, req *pb.AddGroupMemberRequest) (*pb.AddGroupMemberResponse, error) {
+	actorID, tenantID, err := groupAdmin(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := h.service.AddGroupMember(ctx, actorID, tenantID, req.GroupId, req.UserId); err != nil {
+		return nil, status.Errorf(codes.NotFound, "add group member failed: %v", err)
+	}
+	return &pb.AddGroupMemberResponse{
+		Message: "Member added",
+	}, nil
+}
+
+func (h *AuthHandler) RemoveGroupMember(ctx context.Context, req *pb.RemoveGroupMemberRequest) (*pb.RemoveGroupMemberResponse, error) {
+	actorID, tenantID, err := groupAdmin(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := h.service.RemoveGroupMember(ctx, actorID, tenantID, req.GroupId, req.UserId); err != nil {
+		return nil, status.Errorf(codes.NotFound, "remove group member failed: %v", err)
+	}
+	return &pb.RemoveGroupMemberResponse{
+		Message: "Member removed",
+	}, nil
+}
+
+func (h *AuthHandler) ListGroupMembers(ctx context.Context, req *pb.ListGroupMembersRequest) (*pb.ListGroupMembersResponse, error) {
+	tenantID, err := requireTenantAdmin(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	members, err := h.service.ListGroupMembers(ctx, tenantID, req.GroupId)
+	if err != nil {
+		return nil, status.Errorf(codes.NotFound, "list group members failed: %v", err)
+	}
+
+	items := make([]*pb.GroupMember, 0, len(members))
+	for _, m := range members {
+		items = append(items, &pb.GroupMember{
+			UserId:  m.UserID,
+			Name:    m.Name,
+			Email:   m.Email,
+			AddedAt: m.AddedAt,
+		})
+	}
+	return &pb.ListGroupMembersResponse{
+		Members: items,
+	}, nil
+}
+
+// GetEffectivePermissions lets users see their own roles and permissions, and
+// admins those of their tenant's users.
+func (h *AuthHandler) GetEffectivePermissions(ctx context.Context, req *pb.GetEffectivePermissionsRequest) (*pb.GetEffectivePermissionsResponse, error) {
+	callerID, err := userIDFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	userID, tenantID := req.UserId, ""
+	if userID == "" || userID == callerID {
+		userID = callerID
+	} else if tenantID, err = requireTenantAdmin(ctx); err != nil {
+		return nil, err
+	}
+
+	perms, err := h.service.GetEffectivePermissions(ctx, tenantID, userID)
+	if err != nil {
+		return nil, status.Errorf(codes.NotFound, "get permissions failed: %v", err)
+	}
+
+	groups := make([]*pb.Group, 0, len(perms.Groups))
+	for i := range perms.Groups {
+		groups = append(groups, toGroup(&perms.Groups[i]))
+	}
+	return &pb.GetEffectivePermissionsResponse{
+		Roles:       perms.Roles,
+		Permissions: perms.Permissions,
+		Groups:      groups,
+	}, nil
+}
@@ -0,0 +1,30 @@
+package handler
+
+import (
+	"context"
+
+	pb "github.com/bekbek22/auth_service/api/proto"
+	"github.com/bekbek22/auth_service/internal/service"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// ImpersonateUser issues an admin or support user a short-lived token for
+// another user. It is blocked for impersonation tokens, so impersonation
+// can't chain.
+func (h *AuthHandler) ImpersonateUser(ctx context.Context, req *pb.ImpersonateUserRequest) (*pb.LoginResponse, error) {
+	tenantID, err := requireTenantPermission(ctx, service.PermUsersImpersonate)
+	if err != nil {
+		return nil, err
+	}
+	actorID, err := userIDFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := h.service.ImpersonateUser(ctx, actorID, tenantID, req.UserId, req.Reason)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "impersonate failed: %v", err)
+	}
+	return toLoginResponse(result), nil
+}
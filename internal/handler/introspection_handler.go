@@ -0,0 +1,56 @@
+package handler
+
+import (
+	"context"
+
+	pb "github.com/bekbek22/auth_service/api/proto"
+	"github.com/bekbek22/auth_service/internal/service"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func toTokenInfo(info *service.TokenInfo) *pb.TokenInfo {
+	return &pb.TokenInfo{
+		Active:    true,
+		TokenType: info.TokenType,
+		Subject:   info.Subject,
+		Role:      info.Role,
+		Roles:     info.Roles,
+		Scopes:    info.Scopes,
+		ClientId:  info.ClientID,
+		SessionId: info.SessionID,
+		ExpiresAt: info.ExpiresAt,
+		ActorId:   info.ActorID,
+		TenantId:  info.TenantID,
+		OrgId:     info.OrgID,
+		OrgRole:   info.OrgRole,
+	}
+}
+
+// IntrospectToken follows RFC 7662: an unusable token is reported as
+// inactive, without saying why.
+func (h *AuthHandler) IntrospectToken(ctx context.Context, req *pb.IntrospectTokenRequest) (*pb.TokenInfo, error) {
+	if err := requireAdminOrScope(ctx, service.ScopeTokensIntrospect); err != nil {
+		return nil, err
+	}
+
+	info, err := h.service.IntrospectToken(ctx, req.Token)
+	if err != nil {
+		return &pb.TokenInfo{Active: false}, nil
+	}
+	return toTokenInfo(info), nil
+}
+
+// ValidateToken fails with Unauthenticated, and the reason, for a token that
+// is not active.
+func (h *AuthHandler) ValidateToken(ctx context.Context, req *pb.ValidateTokenRequest) (*pb.TokenInfo, error) {
+	if err := requireAdminOrScope(ctx, service.ScopeTokensIntrospect); err != nil {
+		return nil, err
+	}
+
+	info, err := h.service.IntrospectToken(ctx, req.Token)
+	if err != nil {
+		return nil, status.Errorf(codes.Unauthenticated, "token invalid: %v", err)
+	}
+	return toTokenInfo(info), nil
+}
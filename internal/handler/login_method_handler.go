@@ -0,0 +1,88 @@
+package handler
+
+import (
+	"context"
+
+	pb "github.com/bekbek22/auth_service/api/proto"
+	"github.com/bekbek22/auth_service/internal/model"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func (h *AuthHandler) ListLoginMethods(ctx context.Context, req *pb.ListLoginMethodsRequest) (*pb.ListLoginMethodsResponse, error) {
+	userID, err := userIDFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	methods, err := h.service.ListLoginMethods(ctx, userID)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to list login methods: %v", err)
+	}
+
+	items := make([]*pb.LoginMethodItem, 0, len(methods))
+	for _, m := range methods {
+		items = append(items, &pb.LoginMethodItem{
+			Id:          m.ID,
+			Type:        m.Type,
+			Provider:    m.Provider,
+			Email:       m.Email,
+			Name:        m.Name,
+			CreatedAt:   m.CreatedAt,
+			LastLoginAt: m.LastLoginAt,
+		})
+	}
+
+	return &pb.ListLoginMethodsResponse{
+		Methods: items,
+	}, nil
+}
+
+func (h *AuthHandler) LinkLoginMethod(ctx context.Context, req *pb.LinkLoginMethodRequest) (*pb.LinkLoginMethodResponse, error) {
+	userID, err := userIDFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	switch req.Type {
+	case model.LoginMethodPassword:
+		if err := h.service.SetPassword(ctx, userID, req.Password); err != nil {
+			return nil, status.Errorf(codes.FailedPrecondition, "link failed: %v", err)
+		}
+		return &pb.LinkLoginMethodResponse{
+			Message: "Password set",
+		}, nil
+
+	case model.LoginMethodOIDC:
+		authURL, err := h.service.StartIdentityLink(ctx, userID, req.Provider)
+		if err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "link failed: %v", err)
+		}
+		return &pb.LinkLoginMethodResponse{
+			Message:          "Open the authorization URL to finish linking",
+			AuthorizationUrl: authURL,
+		}, nil
+
+	case model.LoginMethodPasskey:
+		return nil, status.Errorf(codes.InvalidArgument, "use BeginPasskeyRegistration to add a passkey")
+
+	default:
+		return nil, status.Errorf(codes.InvalidArgument, "unsupported login method type %q", req.Type)
+	}
+}
+
+func (h *AuthHandler) UnlinkLoginMethod(ctx context.Context, req *pb.UnlinkLoginMethodRequest) (*pb.UnlinkLoginMethodResponse, error) {
+	userID, err := userIDFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	err = h.service.UnlinkLoginMethod(ctx, userID, req.Id)
+	if err != nil {
+		return nil, status.Errorf(codes.FailedPrecondition, "unlink failed: %v", err)
+	}
+
+	return &pb.UnlinkLoginMethodResponse{
+		Message: "Login method removed",
+	}, nil
+}
@@ -0,0 +1,29 @@
+package handler
+
+import (
+	"context"
+
+	pb "github.com/bekbek22/auth_service/api/proto"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func (h *AuthHandler) RequestMagicLink(ctx context.Context, req *pb.RequestMagicLinkRequest) (*pb.RequestMagicLinkResponse, error) {
+	err := h.service.RequestMagicLink(ctx, req.Email)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "request failed: %v", err)
+	}
+
+	return &pb.RequestMagicLinkResponse{
+		Message: "If the email is registered, a sign-in link has been sent",
+	}, nil
+}
+
+func (h *AuthHandler) ConsumeMagicLink(ctx context.Context, req *pb.ConsumeMagicLinkRequest) (*pb.LoginResponse, error) {
+	result, err := h.service.ConsumeMagicLink(ctx, req.Token, req.DeviceName, req.ClientId)
+	if err != nil {
+		return nil, status.Errorf(codes.Unauthenticated, "Login failed: %v", err)
+	}
+
+	return toLoginResponse(result), nil
+}
@@ -0,0 +1,207 @@
+package handler
+
+import (
+	"context"
+
+	pb "github.com/bekbek22/auth_service/api/proto"
+	"github.com/bekbek22/auth_service/internal/middleware"
+	"github.com/bekbek22/auth_service/internal/model"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func toOrganization(o *model.Organization, role string) *pb.Organization {
+	return &pb.Organization{
+		Id:        o.ID.Hex(),
+		Name:      o.Name,
+		TenantId:  o.TenantID,
+		CreatedAt: o.CreatedAt,
+		UpdatedAt: o.UpdatedAt,
+		Role:      role,
+	}
+}
+
+func toInvitation(inv *model.Invitation) *pb.Invitation {
+	return &pb.Invitation{
+		Id:             inv.ID.Hex(),
+		OrganizationId: inv.OrgID.Hex(),
+		Email:          inv.Email,
+		Role:           inv.Role,
+		InvitedBy:      inv.InvitedBy.Hex(),
+		CreatedAt:      inv.CreatedAt,
+		ExpiresAt:      inv.ExpiresAt,
+	}
+}
+
+func (h *AuthHandler) CreateOrganization(ctx context.Context, req *pb.CreateOrganizationRequest) (*pb.Organization, error) {
+	userID, err := userIDFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	org, err := h.service.CreateOrganization(ctx, userID, req.Name)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "create organization failed: %v", err)
+	}
+	return toOrganization(org, "owner"), nil
+}
+
+func (h *AuthHandler) ListMyOrganizations(ctx context.Context, req *pb.ListMyOrganizationsRequest) (*pb.ListMyOrganizationsResponse, error) {
+	userID, err := userIDFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	memberships, err := h.service.ListMyOrganizations(ctx, userID)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to list organizations: %v", err)
+	}
+
+	items := make([]*pb.Organization, 0, len(memberships))
+	for i := range memberships {
+		items = append(items, toOrganization(&memberships[i].Organization, memberships[i].Role))
+	}
+	return &pb.ListMyOrganizationsResponse{
+		Organizations: items,
+	}, nil
+}
+
+func (h *AuthHandler) DeleteOrganization(ctx context.Context, req *pb.DeleteOrganizationRequest) (*pb.DeleteOrganizationResponse, error) {
+	userID, err := userIDFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := h.service.DeleteOrganization(ctx, userID, req.OrganizationId); err != nil {
+		return nil, status.Errorf(codes.PermissionDenied, "delete organization failed: %v", err)
+	}
+	return &pb.DeleteOrganizationResponse{
+		Message: "Organization deleted",
+	}, nil
+}
+
+func (h *AuthHandler) ListOrganizationMembers(ctx context.Context, req *pb.ListOrganizationMembersRequest) (*pb.ListOrganizationMembersResponse, error) {
+	userID, err := userIDFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	members, err := h.service.ListOrgMembers(ctx, userID, req.OrganizationId)
+	if err != nil {
+		return nil, status.Errorf(codes.NotFound, "list members failed: %v", err)
+	}
+
+	items := make([]*pb.OrganizationMember, 0, len(members))
+	for _, m := range members {
+		items = append(items, &pb.OrganizationMember{
+			UserId:   m.UserID,
+			Name:     m.Name,
+			Email:    m.Email,
+			Role:     m.Role,
+			JoinedAt: m.JoinedAt,
+		})
+	}
+	return &pb.ListOrganizationMembersResponse{
+		Members: items,
+	}, nil
+}
+
+func (h *AuthHandler) UpdateMemberRole(ctx context.Context, req *pb.UpdateMemberRoleRequest) (*pb.UpdateMemberRoleResponse, error) {
+	userID, err := userIDFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := h.service.UpdateMemberRole(ctx, userID, req.OrganizationId, req.UserId, req.Role); err != nil {
+		return nil, status.Errorf(codes.FailedPrecondition, "update member role failed: %v", err)
+	}
+	return &pb.UpdateMemberRoleResponse{
+		Message: "Member role updated",
+	}, nil
+}
+
+func (h *AuthHandler) RemoveMember(ctx context.Context, req *pb.RemoveMemberRequest) (*pb.RemoveMemberResponse, error) {
+	userID, err := userIDFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := h.service.RemoveMember(ctx, userID, req.OrganizationId, req.UserId); err != nil {
+		return nil, status.Errorf(codes.FailedPrecondition, "remove member failed: %v", err)
+	}
+	return &pb.RemoveMemberResponse{
+		Message: "Member removed",
+	}, nil
+}
+
+func (h *AuthHandler) InviteMember(ctx context.Context, req *pb.InviteMemberRequest) (*pb.Invitation, error) {
+	userID, err := userIDFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	inv, err := h.service.InviteMember(ctx, userID, req.OrganizationId, req.Email, req.Role)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invite member failed: %v", err)
+	}
+	return toInvitation(inv), nil
+}
+
+func (h *AuthHandler) ListInvitations(ctx context.Context, req *pb.ListInvitationsRequest) (*pb.ListInvitationsResponse, error) {
+	userID, err := userIDFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	invitations, err := h.service.ListInvitations(ctx, userID, req.OrganizationId)
+	if err != nil {
+		return nil, status.Errorf(codes.PermissionDenied, "list invitations failed: %v", err)
+	}
+
+	items := make([]*pb.Invitation, 0, len(invitations))
+	for i := range invitations {
+		items = append(items, toInvitation(&invitations[i]))
+	}
+	return &pb.ListInvitationsResponse{
+		Invitations: items,
+	}, nil
+}
+
+func (h *AuthHandler) RevokeInvitation(ctx context.Context, req *pb.RevokeInvitationRequest) (*pb.RevokeInvitationResponse, error) {
+	userID, err := userIDFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := h.service.RevokeInvitation(ctx, userID, req.OrganizationId, req.InvitationId); err != nil {
+		return nil, status.Errorf(codes.NotFound, "revoke invitation failed: %v", err)
+	}
+	return &pb.RevokeInvitationResponse{
+		Message: "Invitation revoked",
+	}, nil
+}
+
+func (h *AuthHandler) AcceptInvitation(ctx context.Context, req *pb.AcceptInvitationRequest) (*pb.AcceptInvitationResponse, error) {
+	accepted, err := h.service.AcceptInvitation(ctx, req.Token, req.Name, req.Password)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "accept invitation failed: %v", err)
+	}
+	return &pb.AcceptInvitationResponse{
+		OrganizationId: accepted.OrgID,
+		Role:           accepted.Role,
+		AccountCreated: accepted.AccountCreated,
+	}, nil
+}
+
+func (h *AuthHandler) SwitchOrganization(ctx context.Context, req *pb.SwitchOrganizationRequest) (*pb.LoginResponse, error) {
+	if _, err := userIDFromContext(ctx); err != nil {
+		return nil, err
+	}
+	claims, _ := middleware.ClaimsFromContext(ctx)
+
+	result, err := h.service.SwitchOrganization(ctx, claims, req.OrganizationId)
+	if err != nil {
+		return nil, status.Errorf(codes.PermissionDenied, "switch organization failed: %v", err)
+	}
+	return toLoginResponse(result), nil
+}
@@ -0,0 +1,78 @@
+package handler
+
+import (
+	"context"
+
+	pb "github.com/bekbek22/auth_service/api/proto"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func (h *AuthHandler) EnrollOTP(ctx context.Context, req *pb.EnrollOTPRequest) (*pb.EnrollOTPResponse, error) {
+	userID, err := userIDFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	err = h.service.EnrollOTP(ctx, userID, req.Channel, req.PhoneNumber)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "enroll failed: %v", err)
+	}
+
+	return &pb.EnrollOTPResponse{
+		Message: "Verification code sent",
+	}, nil
+}
+
+func (h *AuthHandler) ConfirmOTPEnrollment(ctx context.Context, req *pb.ConfirmOTPEnrollmentRequest) (*pb.ConfirmOTPEnrollmentResponse, error) {
+	userID, err := userIDFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	err = h.service.ConfirmOTPEnrollment(ctx, userID, req.Code)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "confirm failed: %v", err)
+	}
+
+	return &pb.ConfirmOTPEnrollmentResponse{
+		Message: "One-time passcodes enabled",
+	}, nil
+}
+
+func (h *AuthHandler) DisableOTP(ctx context.Context, req *pb.DisableOTPRequest) (*pb.DisableOTPResponse, error) {
+	userID, err := userIDFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	err = h.service.DisableOTP(ctx, userID)
+	if err != nil {
+		return nil, status.Errorf(codes.FailedPrecondition, "disable failed: %v", err)
+	}
+
+	return &pb.DisableOTPResponse{
+		Message: "One-time passcodes disabled",
+	}, nil
+}
+
+func (h *AuthHandler) SendLoginOTP(ctx context.Context, req *pb.SendLoginOTPRequest) (*pb.SendLoginOTPResponse, error) {
+	destination, err := h.service.SendLoginOTP(ctx, req.MfaToken)
+	if err != nil {
+		return nil, status.Errorf(codes.Unauthenticated, "send code failed: %v", err)
+	}
+
+	return &pb.SendLoginOTPResponse{
+		Message:     "Verification code sent",
+		Destination: destination,
+	}, nil
+}
+
+func (h *AuthHandler) VerifyLoginOTP(ctx context.Context, req *pb.VerifyLoginOTPRequest) (*pb.LoginResponse, error) {
+	result, err := h.service.VerifyLoginOTP(ctx, req.MfaToken, req.Code)
+	if err != nil {
+		return nil, status.Errorf(codes.Unauthenticated, "Login failed: %v", err)
+	}
+
+	return toLoginResponse(result), nil
+}
@@ -0,0 +1,64 @@
+package handler
+
+import (
+	"context"
+
+	pb "github.com/bekbek22/auth_service/api/proto"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func (h *AuthHandler) BeginPasskeyRegistration(ctx context.Context, req *pb.BeginPasskeyRegistrationRequest) (*pb.BeginPasskeyRegistrationResponse, error) {
+	userID, err := userIDFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	ceremonyID, options, err := h.service.BeginPasskeyRegistration(ctx, userID)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "begin registration failed: %v", err)
+	}
+
+	return &pb.BeginPasskeyRegistrationResponse{
+		CeremonyId:  ceremonyID,
+		OptionsJson: string(options),
+	}, nil
+}
+
+func (h *AuthHandler) FinishPasskeyRegistration(ctx context.Context, req *pb.FinishPasskeyRegistrationRequest) (*pb.FinishPasskeyRegistrationResponse, error) {
+	userID, err := userIDFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	identity, err := h.service.FinishPasskeyRegistration(ctx, userID, req.CeremonyId, []byte(req.CredentialJson), req.Name)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "finish registration failed: %v", err)
+	}
+
+	return &pb.FinishPasskeyRegistrationResponse{
+		Message: "Passkey registered",
+		Id:      identity.ID.Hex(),
+	}, nil
+}
+
+func (h *AuthHandler) BeginPasskeyLogin(ctx context.Context, req *pb.BeginPasskeyLoginRequest) (*pb.BeginPasskeyLoginResponse, error) {
+	ceremonyID, options, err := h.service.BeginPasskeyLogin(ctx, req.Email, req.MfaToken)
+	if err != nil {
+		return nil, status.Errorf(codes.Unauthenticated, "begin login failed: %v", err)
+	}
+
+	return &pb.BeginPasskeyLoginResponse{
+		CeremonyId:  ceremonyID,
+		OptionsJson: string(options),
+	}, nil
+}
+
+func (h *AuthHandler) FinishPasskeyLogin(ctx context.Context, req *pb.FinishPasskeyLoginRequest) (*pb.LoginResponse, error) {
+	result, err := h.service.FinishPasskeyLogin(ctx, req.CeremonyId, []byte(req.CredentialJson), req.MfaToken, req.DeviceName, req.ClientId)
+	if err != nil {
+		return nil, status.Errorf(codes.Unauthenticated, "Login failed: %v", err)
+	}
+
+	return toLoginResponse(result), nil
+}
@@ -0,0 +1,73 @@
+package handler
+
+import (
+	"context"
+
+	pb "github.com/bekbek22/auth_service/api/proto"
+	"github.com/bekbek22/auth_service/internal/model"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func toPersonalAccessTokenItem(t *model.PersonalAccessToken) *pb.PersonalAccessTokenItem {
+	return &pb.PersonalAccessTokenItem{
+		Id:         t.ID.Hex(),
+		Name:       t.Name,
+		Prefix:     t.Prefix,
+		Scopes:     t.Scopes,
+		CreatedAt:  t.CreatedAt,
+		ExpiresAt:  t.ExpiresAt,
+		LastUsedAt: t.LastUsedAt,
+		RevokedAt:  t.RevokedAt,
+	}
+}
+
+func (h *AuthHandler) CreatePersonalAccessToken(ctx context.Context, req *pb.CreatePersonalAccessTokenRequest) (*pb.CreatePersonalAccessTokenResponse, error) {
+	userID, err := userIDFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	token, plaintext, err := h.service.CreatePersonalAccessToken(ctx, userID, req.Name, req.Scopes, req.ExpiresInDays)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "create token failed: %v", err)
+	}
+
+	return &pb.CreatePersonalAccessTokenResponse{
+		Token:               plaintext,
+		PersonalAccessToken: toPersonalAccessTokenItem(token),
+	}, nil
+}
+
+func (h *AuthHandler) ListPersonalAccessTokens(ctx context.Context, req *pb.ListPersonalAccessTokensRequest) (*pb.ListPersonalAccessTokensResponse, error) {
+	userID, err := userIDFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	tokens, err := h.service.ListPersonalAccessTokens(ctx, userID)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to list tokens: %v", err)
+	}
+
+	items := make([]*pb.PersonalAccessTokenItem, 0, len(tokens))
+	for i := range tokens {
+		items = append(items, toPersonalAccessTokenItem(&tokens[i]))
+	}
+	return &pb.ListPersonalAccessTokensResponse{
+		Tokens: items,
+	}, nil
+}
+
+func (h *AuthHandler) RevokePersonalAccessToken(ctx context.Context, req *pb.RevokePersonalAccessTokenRequest) (*pb.RevokePersonalAccessTokenResponse, error) {
+	userID, err := userIDFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := h.service.RevokePersonalAccessToken(ctx, userID, req.TokenId); err != nil {
+		return nil, status.Errorf(codes.NotFound, "revoke token failed: %v", err)
+	}
+	return &pb.RevokePersonalAccessTokenResponse{
+		Message: "Token revoked",
+	}, nil
+}
@@ -0,0 +1,131 @@
+package handler
+
+import (
+	"context"
+
+	pb "github.com/bekbek22/auth_service/api/proto"
+	"github.com/bekbek22/auth_service/internal/model"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func toServiceAccountItem(a *model.ServiceAccount) *pb.ServiceAccount {
+	return &pb.ServiceAccount{
+		Id:          a.ID.Hex(),
+		Name:        a.Name,
+		Description: a.Description,
+		Scopes:      a.Scopes,
+		CreatedAt:   a.CreatedAt,
+	}
+}
+
+func toAPIKeyItem(k *model.APIKey) *pb.APIKeyItem {
+	return &pb.APIKeyItem{
+		Id:               k.ID.Hex(),
+		ServiceAccountId: k.ServiceAccountID.Hex(),
+		Name:             k.Name,
+		Prefix:           k.Prefix,
+		Scopes:           k.Scopes,
+		CreatedAt:        k.CreatedAt,
+		ExpiresAt:        k.ExpiresAt,
+		LastUsedAt:       k.LastUsedAt,
+		RevokedAt:        k.RevokedAt,
+	}
+}
+
+func (h *AuthHandler) CreateServiceAccount(ctx context.Context, req *pb.CreateServiceAccountRequest) (*pb.ServiceAccount, error) {
+	actorID, err := adminID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	account, err := h.service.CreateServiceAccount(ctx, actorID, req.Name, req.Description, req.Scopes)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "create service account failed: %v", err)
+	}
+	return toServiceAccountItem(account), nil
+}
+
+func (h *AuthHandler) ListServiceAccounts(ctx context.Context, req *pb.ListServiceAccountsRequest) (*pb.ListServiceAccountsResponse, error) {
+	if err := requireAdmin(ctx); err != nil {
+		return nil, err
+	}
+
+	accounts, err := h.service.ListServiceAccounts(ctx)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to list service accounts: %v", err)
+	}
+
+	items := make([]*pb.ServiceAccount, 0, len(accounts))
+	for i := range accounts {
+		items = append(items, toServiceAccountItem(&accounts[i]))
+	}
+	return &pb.ListServiceAccountsResponse{
+		ServiceAccounts: items,
+	}, nil
+}
+
+func (h *AuthHandler) CreateAPIKey(ctx context.Context, req *pb.CreateAPIKeyRequest) (*pb.CreateAPIKeyResponse, error) {
+	actorID, err := adminID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	key, plaintext, err := h.service.CreateAPIKey(ctx, actorID, req.ServiceAccountId, req.Name, req.Scopes, req.ExpiresInDays)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "create api key failed: %v", err)
+	}
+	return &pb.CreateAPIKeyResponse{
+		Key:    plaintext,
+		ApiKey: toAPIKeyItem(key),
+	}, nil
+}
+
+func (h *AuthHandler) ListAPIKeys(ctx context.Context, req *pb.ListAPIKeysRequest) (*pb.ListAPIKeysResponse, error) {
+	if err := requireAdmin(ctx); err != nil {
+		return nil, err
+	}
+
+	keys, err := h.service.ListAPIKeys(ctx, req.ServiceAccountId)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "failed to list api keys: %v", err)
+	}
+
+	items := make([]*pb.APIKeyItem, 0, len(keys))
+	for i := range keys {
+		items = append(items, toAPIKeyItem(&keys[i]))
+	}
+	return &pb.ListAPIKeysResponse{
+		ApiKeys: items,
+	}, nil
+}
+
+func (h *AuthHandler) RotateAPIKey(ctx context.Context, req *pb.RotateAPIKeyRequest) (*pb.CreateAPIKeyResponse, error) {
+	actorID, err := adminID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	key, plaintext, err := h.service.RotateAPIKey(ctx, actorID, req.KeyId)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "rotate api key failed: %v", err)
+	}
+	return &pb.CreateAPIKeyResponse{
+		Key:    plaintext,
+		ApiKey: toAPIKeyItem(key),
+	}, nil
+}
+
+func (h *AuthHandler) RevokeAPIKey(ctx context.Context, req *pb.RevokeAPIKeyRequest) (*pb.RevokeAPIKeyResponse, error) {
+	actorID, err := adminID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := h.service.RevokeAPIKey(ctx, actorID, req.KeyId); err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "revoke api key failed: %v", err)
+	}
+	return &pb.RevokeAPIKeyResponse{
+		Message: "API key revoked",
+	}, nil
+}
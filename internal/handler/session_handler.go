@@ -0,0 +1,58 @@
+package handler
+
+import (
+	"context"
+
+	pb "github.com/bekbek22/auth_service/api/proto"
+	"github.com/bekbek22/auth_service/internal/middleware"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func (h *AuthHandler) ListMySessions(ctx context.Context, req *pb.ListMySessionsRequest) (*pb.ListMySessionsResponse, error) {
+	userID, err := userIDFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	sessions, err := h.service.ListMySessions(ctx, userID)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to list sessions: %v", err)
+	}
+
+	claims, _ := middleware.ClaimsFromContext(ctx)
+	currentSID := claims.SessionID
+
+	items := make([]*pb.SessionItem, 0, len(sessions))
+	for _, s := range sessions {
+		items = append(items, &pb.SessionItem{
+			Id:         s.ID.Hex(),
+			DeviceName: s.DeviceName,
+			Ip:         s.IP,
+			UserAgent:  s.UserAgent,
+			CreatedAt:  s.CreatedAt,
+			LastSeenAt: s.LastSeenAt,
+			Current:    s.ID.Hex() == currentSID,
+		})
+	}
+
+	return &pb.ListMySessionsResponse{
+		Sessions: items,
+	}, nil
+}
+
+func (h *AuthHandler) RevokeSession(ctx context.Context, req *pb.RevokeSessionRequest) (*pb.RevokeSessionResponse, error) {
+	userID, err := userIDFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	err = h.service.RevokeSession(ctx, userID, req.SessionId)
+	if err != nil {
+		return nil, status.Errorf(codes.NotFound, "revoke failed: %v", err)
+	}
+
+	return &pb.RevokeSessionResponse{
+		Message: "Session revoked",
+	}, nil
+}
@@ -0,0 +1,99 @@
+package handler
+
+import (
+	"context"
+
+	pb "github.com/bekbek22/auth_service/api/proto"
+	"github.com/bekbek22/auth_service/internal/model"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func toTenant(t *model.Tenant) *pb.Tenant {
+	return &pb.Tenant{
+		Id:        t.ID.Hex(),
+		Name:      t.Name,
+		Slug:      t.Slug,
+		CreatedAt: t.CreatedAt,
+		UpdatedAt: t.UpdatedAt,
+	}
+}
+
+func (h *AuthHandler) CreateTenant(ctx context.Context, req *pb.CreateTenantRequest) (*pb.Tenant, error) {
+	actorID, err := adminID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	tenant, err := h.service.CreateTenant(ctx, actorID, req.Name, req.Slug)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "create tenant failed: %v", err)
+	}
+	return toTenant(tenant), nil
+}
+
+func (h *AuthHandler) GetTenant(ctx context.Context, req *pb.GetTenantRequest) (*pb.Tenant, error) {
+	if err := requireAdmin(ctx); err != nil {
+		return nil, err
+	}
+
+	tenant, err := h.service.GetTenant(ctx, req.TenantId)
+	if err != nil {
+		return nil, status.Errorf(codes.NotFound, "tenant not found")
+	}
+	return toTenant(tenant), nil
+}
+
+func (h *AuthHandler) ListTenants(ctx context.Context, req *pb.ListTenantsRequest) (*pb.ListTenantsResponse, error) {
+	if err := requireAdmin(ctx); err != nil {
+		return nil, err
+	}
+
+	if req.Page < 1 {
+		req.Page = 1
+	}
+	if req.Limit <= 0 {
+		req.Limit = 10
+	}
+
+	tenants, total, err := h.service.ListTenants(ctx, req.Page, req.Limit)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to list tenants: %v", err)
+	}
+
+	items := make([]*pb.Tenant, 0, len(tenants))
+	for i := range tenants {
+		items = append(items, toTenant(&tenants[i]))
+	}
+	return &pb.ListTenantsResponse{
+		Tenants: items,
+		Total:   total,
+	}, nil
+}
+
+func (h *AuthHandler) UpdateTenant(ctx context.Context, req *pb.UpdateTenantRequest) (*pb.Tenant, error) {
+	actorID, err := adminID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	tenant, err := h.service.UpdateTenant(ctx, actorID, req.TenantId, req.Name)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "update tenant failed: %v", err)
+	}
+	return toTenant(tenant), nil
+}
+
+func (h *AuthHandler) DeleteTenant(ctx context.Context, req *pb.DeleteTenantRequest) (*pb.DeleteTenantResponse, error) {
+	actorID, err := adminID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := h.service.DeleteTenant(ctx, actorID, req.TenantId); err != nil {
+		return nil, status.Errorf(codes.FailedPrecondition, "delete tenant failed: %v", err)
+	}
+	return &pb.DeleteTenantResponse{
+		Message: "Tenant deleted",
+	}, nil
+}
@@ -0,0 +1,205 @@
+package middleware
+
+import (
+	"context"
+	"slices"
+	"strings"
+
+	"github.com/bekbek22/auth_service/internal/utils"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type claimsKey struct{}
+
+type principalKey struct{}
+
+// Kinds of principal that can make an authenticated call.
+const (
+	PrincipalUser           = "user"
+	PrincipalServiceAccount = "service_account"
+)
+
+// Principal identifies who made an authenticated call.
+type Principal struct {
+	Kind string
+	ID   string
+}
+
+// TokenChecker runs after the signature is verified, e.g. to reject tokens
+// whose session was revoked.
+type TokenChecker func(ctx context.Context, token string, claims *utils.AuthClaims) error
+
+// APIKeyAuthenticator resolves an API key or personal access token to the
+// claims of its owner.
+type APIKeyAuthenticator func(ctx context.Context, key string) (*utils.AuthClaims, error)
+
+// ImpersonationRecorder is told about every call made with an impersonation
+// token, including the ones refused.
+type ImpersonationRecorder func(ctx context.Context, claims *utils.AuthClaims, method string, allowed bool)
+
+// AuthInterceptor authenticates every RPC except the public ones, with a
+// bearer JWT, a bearer personal access token or an x-api-key, and stores the
+// claims and principal in the request context. Personal access tokens are
+// refused on the sensitive methods, and impersonation tokens on every method
+// they aren't allowed. Keys, and JWTs that carry scopes, are refused on the
+// methods whose required scopes they lack.
+type AuthInterceptor struct {
+	tokens              *utils.TokenIssuer
+	publicMethods       map[string]bool
+	sensitive           map[string]bool
+	impersonation       map[string]bool
+	requiredScopes      map[string][]string
+	check               TokenChecker
+	apiKeys             APIKeyAuthenticator
+	recordImpersonation ImpersonationRecorder
+}
+
+func NewAuthInterceptor(tokens *utils.TokenIssuer, publicMethods, sensitive, impersonation []string, requiredScopes map[string][]string, check TokenChecker, apiKeys APIKeyAuthenticator, recordImpersonation ImpersonationRecorder) *AuthInterceptor {
+	return &AuthInterceptor{
+		tokens:              tokens,
+		publicMethods:       methodSet(publicMethods),
+		sensitive:           methodSet(sensitive),
+		impersonation:       methodSet(impersonation),
+		requiredScopes:      requiredScopes,
+		check:               check,
+		apiKeys:             apiKeys,
+		recordImpersonation: recordImpersonation,
+	}
+}
+
+func methodSet(methods []string) map[string]bool {
+	set := make(map[string]bool, len(methods))
+	for _, m := range methods {
+		set[m] = true
+	}
+	return set
+}
+
+func (i *AuthInterceptor) Unary() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if i.publicMethods[info.FullMethod] {
+			// Public methods ignore the caller's token, but an impersonator
+			// must not use them to act as the user either
+			if claims, ok := i.impersonationClaims(ctx); ok && !i.allowImpersonated(ctx, claims, info.FullMethod) {
+				return nil, status.Errorf(codes.PermissionDenied, "not available while impersonating")
+			}
+			return handler(ctx, req)
+		}
+
+		if key := ExtractAPIKeyFromContext(ctx); key != "" && i.apiKeys != nil {
+			return i.handleKey(ctx, key, info.FullMethod, req, handler)
+		}
+
+		tokenStr, err := ExtractTokenFromContext(ctx)
+		if err != nil {
+			return nil, status.Errorf(codes.Unauthenticated, "missing or invalid token: %v", err)
+		}
+
+		// Personal access tokens are opaque, not JWTs
+		if strings.Count(tokenStr, ".") != 2 && i.apiKeys != nil {
+			return i.handleKey(ctx, tokenStr, info.FullMethod, req, handler)
+		}
+
+		claims, err := i.tokens.Validate(tokenStr)
+		if err != nil {
+			return nil, status.Errorf(codes.Unauthenticated, "token invalid: %v", err)
+		}
+
+		if i.check != nil {
+			if err := i.check(ctx, tokenStr, claims); err != nil {
+				return nil, status.Errorf(codes.Unauthenticated, "token invalid: %v", err)
+			}
+		}
+
+		// Tokens without scopes are limited only by the user's roles, but
+		// only first-party logins get those: a token issued to an OAuth
+		// client always carries the scopes the user granted it
+		if claims.Scope != "" {
+			if err := i.checkScope(claims, info.FullMethod); err != nil {
+				return nil, err
+			}
+		} else if claims.ClientID != "" {
+			return nil, status.Errorf(codes.PermissionDenied, "token issued to a client carries no scope")
+		}
+
+		if claims.Impersonated() && !i.allowImpersonated(ctx, claims, info.FullMethod) {
+			return nil, status.Errorf(codes.PermissionDenied, "not available while impersonating")
+		}
+
+		ctx = context.WithValue(ctx, principalKey{}, Principal{Kind: PrincipalUser, ID: claims.Subject})
+		return handler(context.WithValue(ctx, claimsKey{}, claims), req)
+	}
+}
+
+// impersonationClaims returns the claims of the bearer token sent with a call
+// if it is a valid impersonation token.
+func (i *AuthInterceptor) impersonationClaims(ctx context.Context) (*utils.AuthClaims, bool) {
+	tokenStr, err := ExtractTokenFromContext(ctx)
+	if err != nil {
+		return nil, false
+	}
+	claims, err := i.tokens.Validate(tokenStr)
+	if err != nil || !claims.Impersonated() {
+		return nil, false
+	}
+	return claims, true
+}
+
+// allowImpersonated reports whether an impersonation token may call method,
+// and records the call either way.
+func (i *AuthInterceptor) allowImpersonated(ctx context.Context, claims *utils.AuthClaims, method string) bool {
+	allowed := i.impersonation[method]
+	if i.recordImpersonation != nil {
+		i.recordImpersonation(ctx, claims, method, allowed)
+	}
+	return allowed
+}
+
+// handleKey authenticates an API key or personal access token. API keys
+// belong to service accounts, personal access tokens to users.
+func (i *AuthInterceptor) handleKey(ctx context.Context, key, method string, req interface{}, handler grpc.UnaryHandler) (interface{}, error) {
+	claims, err := i.apiKeys(ctx, key)
+	if err != nil {
+		return nil, status.Errorf(codes.Unauthenticated, "api key invalid: %v", err)
+	}
+	if claims.PersonalTokenID != "" && i.sensitive[method] {
+		return nil, status.Errorf(codes.PermissionDenied, "not available to personal access tokens")
+	}
+	if err := i.checkScope(claims, method); err != nil {
+		return nil, err
+	}
+
+	principal := Principal{Kind: PrincipalUser, ID: claims.Subject}
+	if claims.APIKeyID != "" {
+		principal.Kind = PrincipalServiceAccount
+	}
+	ctx = context.WithValue(ctx, principalKey{}, principal)
+	return handler(context.WithValue(ctx, claimsKey{}, claims), req)
+}
+
+// checkScope refuses a scoped credential that holds none of the scopes method
+// requires.
+func (i *AuthInterceptor) checkScope(claims *utils.AuthClaims, method string) error {
+	required, ok := i.requiredScopes[method]
+	if !ok {
+		return status.Errorf(codes.PermissionDenied, "not available to scoped credentials")
+	}
+	if !slices.ContainsFunc(required, claims.HasScope) {
+		return status.Errorf(codes.PermissionDenied, "credential lacks scope %s", strings.Join(required, " or "))
+	}
+	return nil
+}
+
+// ClaimsFromContext returns the claims stored by AuthInterceptor.
+func ClaimsFromContext(ctx context.Context) (*utils.AuthClaims, bool) {
+	claims, ok := ctx.Value(claimsKey{}).(*utils.AuthClaims)
+	return claims, ok
+}
+
+// PrincipalFromContext returns who made the call, as stored by AuthInterceptor.
+func PrincipalFromContext(ctx context.Context) (Principal, bool) {
+	p, ok := ctx.Value(principalKey{}).(Principal)
+	return p, ok
+}
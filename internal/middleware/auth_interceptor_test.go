@@ -0,0 +1,163 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/bekbek22/auth_service/internal/utils"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	methodLogin     = "/auth.AuthService/Login"
+	methodProfile   = "/auth.AuthService/GetProfile"
+	methodListUsers = "/auth.AuthService/ListUsers"
+	methodDelete    = "/auth.AuthService/DeleteUser"
+	methodCreatePAT = "/auth.AuthService/CreatePersonalAccessToken"
+)
+
+type impersonatedCall struct {
+	method  string
+	allowed bool
+}
+
+func newTestInterceptor(t *testing.T) (*AuthInterceptor, *utils.TokenIssuer, *[]impersonatedCall) {
+	t.Helper()
+	key, err := utils.LoadSigningKey("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tokens := utils.NewTokenIssuer(key, "https://auth.example.com", "auth-service")
+
+	keys := map[string]*utils.AuthClaims{
+		"sa-key": {APIKeyID: "k1", Scope: "users:read"},
+		"pat-1":  {PersonalTokenID: "p1", Scope: "profile:read"},
+		"pat-2":  {PersonalTokenID: "p2", Scope: "tokens:write"},
+	}
+	apiKeys := func(ctx context.Context, key string) (*utils.AuthClaims, error) {
+		if claims, ok := keys[key]; ok {
+			return claims, nil
+		}
+		return nil, errors.New("unknown key")
+	}
+	check := func(ctx context.Context, token string, claims *utils.AuthClaims) error {
+		if claims.SessionID == "revoked" {
+			return errors.New("session revoked")
+		}
+		return nil
+	}
+	var calls []impersonatedCall
+	record := func(ctx context.Context, claims *utils.AuthClaims, method string, allowed bool) {
+		calls = append(calls, impersonatedCall{method: method, allowed: allowed})
+	}
+
+	i := NewAuthInterceptor(
+		tokens,
+		[]string{methodLogin},
+		[]string{methodCreatePAT},
+		[]string{methodProfile},
+		map[string][]string{
+			methodProfile:   {"profile:read"},
+			methodListUsers: {"users:read", "users:write"},
+			methodCreatePAT: {"tokens:write"},
+		},
+		check,
+		apiKeys,
+		record,
+	)
+	return i, tokens, &calls
+}
+
+func issueTestToken(t *testing.T, tokens *utils.TokenIssuer, claims utils.AuthClaims) string {
+	t.Helper()
+	claims.Subject = "u1"
+	token, err := tokens.Issue(&claims, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return token
+}
+
+func TestAuthInterceptor(t *testing.T) {
+	i, tokens, _ := newTestInterceptor(t)
+	bearer := func(claims utils.AuthClaims) []string {
+		return []string{"authorization", "Bearer " + issueTestToken(t, tokens, claims)}
+	}
+	impersonation := utils.AuthClaims{Role: "user", Actor: &utils.Actor{Subject: "admin"}}
+
+	tests := []struct {
+		name          string
+		md            []string
+		method        string
+		wantCode      codes.Code
+		wantPrincipal string
+	}{
+		{name: "public without token", method: methodLogin, wantCode: codes.OK},
+		{name: "missing token", method: methodProfile, wantCode: codes.Unauthenticated},
+		{name: "malformed token", md: []string{"authorization", "Bearer a.b.c"}, method: methodProfile, wantCode: codes.Unauthenticated},
+		{name: "rejected by check", md: bearer(utils.AuthClaims{Role: "user", SessionID: "revoked"}), method: methodProfile, wantCode: codes.Unauthenticated},
+
+		{name: "unscoped login", md: bearer(utils.AuthClaims{Role: "user"}), method: methodDelete, wantCode: codes.OK, wantPrincipal: PrincipalUser},
+		{name: "scoped login with scope", md: bearer(utils.AuthClaims{Role: "user", Scope: "profile:read"}), method: methodProfile, wantCode: codes.OK, wantPrincipal: PrincipalUser},
+		{name: "scoped login with one of the scopes", md: bearer(utils.AuthClaims{Role: "admin", Scope: "users:write"}), method: methodListUsers, wantCode: codes.OK, wantPrincipal: PrincipalUser},
+		{name: "scoped login without scope", md: bearer(utils.AuthClaims{Role: "user", Scope: "profile:read"}), method: methodListUsers, wantCode: codes.PermissionDenied},
+		{name: "scoped login on unscoped method", md: bearer(utils.AuthClaims{Role: "user", Scope: "profile:read"}), method: methodDelete, wantCode: codes.PermissionDenied},
+		{name: "client token with scope", md: bearer(utils.AuthClaims{Role: "user", ClientID: "app", Scope: "profile:read"}), method: methodProfile, wantCode: codes.OK, wantPrincipal: PrincipalUser},
+		{name: "client token without scope", md: bearer(utils.AuthClaims{Role: "user", ClientID: "app"}), method: methodProfile, wantCode: codes.PermissionDenied},
+
+		{name: "api key with scope", md: []string{"x-api-key", "sa-key"}, method: methodListUsers, wantCode: codes.OK, wantPrincipal: PrincipalServiceAccount},
+		{name: "api key without scope", md: []string{"x-api-key", "sa-key"}, method: methodProfile, wantCode: codes.PermissionDenied},
+		{name: "unknown api key", md: []string{"x-api-key", "nope"}, method: methodListUsers, wantCode: codes.Unauthenticated},
+		{name: "personal access token", md: []string{"authorization", "Bearer pat-1"}, method: methodProfile, wantCode: codes.OK, wantPrincipal: PrincipalUser},
+		{name: "personal access token without scope", md: []string{"authorization", "Bearer pat-1"}, method: methodListUsers, wantCode: codes.PermissionDenied},
+		{name: "personal access token on sensitive method", md: []string{"authorization", "Bearer pat-2"}, method: methodCreatePAT, wantCode: codes.PermissionDenied},
+
+		{name: "impersonation on allowed method", md: bearer(impersonation), method: methodProfile, wantCode: codes.OK, wantPrincipal: PrincipalUser},
+		{name: "impersonation on other method", md: bearer(impersonation), method: methodDelete, wantCode: codes.PermissionDenied},
+		{name: "impersonation on public method", md: bearer(impersonation), method: methodLogin, wantCode: codes.PermissionDenied},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(tt.md...))
+			var principal Principal
+			handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+				principal, _ = PrincipalFromContext(ctx)
+				return "ok", nil
+			}
+
+			_, err := i.Unary()(ctx, nil, &grpc.UnaryServerInfo{FullMethod: tt.method}, handler)
+			if got := status.Code(err); got != tt.wantCode {
+				t.Fatalf("got %v (%v), want %v", got, err, tt.wantCode)
+			}
+			if principal.Kind != tt.wantPrincipal {
+				t.Fatalf("got principal %q, want %q", principal.Kind, tt.wantPrincipal)
+			}
+		})
+	}
+}
+
+func TestAuthInterceptorRecordsImpersonatedCalls(t *testing.T) {
+	i, tokens, calls := newTestInterceptor(t)
+	token := issueTestToken(t, tokens, utils.AuthClaims{Role: "user", Actor: &utils.Actor{Subject: "admin"}})
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs("authorization", "Bearer "+token))
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) { return "ok", nil }
+
+	for _, method := range []string{methodProfile, methodDelete, methodLogin} {
+		_, _ = i.Unary()(ctx, nil, &grpc.UnaryServerInfo{FullMethod: method}, handler)
+	}
+
+	want := []impersonatedCall{{methodProfile, true}, {methodDelete, false}, {methodLogin, false}}
+	if len(*calls) != len(want) {
+		t.Fatalf("got %d recorded calls, want %d", len(*calls), len(want))
+	}
+	for n, call := range *calls {
+		if call != want[n] {
+			t.Fatalf("call %d: got %+v, want %+v", n, call, want[n])
+		}
+	}
+}
@@ -5,10 +5,28 @@ import (
 	"errors"
 	"strings"
 
-	"github.com/golang-jwt/jwt/v5"
 	"google.golang.org/grpc/metadata"
 )
 
+// ExtractAPIKeyFromContext returns the x-api-key metadata, or "" when absent.
+func ExtractAPIKeyFromContext(ctx context.Context) string {
+	md, _ := metadata.FromIncomingContext(ctx)
+	if vals := md.Get("x-api-key"); len(vals) > 0 {
+		return strings.TrimSpace(vals[0])
+	}
+	return ""
+}
+
+// ExtractTenantFromContext returns the x-tenant metadata, the slug of the
+// tenant a public call is for, or "" for the default tenant.
+func ExtractTenantFromContext(ctx context.Context) string {
+	md, _ := metadata.FromIncomingContext(ctx)
+	if vals := md.Get("x-tenant"); len(vals) > 0 {
+		return strings.ToLower(strings.TrimSpace(vals[0]))
+	}
+	return ""
+}
+
 func ExtractTokenFromContext(ctx context.Context) (string, error) {
 	md, ok := metadata.FromIncomingContext(ctx)
 	if !ok {
@@ -24,18 +42,3 @@ func ExtractTokenFromContext(ctx context.Context) (string, error) {
 	token = strings.TrimPrefix(token, "Bearer ")
 	return token, nil
 }
-
-func ValidateJWT(tokenString, secret string) (jwt.MapClaims, error) {
-	token, err := jwt.Parse(tokenString, func(t *jwt.Token) (interface{}, error) {
-		return []byte(secret), nil
-	})
-	if err != nil || !token.Valid {
-		return nil, errors.New("invalid or expired token")
-	}
-
-	claims, ok := token.Claims.(jwt.MapClaims)
-	if !ok {
-		return nil, errors.New("invalid claims")
-	}
-	return claims, nil
-}
@@ -0,0 +1,126 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+)
+
+type clientInfoKey struct{}
+
+type clientInfo struct {
+	ip        string
+	userAgent string
+}
+
+// WithClientInfo attaches caller details for requests that don't arrive over
+// gRPC, such as the OAuth HTTP endpoints.
+func WithClientInfo(ctx context.Context, ip, userAgent string) context.Context {
+	return context.WithValue(ctx, clientInfoKey{}, clientInfo{ip: ip, userAgent: userAgent})
+}
+
+// trustedProxies are the proxies whose X-Forwarded-For is believed. It is set
+// once at startup.
+var trustedProxies []*net.IPNet
+
+// SetTrustedProxies sets the proxies, as IPs or CIDRs, whose X-Forwarded-For
+// header is used as the caller's IP. Calls from any other address are
+// recorded with their own address, so clients can't forge it.
+func SetTrustedProxies(proxies []string) error {
+	var nets []*net.IPNet
+	for _, p := range proxies {
+		p = strings.TrimSpace(p)
+		if p == "" {
+			continue
+		}
+		if !strings.Contains(p, "/") {
+			if ip := net.ParseIP(p); ip != nil && ip.To4() != nil {
+				p += "/32"
+			} else {
+				p += "/128"
+			}
+		}
+		_, n, err := net.ParseCIDR(p)
+		if err != nil {
+			return fmt.Errorf("invalid trusted proxy %q", p)
+		}
+		nets = append(nets, n)
+	}
+	trustedProxies = nets
+	return nil
+}
+
+func isTrustedProxy(ip string) bool {
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return false
+	}
+	for _, n := range trustedProxies {
+		if n.Contains(parsed) {
+			return true
+		}
+	}
+	return false
+}
+
+// clientIP returns the caller's IP given the connection's peer address and
+// the X-Forwarded-For header. The header is only read when the peer is a
+// trusted proxy, from the right, skipping the trusted proxies it passed.
+func clientIP(peerAddr, forwardedFor string) string {
+	ip := peerAddr
+	if host, _, err := net.SplitHostPort(ip); err == nil {
+		ip = host
+	}
+	if forwardedFor == "" || !isTrustedProxy(ip) {
+		return ip
+	}
+
+	hops := strings.Split(forwardedFor, ",")
+	for j := len(hops) - 1; j >= 0; j-- {
+		hop := strings.TrimSpace(hops[j])
+		if hop == "" {
+			continue
+		}
+		ip = hop
+		if !isTrustedProxy(hop) {
+			break
+		}
+	}
+	return ip
+}
+
+// WithHTTPClientInfo attaches the address and user agent of an HTTP request to
+// its context, using the same forwarded-IP rule as gRPC requests.
+func WithHTTPClientInfo(r *http.Request) context.Context {
+	ip := clientIP(r.RemoteAddr, r.Header.Get("X-Forwarded-For"))
+	return WithClientInfo(r.Context(), ip, r.UserAgent())
+}
+
+// ClientInfoFromContext returns the caller's IP and user agent. A forwarded IP
+// is only used when the peer is a trusted proxy.
+func ClientInfoFromContext(ctx context.Context) (ip, userAgent string) {
+	if info, ok := ctx.Value(clientInfoKey{}).(clientInfo); ok {
+		return info.ip, info.userAgent
+	}
+
+	md, _ := metadata.FromIncomingContext(ctx)
+
+	var peerAddr, forwardedFor string
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		peerAddr = p.Addr.String()
+	}
+	if vals := md.Get("x-forwarded-for"); len(vals) > 0 {
+		forwardedFor = strings.Join(vals, ",")
+	}
+	ip = clientIP(peerAddr, forwardedFor)
+
+	if vals := md.Get("user-agent"); len(vals) > 0 {
+		userAgent = vals[0]
+	}
+	return ip, userAgent
+}
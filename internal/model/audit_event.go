@@ -0,0 +1,16 @@
+package model
+
+import "go.mongodb.org/mongo-driver/bson/primitive"
+
+// AuditEvent is an append-only record of a security-relevant action.
+type AuditEvent struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	ActorID   string             `bson:"actor_id,omitempty" json:"actor_id,omitempty"`     // who performed the action
+	SubjectID string             `bson:"subject_id,omitempty" json:"subject_id,omitempty"` // whose account it affected
+	Action    string             `bson:"action" json:"action"`
+	Outcome   string             `bson:"outcome" json:"outcome"` // "success" or "failure"
+	IP        string             `bson:"ip,omitempty" json:"ip,omitempty"`
+	UserAgent string             `bson:"user_agent,omitempty" json:"user_agent,omitempty"`
+	Details   map[string]string  `bson:"details,omitempty" json:"details,omitempty"`
+	Timestamp int64              `bson:"timestamp" json:"timestamp"`
+}
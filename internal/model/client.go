@@ -0,0 +1,23 @@
+package model
+
+import "go.mongodb.org/mongo-driver/bson/primitive"
+
+// Client is a registered OAuth client application. Public clients have no
+// secret hash.
+type Client struct {
+	ID              primitive.ObjectID `bson:"_id,omitempty"`
+	ClientID        string             `bson:"client_id"`
+	Name            string             `bson:"name"`
+	SecretHash      string             `bson:"secret_hash,omitempty"`
+	RedirectURIs    []string           `bson:"redirect_uris"`
+	GrantTypes      []string           `bson:"grant_types"`
+	Scopes          []string           `bson:"scopes"`
+	CreatedAt       int64              `bson:"created_at"`
+	UpdatedAt       int64              `bson:"updated_at"`
+	SecretRotatedAt int64              `bson:"secret_rotated_at,omitempty"`
+	TenantID        string             `bson:"tenant_id,omitempty"` // whose users sign in through it
+
+	// Token lifetimes in seconds for this client; 0 uses the defaults
+	AccessTokenTTL  int64 `bson:"access_token_ttl,omitempty"`
+	RefreshTokenTTL int64 `bson:"refresh_token_ttl,omitempty"`
+}
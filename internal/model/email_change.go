@@ -0,0 +1,18 @@
+package model
+
+import "go.mongodb.org/mongo-driver/bson/primitive"
+
+// EmailChange tracks a requested email change until it is confirmed by the new
+// address, and keeps a revert token for the old address afterwards.
+type EmailChange struct {
+	ID               primitive.ObjectID `bson:"_id,omitempty"`
+	UserID           primitive.ObjectID `bson:"user_id"`
+	OldEmail         string             `bson:"old_email"`
+	NewEmail         string             `bson:"new_email"`
+	ConfirmTokenHash string             `bson:"confirm_token_hash"`
+	ConfirmExp       int64              `bson:"confirm_exp"`
+	RevertTokenHash  string             `bson:"revert_token_hash,omitempty"`
+	RevertExp        int64              `bson:"revert_exp,omitempty"`
+	ConfirmedAt      int64              `bson:"confirmed_at,omitempty"`
+	CreatedAt        int64              `bson:"created_at"`
+}
@@ -0,0 +1,24 @@
+package model
+
+import "go.mongodb.org/mongo-driver/bson/primitive"
+
+// Group collects users of a tenant so roles can be granted to all of them at
+// once. A member's effective roles are their own role plus every group's.
+type Group struct {
+	ID          primitive.ObjectID `bson:"_id,omitempty"`
+	TenantID    string             `bson:"tenant_id,omitempty"`
+	Name        string             `bson:"name"`
+	Description string             `bson:"description,omitempty"`
+	Roles       []string           `bson:"roles"`
+	CreatedAt   int64              `bson:"created_at"`
+	UpdatedAt   int64              `bson:"updated_at"`
+}
+
+// GroupMember puts a user in a group.
+type GroupMember struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty"`
+	GroupID   primitive.ObjectID `bson:"group_id"`
+	UserID    primitive.ObjectID `bson:"user_id"`
+	AddedBy   primitive.ObjectID `bson:"added_by"`
+	CreatedAt int64              `bson:"created_at"`
+}
@@ -0,0 +1,42 @@
+package model
+
+import "go.mongodb.org/mongo-driver/bson/primitive"
+
+// Login method types. A password lives on the user itself; every other
+// method is an Identity.
+const (
+	LoginMethodPassword = "password"
+	LoginMethodOIDC     = "oidc"
+	LoginMethodPasskey  = "passkey"
+)
+
+// Identity is a login method held by a user besides their password, such as
+// an account at an external OIDC provider or a passkey.
+type Identity struct {
+	ID          primitive.ObjectID `bson:"_id,omitempty"`
+	UserID      primitive.ObjectID `bson:"user_id"`
+	Type        string             `bson:"type"`
+	Provider    string             `bson:"provider"`
+	Subject     string             `bson:"subject"` // the provider's sub claim, or the passkey credential ID
+	Email       string             `bson:"email,omitempty"`
+	Name        string             `bson:"name,omitempty"` // user-chosen label
+	CreatedAt   int64              `bson:"created_at"`
+	LastLoginAt int64              `bson:"last_login_at,omitempty"`
+
+	Passkey *PasskeyCredential `bson:"passkey,omitempty"`
+}
+
+// FederationState is the pending half of a login redirect to an external
+// provider, looked up again when the provider calls back. A state with a
+// LinkUserID and no Nonce is a link ticket: it starts a redirect that links
+// the external account to that user instead of signing in.
+type FederationState struct {
+	ID           primitive.ObjectID `bson:"_id,omitempty"`
+	StateHash    string             `bson:"state_hash"`
+	Provider     string             `bson:"provider"`
+	Nonce        string             `bson:"nonce"`
+	CodeVerifier string             `bson:"code_verifier"`
+	DeviceName   string             `bson:"device_name,omitempty"`
+	LinkUserID   primitive.ObjectID `bson:"link_user_id,omitempty"`
+	ExpiresAt    int64              `bson:"expires_at"`
+}
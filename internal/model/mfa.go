@@ -0,0 +1,52 @@
+package model
+
+import "go.mongodb.org/mongo-driver/bson/primitive"
+
+// MFAChallenge is a password login waiting for its second factor. The client
+// holds the token and exchanges it once a factor has been verified.
+type MFAChallenge struct {
+	ID         primitive.ObjectID `bson:"_id,omitempty"`
+	TokenHash  string             `bson:"token_hash"`
+	UserID     primitive.ObjectID `bson:"user_id"`
+	DeviceName string             `bson:"device_name,omitempty"`
+	ClientID   string             `bson:"client_id,omitempty"`
+	Scope      string             `bson:"scope,omitempty"`
+	CreatedAt  int64              `bson:"created_at"`
+	ExpiresAt  int64              `bson:"expires_at"`
+}
+
+// OTP delivery channels.
+const (
+	OTPChannelEmail = "email"
+	OTPChannelSMS   = "sms"
+)
+
+// OTPFactor is an enrolled one-time passcode factor.
+type OTPFactor struct {
+	Channel     string `bson:"channel"`
+	Destination string `bson:"destination"` // email address or phone number
+	EnabledAt   int64  `bson:"enabled_at"`
+}
+
+// OTP code purposes.
+const (
+	OTPPurposeEnroll = "enroll"
+	OTPPurposeLogin  = "login"
+)
+
+// OTPCode is a one-time passcode that was sent and is waiting to be entered.
+type OTPCode struct {
+	ID          primitive.ObjectID `bson:"_id,omitempty"`
+	UserID      primitive.ObjectID `bson:"user_id"`
+	Purpose     string             `bson:"purpose"`
+	ChallengeID primitive.ObjectID `bson:"challenge_id,omitempty"` // the MFA challenge of a login code
+	CodeHash    string             `bson:"code_hash"`
+	Channel     string             `bson:"channel"`
+	Destination string             `bson:"destination"`
+	Attempts    int                `bson:"attempts"`
+	CreatedAt   int64              `bson:"created_at"`
+	ExpiresAt   int64              `bson:"expires_at"`
+}
+
+// MFAMethodOTP is the name of the one-time passcode factor in an MFA challenge.
+const MFAMethodOTP = "otp"
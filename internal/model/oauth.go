@@ -0,0 +1,35 @@
+package model
+
+import "go.mongodb.org/mongo-driver/bson/primitive"
+
+// AuthorizationCode is a single-use code from the OAuth authorization endpoint.
+type AuthorizationCode struct {
+	ID                  primitive.ObjectID `bson:"_id,omitempty"`
+	CodeHash            string             `bson:"code_hash"`
+	ClientID            string             `bson:"client_id"`
+	UserID              primitive.ObjectID `bson:"user_id"`
+	RedirectURI         string             `bson:"redirect_uri"`
+	Scope               string             `bson:"scope"`
+	CodeChallenge       string             `bson:"code_challenge"`
+	CodeChallengeMethod string             `bson:"code_challenge_method"`
+	Nonce               string             `bson:"nonce,omitempty"`
+	AuthTime            int64              `bson:"auth_time"`
+	ExpiresAt           int64              `bson:"expires_at"`
+	CreatedAt           int64              `bson:"created_at"`
+}
+
+// RefreshToken belongs to one session; rotating it issues a new token in the
+// same session and marks the old one as used.
+type RefreshToken struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty"`
+	TokenHash string             `bson:"token_hash"`
+	ClientID  string             `bson:"client_id"`
+	UserID    primitive.ObjectID `bson:"user_id"`
+	SessionID primitive.ObjectID `bson:"session_id"`
+	Scope     string             `bson:"scope"`
+	AuthTime  int64              `bson:"auth_time"` // when the user last signed in, for ID tokens
+	ExpiresAt int64              `bson:"expires_at"`
+	CreatedAt int64              `bson:"created_at"`
+	RotatedAt int64              `bson:"rotated_at,omitempty"`
+	RevokedAt int64              `bson:"revoked_at,omitempty"`
+}
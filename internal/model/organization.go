@@ -0,0 +1,37 @@
+package model
+
+import "go.mongodb.org/mongo-driver/bson/primitive"
+
+// Organization is a team inside a tenant. Users of the tenant can belong to
+// several organizations, with a different role in each.
+type Organization struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty"`
+	TenantID  string             `bson:"tenant_id,omitempty"`
+	Name      string             `bson:"name"`
+	CreatedBy primitive.ObjectID `bson:"created_by"`
+	CreatedAt int64              `bson:"created_at"`
+	UpdatedAt int64              `bson:"updated_at"`
+}
+
+// Membership links a user to an organization with their role in it.
+type Membership struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty"`
+	OrgID     primitive.ObjectID `bson:"org_id"`
+	UserID    primitive.ObjectID `bson:"user_id"`
+	Role      string             `bson:"role"` // owner, admin or member
+	CreatedAt int64              `bson:"created_at"`
+	UpdatedAt int64              `bson:"updated_at"`
+}
+
+// Invitation is a pending invite to join an organization. Only a hash of the
+// emailed token is stored; accepting or revoking deletes it.
+type Invitation struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty"`
+	OrgID     primitive.ObjectID `bson:"org_id"`
+	Email     string             `bson:"email"`
+	Role      string             `bson:"role"`
+	TokenHash string             `bson:"token_hash"`
+	InvitedBy primitive.ObjectID `bson:"invited_by"`
+	CreatedAt int64              `bson:"created_at"`
+	ExpiresAt int64              `bson:"expires_at"`
+}
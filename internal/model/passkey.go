@@ -0,0 +1,35 @@
+package model
+
+import "go.mongodb.org/mongo-driver/bson/primitive"
+
+// PasskeyProvider is the Identity.Provider of every passkey.
+const PasskeyProvider = "webauthn"
+
+// PasskeyCredential is the WebAuthn public key credential stored on a passkey
+// identity.
+type PasskeyCredential struct {
+	PublicKey       []byte   `bson:"public_key"`
+	AttestationType string   `bson:"attestation_type,omitempty"`
+	AAGUID          []byte   `bson:"aaguid,omitempty"`
+	SignCount       uint32   `bson:"sign_count"`
+	CloneWarning    bool     `bson:"clone_warning,omitempty"`
+	Flags           byte     `bson:"flags"` // raw authenticator flags
+	Transports      []string `bson:"transports,omitempty"`
+}
+
+// WebAuthn ceremony purposes.
+const (
+	CeremonyRegistration = "registration"
+	CeremonyLogin        = "login"
+)
+
+// WebAuthnCeremony holds the server side of a passkey registration or login
+// between its begin and finish calls.
+type WebAuthnCeremony struct {
+	ID           primitive.ObjectID `bson:"_id,omitempty"`
+	CeremonyHash string             `bson:"ceremony_hash"`
+	Purpose      string             `bson:"purpose"`
+	UserID       primitive.ObjectID `bson:"user_id,omitempty"` // empty for discoverable login
+	Session      []byte             `bson:"session"`           // JSON encoded webauthn.SessionData
+	ExpiresAt    int64              `bson:"expires_at"`
+}
@@ -0,0 +1,19 @@
+package model
+
+import "go.mongodb.org/mongo-driver/bson/primitive"
+
+// PersonalAccessToken lets a user call the API from scripts without their
+// password. Only a hash of the token is stored; Prefix is the public part
+// shown in listings.
+type PersonalAccessToken struct {
+	ID         primitive.ObjectID `bson:"_id,omitempty"`
+	UserID     primitive.ObjectID `bson:"user_id"`
+	Name       string             `bson:"name"`
+	Prefix     string             `bson:"prefix"`
+	TokenHash  string             `bson:"token_hash"`
+	Scopes     []string           `bson:"scopes"`
+	CreatedAt  int64              `bson:"created_at"`
+	ExpiresAt  int64              `bson:"expires_at"`
+	LastUsedAt int64              `bson:"last_used_at,omitempty"`
+	RevokedAt  int64              `bson:"revoked_at,omitempty"`
+}
@@ -0,0 +1,35 @@
+package model
+
+import "go.mongodb.org/mongo-driver/bson/primitive"
+
+// Policy allows or denies actions on resources to subjects with certain
+// roles, optionally only under conditions. A deny beats any allow, and what no
+// policy allows is denied.
+type Policy struct {
+	ID          primitive.ObjectID `bson:"_id,omitempty"`
+	Name        string             `bson:"name"`
+	Description string             `bson:"description,omitempty"`
+	Effect      string             `bson:"effect"`    // allow or deny
+	Roles       []string           `bson:"roles"`     // any of these; empty matches every subject
+	Actions     []string           `bson:"actions"`   // e.g. "documents:read", "documents:*" or "*"
+	Resources   []string           `bson:"resources"` // resource types, or "*"
+	Conditions  PolicyConditions   `bson:"conditions"`
+	CreatedAt   int64              `bson:"created_at"`
+	UpdatedAt   int64              `bson:"updated_at"`
+}
+
+// PolicyConditions narrow a policy down by attributes. Unset conditions
+// always hold.
+type PolicyConditions struct {
+	SameTenant bool        `bson:"same_tenant,omitempty"` // resource is in the subject's tenant
+	Owner      bool        `bson:"owner,omitempty"`       // subject owns the resource
+	TimeOfDay  *TimeWindow `bson:"time_of_day,omitempty"`
+}
+
+// TimeWindow is a daily window such as 09:00-17:00. A window whose end is
+// before its start runs past midnight.
+type TimeWindow struct {
+	Start    string `bson:"start"` // HH:MM
+	End      string `bson:"end"`   // HH:MM, exclusive
+	Timezone string `bson:"timezone,omitempty"`
+}
@@ -0,0 +1,29 @@
+package model
+
+import "go.mongodb.org/mongo-driver/bson/primitive"
+
+// ServiceAccount is a non-human principal, such as a backend job, that
+// authenticates with API keys instead of a password.
+type ServiceAccount struct {
+	ID          primitive.ObjectID `bson:"_id,omitempty"`
+	Name        string             `bson:"name"`
+	Description string             `bson:"description,omitempty"`
+	Scopes      []string           `bson:"scopes"`
+	CreatedBy   string             `bson:"created_by"`
+	CreatedAt   int64              `bson:"created_at"`
+}
+
+// APIKey is a secret a service account sends in the x-api-key metadata. Only
+// a hash of the key is stored; Prefix is the public part shown in listings.
+type APIKey struct {
+	ID               primitive.ObjectID `bson:"_id,omitempty"`
+	ServiceAccountID primitive.ObjectID `bson:"service_account_id"`
+	Name             string             `bson:"name"`
+	Prefix           string             `bson:"prefix"`
+	KeyHash          string             `bson:"key_hash"`
+	Scopes           []string           `bson:"scopes"`
+	CreatedAt        int64              `bson:"created_at"`
+	ExpiresAt        int64              `bson:"expires_at,omitempty"` // 0 means no expiry
+	LastUsedAt       int64              `bson:"last_used_at,omitempty"`
+	RevokedAt        int64              `bson:"revoked_at,omitempty"`
+}
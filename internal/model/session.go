@@ -0,0 +1,16 @@
+package model
+
+import "go.mongodb.org/mongo-driver/bson/primitive"
+
+// Session is one signed-in device. Its ID is carried in the token's sid claim.
+type Session struct {
+	ID         primitive.ObjectID `bson:"_id,omitempty"`
+	UserID     primitive.ObjectID `bson:"user_id"`
+	DeviceName string             `bson:"device_name"`
+	IP         string             `bson:"ip"`
+	UserAgent  string             `bson:"user_agent"`
+	CreatedAt  int64              `bson:"created_at"`
+	LastSeenAt int64              `bson:"last_seen_at"`
+	ExpiresAt  int64              `bson:"expires_at"`
+	RevokedAt  int64              `bson:"revoked_at,omitempty"`
+}
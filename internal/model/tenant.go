@@ -0,0 +1,13 @@
+package model
+
+import "go.mongodb.org/mongo-driver/bson/primitive"
+
+// Tenant is a customer organization with its own users. Users without a
+// tenant belong to the default tenant, whose admins run the platform.
+type Tenant struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty"`
+	Name      string             `bson:"name"`
+	Slug      string             `bson:"slug"` // sent by clients in the x-tenant header
+	CreatedAt int64              `bson:"created_at"`
+	UpdatedAt int64              `bson:"updated_at"`
+}
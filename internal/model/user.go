@@ -12,8 +12,16 @@ type User struct {
 	Email     string             `bson:"email"`
 	Password  string             `bson:"password"`
 	Role      string             `bson:"role"`
-	IsDeleted bool               `bson:"is_deleted"` //สำหรับ soft delete
+	TenantID  string             `bson:"tenant_id,omitempty"` // empty for the default tenant
+	IsDeleted bool               `bson:"is_deleted"`          //สำหรับ soft delete
+	DeletedAt int64              `bson:"deleted_at,omitempty"`
 	CreatedAt int64              `bson:"created_at"`
+
+	// One-time passcode second factor, nil until the user enrolls
+	OTPFactor *OTPFactor `bson:"otp_factor,omitempty"`
+
+	// Set once the retention job has scrubbed personal data from a deleted user
+	AnonymizedAt int64 `bson:"anonymized_at,omitempty"`
 }
 
 type BlacklistedToken struct {
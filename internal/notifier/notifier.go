@@ -0,0 +1,23 @@
+package notifier
+
+import (
+	"context"
+	"log"
+)
+
+// Notifier delivers a message to a user-facing address (email, phone, ...).
+type Notifier interface {
+	Notify(ctx context.Context, to, subject, body string) error
+}
+
+// LogNotifier only writes messages to the server log. Used for local development.
+type LogNotifier struct{}
+
+func NewLogNotifier() *LogNotifier {
+	return &LogNotifier{}
+}
+
+func (n *LogNotifier) Notify(ctx context.Context, to, subject, body string) error {
+	log.Printf("📧 to=%s subject=%q\n%s", to, subject, body)
+	return nil
+}
@@ -0,0 +1,215 @@
+package oauth
+
+import (
+	"html/template"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/bekbek22/auth_service/internal/audit"
+	"github.com/bekbek22/auth_service/internal/middleware"
+	"github.com/bekbek22/auth_service/internal/model"
+	"github.com/bekbek22/auth_service/internal/utils"
+)
+
+var loginPage = template.Must(template.New("login").Parse(`<!DOCTYPE html>
+<html>
+<head><meta charset="utf-8"><title>Sign in</title></head>
+<body>
+  <h1>Sign in to continue to {{.ClientID}}</h1>
+  {{if .Error}}<p style="color:red">{{.Error}}</p>{{end}}
+  <form method="POST" action="/oauth/authorize">
+    <input type="hidden" name="response_type" value="code">
+    <input type="hidden" name="client_id" value="{{.ClientID}}">
+    <input type="hidden" name="redirect_uri" value="{{.RedirectURI}}">
+    <input type="hidden" name="scope" value="{{.Scope}}">
+    <input type="hidden" name="state" value="{{.State}}">
+    <input type="hidden" name="code_challenge" value="{{.CodeChallenge}}">
+    <input type="hidden" name="code_challenge_method" value="{{.CodeChallengeMethod}}">
+    <input type="hidden" name="nonce" value="{{.Nonce}}">
+    <p><label>Email <input type="email" name="email" required></label></p>
+    <p><label>Password <input type="password" name="password" required></label></p>
+    <p><button type="submit">Sign in</button></p>
+  </form>
+</body>
+</html>`))
+
+type authorizeRequest struct {
+	ClientID            string
+	RedirectURI         string
+	Scope               string
+	State               string
+	CodeChallenge       string
+	CodeChallengeMethod string
+	Nonce               string
+	Error               string
+
+	client *Client
+}
+
+// parseAuthorizeRequest validates an authorization request. Until the client
+// and redirect URI are known to be valid, errors are shown to the user instead
+// of being redirected (RFC 6749 section 4.1.2.1).
+func (s *Server) parseAuthorizeRequest(w http.ResponseWriter, r *http.Request, values url.Values) (*authorizeRequest, bool) {
+	req := &authorizeRequest{
+		ClientID:            values.Get("client_id"),
+		RedirectURI:         values.Get("redirect_uri"),
+		Scope:               values.Get("scope"),
+		State:               values.Get("state"),
+		CodeChallenge:       values.Get("code_challenge"),
+		CodeChallengeMethod: values.Get("code_challenge_method"),
+		Nonce:               values.Get("nonce"),
+	}
+
+	client, err := s.clients.FindClient(r.Context(), req.ClientID)
+	if err != nil {
+		http.Error(w, "unknown client_id", http.StatusBadRequest)
+		return nil, false
+	}
+	if !client.AllowsRedirectURI(req.RedirectURI) {
+		http.Error(w, "redirect_uri is not registered for this client", http.StatusBadRequest)
+		return nil, false
+	}
+	req.client = client
+
+	if values.Get("response_type") != "code" {
+		redirectError(w, r, req, "unsupported_response_type", "only response_type=code is supported")
+		return nil, false
+	}
+	if !client.AllowsGrant(GrantAuthorizationCode) {
+		redirectError(w, r, req, "unauthorized_client", "client may not use the authorization code grant")
+		return nil, false
+	}
+	// PKCE is required for every client, and only with S256
+	if req.CodeChallenge == "" || req.CodeChallengeMethod != "S256" {
+		redirectError(w, r, req, "invalid_request", "code_challenge with code_challenge_method=S256 is required")
+		return nil, false
+	}
+	scope, ok := resolveScope(client, req.Scope)
+	if !ok {
+		redirectError(w, r, req, "invalid_scope", "requested scope is not allowed for this client")
+		return nil, false
+	}
+	req.Scope = scope
+	return req, true
+}
+
+func redirectError(w http.ResponseWriter, r *http.Request, req *authorizeRequest, code, description string) {
+	q := url.Values{"error": {code}, "error_description": {description}}
+	if req.State != "" {
+		q.Set("state", req.State)
+	}
+	http.Redirect(w, r, appendQuery(req.RedirectURI, q), http.StatusFound)
+}
+
+func appendQuery(uri string, q url.Values) string {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return uri
+	}
+	existing := u.Query()
+	for k, v := range q {
+		existing[k] = v
+	}
+	u.RawQuery = existing.Encode()
+	return u.String()
+}
+
+func (s *Server) handleAuthorizeForm(w http.ResponseWriter, r *http.Request) {
+	req, ok := s.parseAuthorizeRequest(w, r, r.URL.Query())
+	if !ok {
+		return
+	}
+	renderLogin(w, req)
+}
+
+func renderLogin(w http.ResponseWriter, req *authorizeRequest) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	_ = loginPage.Execute(w, req)
+}
+
+// handleAuthorize authenticates the resource owner with email and password and
+// redirects back to the client with an authorization code.
+func (s *Server) handleAuthorize(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "invalid form", http.StatusBadRequest)
+		return
+	}
+	req, ok := s.parseAuthorizeRequest(w, r, r.PostForm)
+	if !ok {
+		return
+	}
+
+	ctx := middleware.WithHTTPClientInfo(r)
+	email := r.PostFormValue("email")
+	password := r.PostFormValue("password")
+
+	if !s.rateLimiter.Allow(email) {
+		req.Error = "Too many login attempts, please wait"
+		renderLogin(w, req)
+		return
+	}
+
+	// Users sign in to the tenant the client belongs to
+	user, err := s.users.FindByEmail(ctx, req.client.TenantID, email)
+	if err != nil || !utils.CheckPasswordHash(password, user.Password) {
+		s.audit.Record(ctx, model.AuditEvent{
+			Action:  audit.ActionLogin,
+			Outcome: audit.OutcomeFailure,
+			Details: map[string]string{"email": email, "client_id": req.ClientID, "reason": "invalid_credentials"},
+		})
+		req.Error = "Invalid email or password"
+		renderLogin(w, req)
+		return
+	}
+
+	// This form only knows passwords, so it must not let a second factor be skipped
+	if required, err := s.requiresMFA(ctx, user.ID); err != nil || required {
+		s.audit.Record(ctx, model.AuditEvent{
+			SubjectID: user.ID.Hex(),
+			Action:    audit.ActionLogin,
+			Outcome:   audit.OutcomeFailure,
+			Details:   map[string]string{"client_id": req.ClientID, "reason": "mfa_required"},
+		})
+		req.Error = "This account uses two-step sign-in, which this page does not support yet"
+		renderLogin(w, req)
+		return
+	}
+
+	code, err := utils.GenerateOpaqueToken(32)
+	if err != nil {
+		redirectError(w, r, req, "server_error", "failed to generate code")
+		return
+	}
+	err = s.codes.Save(ctx, &model.AuthorizationCode{
+		CodeHash:            utils.HashToken(code),
+		ClientID:            req.ClientID,
+		UserID:              user.ID,
+		RedirectURI:         req.RedirectURI,
+		Scope:               req.Scope,
+		CodeChallenge:       req.CodeChallenge,
+		CodeChallengeMethod: req.CodeChallengeMethod,
+		Nonce:               req.Nonce,
+		AuthTime:            time.Now().Unix(),
+		ExpiresAt:           time.Now().Add(authorizationCodeTTL).Unix(),
+	})
+	if err != nil {
+		redirectError(w, r, req, "server_error", "failed to save code")
+		return
+	}
+
+	s.audit.Record(ctx, model.AuditEvent{
+		ActorID:   user.ID.Hex(),
+		SubjectID: user.ID.Hex(),
+		Action:    audit.ActionOAuthAuthorize,
+		Outcome:   audit.OutcomeSuccess,
+		Details:   map[string]string{"client_id": req.ClientID, "scope": req.Scope},
+	})
+
+	q := url.Values{"code": {code}}
+	if req.State != "" {
+		q.Set("state", req.State)
+	}
+	http.Redirect(w, r, appendQuery(req.RedirectURI, q), http.StatusFound)
+}
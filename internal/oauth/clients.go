@@ -0,0 +1,87 @@
+package oauth
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"time"
+
+	"github.com/bekbek22/auth_service/internal/model"
+	"github.com/bekbek22/auth_service/internal/repository"
+	"github.com/bekbek22/auth_service/internal/utils"
+)
+
+const (
+	GrantAuthorizationCode = "authorization_code"
+	GrantClientCredentials = "client_credentials"
+	GrantRefreshToken      = "refresh_token"
+)
+
+var ErrClientNotFound = errors.New("client not found")
+
+// Client is an application allowed to request tokens. Public clients (SPAs,
+// native apps) have no secret and must use PKCE.
+type Client struct {
+	ID           string
+	SecretHash   string
+	RedirectURIs []string
+	GrantTypes   []string
+	Scopes       []string
+	TenantID     string
+
+	// Overrides of the server's token lifetimes, 0 if unset
+	AccessTokenTTL  time.Duration
+	RefreshTokenTTL time.Duration
+}
+
+func (c *Client) IsPublic() bool {
+	return c.SecretHash == ""
+}
+
+func (c *Client) AllowsGrant(grant string) bool {
+	return slices.Contains(c.GrantTypes, grant)
+}
+
+func (c *Client) AllowsRedirectURI(uri string) bool {
+	return slices.Contains(c.RedirectURIs, uri)
+}
+
+func (c *Client) CheckSecret(secret string) bool {
+	return !c.IsPublic() && utils.CheckPasswordHash(secret, c.SecretHash)
+}
+
+// ClientStore looks up registered clients.
+type ClientStore interface {
+	FindClient(ctx context.Context, clientID string) (*Client, error)
+}
+
+// RepositoryClientStore serves clients from the clients collection.
+type RepositoryClientStore struct {
+	repo *repository.ClientRepository
+}
+
+func NewRepositoryClientStore(repo *repository.ClientRepository) *RepositoryClientStore {
+	return &RepositoryClientStore{repo: repo}
+}
+
+func (s *RepositoryClientStore) FindClient(ctx context.Context, clientID string) (*Client, error) {
+	c, err := s.repo.FindByClientID(ctx, clientID)
+	if err != nil {
+		return nil, ErrClientNotFound
+	}
+	return fromModel(c), nil
+}
+
+func fromModel(c *model.Client) *Client {
+	return &Client{
+		ID:           c.ClientID,
+		SecretHash:   c.SecretHash,
+		RedirectURIs: c.RedirectURIs,
+		GrantTypes:   c.GrantTypes,
+		Scopes:       c.Scopes,
+		TenantID:     c.TenantID,
+
+		AccessTokenTTL:  time.Duration(c.AccessTokenTTL) * time.Second,
+		RefreshTokenTTL: time.Duration(c.RefreshTokenTTL) * time.Second,
+	}
+}
@@ -0,0 +1,185 @@
+package oauth
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/bekbek22/auth_service/internal/audit"
+	"github.com/bekbek22/auth_service/internal/middleware"
+	"github.com/bekbek22/auth_service/internal/model"
+	"github.com/bekbek22/auth_service/internal/utils"
+)
+
+// introspectionResponse follows RFC 7662 section 2.2.
+type introspectionResponse struct {
+	Active    bool   `json:"active"`
+	Scope     string `json:"scope,omitempty"`
+	ClientID  string `json:"client_id,omitempty"`
+	Sub       string `json:"sub,omitempty"`
+	TokenType string `json:"token_type,omitempty"`
+	Exp       int64  `json:"exp,omitempty"`
+	Iat       int64  `json:"iat,omitempty"`
+	Role      string `json:"role,omitempty"`
+	SessionID string `json:"sid,omitempty"`
+}
+
+func (s *Server) handleIntrospect(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_request", "invalid form")
+		return
+	}
+	if _, ok := s.authenticateClient(r, false); !ok {
+		writeError(w, http.StatusUnauthorized, "invalid_client", "client authentication failed")
+		return
+	}
+
+	token := r.PostFormValue("token")
+	if token == "" {
+		writeError(w, http.StatusBadRequest, "invalid_request", "token is required")
+		return
+	}
+
+	ctx := r.Context()
+	hint := r.PostFormValue("token_type_hint")
+	var resp introspectionResponse
+	if hint == "refresh_token" {
+		resp = s.introspectRefreshToken(ctx, token)
+		if !resp.Active {
+			resp = s.introspectAccessToken(ctx, token)
+		}
+	} else {
+		resp = s.introspectAccessToken(ctx, token)
+		if !resp.Active {
+			resp = s.introspectRefreshToken(ctx, token)
+		}
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (s *Server) introspectAccessToken(ctx context.Context, token string) introspectionResponse {
+	claims, err := s.issuer.Validate(token)
+	if err != nil {
+		return introspectionResponse{}
+	}
+	if !s.accessTokenActive(ctx, claims) {
+		return introspectionResponse{}
+	}
+
+	return introspectionResponse{
+		Active:    true,
+		TokenType: "Bearer",
+		Scope:     claims.Scope,
+		ClientID:  claims.ClientID,
+		Sub:       claims.Subject,
+		Exp:       claims.ExpiresAt.Unix(),
+		Iat:       claims.IssuedAt.Unix(),
+		Role:      claims.Role,
+		SessionID: claims.SessionID,
+	}
+}
+
+// accessTokenActive applies the same revocation checks as the gRPC interceptor.
+func (s *Server) accessTokenActive(ctx context.Context, claims *utils.AuthClaims) bool {
+	if revoked, err := s.revocations.IsTokenRevoked(ctx, claims.ID); err != nil || revoked {
+		return false
+	}
+	if claims.ClientID != "" {
+		if revoked, err := s.revocations.IsClientRevoked(ctx, claims.ClientID); err != nil || revoked {
+			return false
+		}
+	}
+	if sid := claims.SessionID; sid != "" {
+		if revoked, err := s.revocations.IsSessionRevoked(ctx, sid); err != nil || revoked {
+			return false
+		}
+	}
+	return true
+}
+
+func (s *Server) introspectRefreshToken(ctx context.Context, token string) introspectionResponse {
+	rt, err := s.refreshTokens.FindByHash(ctx, utils.HashToken(token))
+	if err != nil || rt.RotatedAt != 0 || rt.RevokedAt != 0 || rt.ExpiresAt <= time.Now().Unix() {
+		return introspectionResponse{}
+	}
+	if revoked, err := s.revocations.IsSessionRevoked(ctx, rt.SessionID.Hex()); err != nil || revoked {
+		return introspectionResponse{}
+	}
+
+	return introspectionResponse{
+		Active:    true,
+		TokenType: "refresh_token",
+		Scope:     rt.Scope,
+		ClientID:  rt.ClientID,
+		Sub:       rt.UserID.Hex(),
+		Exp:       rt.ExpiresAt,
+		Iat:       rt.CreatedAt,
+		SessionID: rt.SessionID.Hex(),
+	}
+}
+
+// handleRevoke implements RFC 7009. It answers 200 even for unknown tokens so
+// callers can't probe which tokens exist.
+func (s *Server) handleRevoke(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_request", "invalid form")
+		return
+	}
+	client, ok := s.authenticateClient(r, true)
+	if !ok {
+		writeError(w, http.StatusUnauthorized, "invalid_client", "client authentication failed")
+		return
+	}
+
+	token := r.PostFormValue("token")
+	if token == "" {
+		writeError(w, http.StatusBadRequest, "invalid_request", "token is required")
+		return
+	}
+
+	ctx := middleware.WithHTTPClientInfo(r)
+	if !s.revokeRefreshToken(ctx, client, token) {
+		s.revokeAccessToken(ctx, client, token)
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+func (s *Server) revokeRefreshToken(ctx context.Context, client *Client, token string) bool {
+	rt, err := s.refreshTokens.FindByHash(ctx, utils.HashToken(token))
+	if err != nil || rt.ClientID != client.ID {
+		return false
+	}
+
+	s.revokeSession(ctx, rt.UserID, rt.SessionID)
+	s.audit.Record(ctx, model.AuditEvent{
+		SubjectID: rt.UserID.Hex(),
+		Action:    audit.ActionTokenRevoke,
+		Outcome:   audit.OutcomeSuccess,
+		Details:   map[string]string{"client_id": client.ID, "token_type": "refresh_token", "session_id": rt.SessionID.Hex()},
+	})
+	return true
+}
+
+func (s *Server) revokeAccessToken(ctx context.Context, client *Client, token string) {
+	claims, err := s.issuer.Validate(token)
+	if err != nil {
+		return
+	}
+	// Clients may only revoke tokens issued to them
+	if claims.ClientID != client.ID {
+		return
+	}
+
+	jti, expiresAt := claims.ID, claims.ExpiresAt.Time
+	if err := s.tokens.BlacklistToken(ctx, jti, expiresAt); err != nil {
+		return
+	}
+	s.revocations.AddToken(jti, expiresAt)
+
+	s.audit.Record(ctx, model.AuditEvent{
+		SubjectID: claims.Subject,
+		Action:    audit.ActionTokenRevoke,
+		Outcome:   audit.OutcomeSuccess,
+		Details:   map[string]string{"client_id": client.ID, "token_type": "access_token", "jti": jti},
+	})
+}
@@ -0,0 +1,93 @@
+package oauth
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/bekbek22/auth_service/internal/utils"
+)
+
+func TestRevoke(t *testing.T) {
+	tests := []struct {
+		name            string
+		clientID        string
+		token           func(t *testing.T, s *testServer) string
+		wantCode        int
+		wantSessionEnd  bool
+		wantBlacklisted bool
+	}{
+		{
+			name:           "refresh token",
+			clientID:       "app",
+			token:          func(t *testing.T, s *testServer) string { return s.saveRefreshToken(t, "app", "profile:read") },
+			wantCode:       http.StatusOK,
+			wantSessionEnd: true,
+		},
+		{
+			name:     "another client's refresh token",
+			clientID: "other",
+			token:    func(t *testing.T, s *testServer) string { return s.saveRefreshToken(t, "app", "profile:read") },
+			wantCode: http.StatusOK,
+		},
+		{
+			name:            "access token",
+			clientID:        "app",
+			token:           func(t *testing.T, s *testServer) string { return s.accessToken(t, "app") },
+			wantCode:        http.StatusOK,
+			wantBlacklisted: true,
+		},
+		{
+			name:     "another client's access token",
+			clientID: "other",
+			token:    func(t *testing.T, s *testServer) string { return s.accessToken(t, "app") },
+			wantCode: http.StatusOK,
+		},
+		{
+			name:     "unknown token",
+			clientID: "app",
+			token:    func(t *testing.T, s *testServer) string { return "not-a-token" },
+			wantCode: http.StatusOK,
+		},
+		{
+			name:     "missing token",
+			clientID: "app",
+			token:    func(t *testing.T, s *testServer) string { return "" },
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:     "unknown client",
+			clientID: "nobody",
+			token:    func(t *testing.T, s *testServer) string { return s.accessToken(t, "app") },
+			wantCode: http.StatusUnauthorized,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestServer(t)
+			w := postForm(s.handleRevoke, url.Values{"client_id": {tt.clientID}, "token": {tt.token(t, s)}})
+
+			if w.Code != tt.wantCode {
+				t.Fatalf("got status %d, want %d: %s", w.Code, tt.wantCode, w.Body)
+			}
+			if ended := s.session.RevokedAt != 0; ended != tt.wantSessionEnd {
+				t.Fatalf("session revoked: got %v, want %v", ended, tt.wantSessionEnd)
+			}
+			if blacklisted := len(s.blacklist.tokens) > 0; blacklisted != tt.wantBlacklisted {
+				t.Fatalf("access token blacklisted: got %v, want %v", blacklisted, tt.wantBlacklisted)
+			}
+		})
+	}
+}
+
+// accessToken issues an access token to clientID for the test user.
+func (s *testServer) accessToken(t *testing.T, clientID string) string {
+	t.Helper()
+	claims := &utils.AuthClaims{Role: s.user.Role, Scope: "profile:read", SessionID: s.session.ID.Hex(), ClientID: clientID}
+	claims.Subject = s.user.ID.Hex()
+	token, err := s.issuer.Issue(claims, s.cfg.AccessTokenTTL, clientID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return token
+}
@@ -0,0 +1,161 @@
+package oauth
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/bekbek22/auth_service/config"
+	"github.com/bekbek22/auth_service/internal/audit"
+	"github.com/bekbek22/auth_service/internal/middleware"
+	"github.com/bekbek22/auth_service/internal/repository"
+	"github.com/bekbek22/auth_service/internal/utils"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const authorizationCodeTTL = 10 * time.Minute
+
+// SecondFactorCheck reports whether a user must complete a second factor
+// after their password.
+type SecondFactorCheck func(ctx context.Context, userID primitive.ObjectID) (bool, error)
+
+// GroupRoleLookup returns the roles a user has through groups.
+type GroupRoleLookup func(ctx context.Context, userID primitive.ObjectID) ([]string, error)
+
+// Server implements the OAuth 2.0 authorization server endpoints over HTTP:
+// authorization code with PKCE, client credentials, refresh tokens, token
+// introspection (RFC 7662) and revocation (RFC 7009). It is also an OpenID
+// Connect provider.
+type Server struct {
+	users         repository.IUserRepository
+	sessions      repository.ISessionRepository
+	codes         repository.IOAuthCodeRepository
+	refreshTokens repository.IRefreshTokenRepository
+	tokens        repository.ITokenRepository
+	revocations   *repository.RevocationCache
+	clients       ClientStore
+	requiresMFA   SecondFactorCheck
+	groupRoles    GroupRoleLookup
+	audit         *audit.Logger
+	signingKey    *utils.SigningKey
+	issuer        *utils.TokenIssuer
+	rateLimiter   *middleware.RateLimiter
+	cfg           *config.Config
+}
+
+func NewServer(
+	users repository.IUserRepository,
+	sessions repository.ISessionRepository,
+	codes repository.IOAuthCodeRepository,
+	refreshTokens repository.IRefreshTokenRepository,
+	tokens repository.ITokenRepository,
+	revocations *repository.RevocationCache,
+	clients ClientStore,
+	requiresMFA SecondFactorCheck,
+	groupRoles GroupRoleLookup,
+	auditLogger *audit.Logger,
+	signingKey *utils.SigningKey,
+	issuer *utils.TokenIssuer,
+	cfg *config.Config,
+) *Server {
+	return &Server{
+		users:         users,
+		sessions:      sessions,
+		codes:         codes,
+		refreshTokens: refreshTokens,
+		tokens:        tokens,
+		revocations:   revocations,
+		clients:       clients,
+		requiresMFA:   requiresMFA,
+		groupRoles:    groupRoles,
+		audit:         auditLogger,
+		signingKey:    signingKey,
+		issuer:        issuer,
+		rateLimiter:   middleware.NewRateLimiter(5, 60),
+		cfg:           cfg,
+	}
+}
+
+// Routes registers the OAuth endpoints on mux.
+func (s *Server) Routes(mux *http.ServeMux) {
+	mux.HandleFunc("GET /oauth/authorize", s.handleAuthorizeForm)
+	mux.HandleFunc("POST /oauth/authorize", s.handleAuthorize)
+	mux.HandleFunc("POST /oauth/token", s.handleToken)
+	mux.HandleFunc("POST /oauth/introspect", s.handleIntrospect)
+	mux.HandleFunc("POST /oauth/revoke", s.handleRevoke)
+
+	mux.HandleFunc("GET /.well-known/openid-configuration", s.handleDiscovery)
+	mux.HandleFunc("GET /.well-known/jwks.json", s.handleJWKS)
+	mux.HandleFunc("GET /userinfo", s.handleUserInfo)
+	mux.HandleFunc("POST /userinfo", s.handleUserInfo)
+}
+
+// oauthError is the error body defined by RFC 6749 section 5.2.
+type oauthError struct {
+	Error       string `json:"error"`
+	Description string `json:"error_description,omitempty"`
+}
+
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("Pragma", "no-cache")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(body)
+}
+
+func writeError(w http.ResponseWriter, status int, code, description string) {
+	if status == http.StatusUnauthorized {
+		w.Header().Set("WWW-Authenticate", `Basic realm="oauth"`)
+	}
+	writeJSON(w, status, oauthError{Error: code, Description: description})
+}
+
+// authenticateClient reads client credentials from HTTP Basic auth or the form
+// body. Public clients may identify themselves with client_id only when
+// allowPublic is set.
+func (s *Server) authenticateClient(r *http.Request, allowPublic bool) (*Client, bool) {
+	clientID, secret, hasBasic := r.BasicAuth()
+	if !hasBasic {
+		clientID = r.PostFormValue("client_id")
+		secret = r.PostFormValue("client_secret")
+	}
+	if clientID == "" {
+		return nil, false
+	}
+
+	client, err := s.clients.FindClient(r.Context(), clientID)
+	if err != nil {
+		return nil, false
+	}
+	if client.IsPublic() {
+		return client, allowPublic && secret == ""
+	}
+	return client, client.CheckSecret(secret)
+}
+
+// resolveScope checks the requested scopes against what the client may ask
+// for. An empty request means every allowed scope. Tokens are never issued
+// without a scope, since an unscoped token could call every RPC.
+func resolveScope(client *Client, requested string) (string, bool) {
+	scopes := strings.Fields(requested)
+	if len(scopes) == 0 {
+		scopes = client.Scopes
+	}
+	if len(scopes) == 0 {
+		return "", false
+	}
+	for _, sc := range scopes {
+		if !slices.Contains(client.Scopes, sc) {
+			return "", false
+		}
+	}
+	return strings.Join(scopes, " "), true
+}
+
+func withClientInfo(r *http.Request) context.Context {
+	return middleware.WithHTTPClientInfo(r)
+}
@@ -0,0 +1,244 @@
+package oauth
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/bekbek22/auth_service/config"
+	"github.com/bekbek22/auth_service/internal/audit"
+	"github.com/bekbek22/auth_service/internal/model"
+	"github.com/bekbek22/auth_service/internal/repository"
+	"github.com/bekbek22/auth_service/internal/utils"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// The stores below keep what the tests need in memory. Methods the tests
+// don't need panic through the embedded nil interface.
+
+type staticClients map[string]*Client
+
+func (c staticClients) FindClient(ctx context.Context, clientID string) (*Client, error) {
+	if client, ok := c[clientID]; ok {
+		return client, nil
+	}
+	return nil, ErrClientNotFound
+}
+
+type memoryUsers struct {
+	repository.IUserRepository
+	users map[primitive.ObjectID]*model.User
+}
+
+func (m *memoryUsers) FindByID(ctx context.Context, id primitive.ObjectID) (*model.User, error) {
+	if u, ok := m.users[id]; ok {
+		return u, nil
+	}
+	return nil, mongo.ErrNoDocuments
+}
+
+type memorySessions struct {
+	repository.ISessionRepository
+
+	mu       sync.Mutex
+	sessions map[primitive.ObjectID]*model.Session
+}
+
+func (m *memorySessions) FindActiveByID(ctx context.Context, id primitive.ObjectID) (*model.Session, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if s, ok := m.sessions[id]; ok && s.RevokedAt == 0 {
+		return s, nil
+	}
+	return nil, mongo.ErrNoDocuments
+}
+
+func (m *memorySessions) Revoke(ctx context.Context, id, userID primitive.ObjectID) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if s, ok := m.sessions[id]; ok && s.UserID == userID {
+		s.RevokedAt = time.Now().Unix()
+	}
+	return nil
+}
+
+func (m *memorySessions) FindRevokedSince(ctx context.Context, since int64, limit int64) ([]model.Session, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var revoked []model.Session
+	for _, s := range m.sessions {
+		if s.RevokedAt != 0 {
+			revoked = append(revoked, *s)
+		}
+	}
+	return revoked, nil
+}
+
+type memoryRefreshTokens struct {
+	repository.IRefreshTokenRepository
+
+	mu     sync.Mutex
+	tokens []*model.RefreshToken
+}
+
+func (m *memoryRefreshTokens) Save(ctx context.Context, token *model.RefreshToken) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	token.ID = primitive.NewObjectID()
+	token.CreatedAt = time.Now().Unix()
+	m.tokens = append(m.tokens, token)
+	return nil
+}
+
+func (m *memoryRefreshTokens) FindByHash(ctx context.Context, tokenHash string) (*model.RefreshToken, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, t := range m.tokens {
+		if t.TokenHash == tokenHash {
+			copied := *t
+			return &copied, nil
+		}
+	}
+	return nil, mongo.ErrNoDocuments
+}
+
+func (m *memoryRefreshTokens) MarkRotated(ctx context.Context, id primitive.ObjectID) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, t := range m.tokens {
+		if t.ID == id && t.RotatedAt == 0 && t.RevokedAt == 0 {
+			t.RotatedAt = time.Now().Unix()
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (m *memoryRefreshTokens) RevokeBySession(ctx context.Context, sessionID primitive.ObjectID) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, t := range m.tokens {
+		if t.SessionID == sessionID && t.RevokedAt == 0 {
+			t.RevokedAt = time.Now().Unix()
+		}
+	}
+	return nil
+}
+
+type memoryBlacklist struct {
+	repository.ITokenRepository
+
+	mu     sync.Mutex
+	tokens []model.BlacklistedToken
+}
+
+func (m *memoryBlacklist) BlacklistToken(ctx context.Context, jti string, expiresAt time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.tokens = append(m.tokens, model.BlacklistedToken{JTI: jti, ExpiresAt: expiresAt, BlacklistedAt: time.Now()})
+	return nil
+}
+
+func (m *memoryBlacklist) IsTokenBlacklisted(ctx context.Context, jti string) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, t := range m.tokens {
+		if t.JTI == jti {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (m *memoryBlacklist) FindBlacklistedSince(ctx context.Context, since time.Time, limit int64) ([]model.BlacklistedToken, error) {
+	return nil, nil
+}
+
+// testServer is a Server over in-memory stores with one user, signed in to
+// the public client "app" in session.
+type testServer struct {
+	*Server
+	user          *model.User
+	session       *model.Session
+	sessions      *memorySessions
+	refreshTokens *memoryRefreshTokens
+	blacklist     *memoryBlacklist
+}
+
+func newTestServer(t *testing.T) *testServer {
+	t.Helper()
+	key, err := utils.LoadSigningKey("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	user := &model.User{ID: primitive.NewObjectID(), Email: "bob@example.com", Role: "user"}
+	session := &model.Session{ID: primitive.NewObjectID(), UserID: user.ID, ExpiresAt: time.Now().Add(time.Hour).Unix()}
+	sessions := &memorySessions{sessions: map[primitive.ObjectID]*model.Session{session.ID: session}}
+	refreshTokens := &memoryRefreshTokens{}
+	blacklist := &memoryBlacklist{}
+	clients := staticClients{
+		"app":   {ID: "app", GrantTypes: []string{GrantAuthorizationCode, GrantRefreshToken}, Scopes: []string{"profile:read", "sessions:read"}},
+		"other": {ID: "other", GrantTypes: []string{GrantAuthorizationCode, GrantRefreshToken}, Scopes: []string{"profile:read"}},
+	}
+	cfg := &config.Config{AccessTokenTTL: 15 * time.Minute, RefreshTokenTTL: time.Hour}
+
+	s := NewServer(
+		&memoryUsers{users: map[primitive.ObjectID]*model.User{user.ID: user}},
+		sessions,
+		nil,
+		refreshTokens,
+		blacklist,
+		repository.NewRevocationCache(blacklist, sessions, 100),
+		clients,
+		func(ctx context.Context, userID primitive.ObjectID) (bool, error) { return false, nil },
+		func(ctx context.Context, userID primitive.ObjectID) ([]string, error) { return nil, nil },
+		audit.NewLogger(),
+		key,
+		utils.NewTokenIssuer(key, "https://auth.example.com", "auth-service"),
+		cfg,
+	)
+	return &testServer{Server: s, user: user, session: session, sessions: sessions, refreshTokens: refreshTokens, blacklist: blacklist}
+}
+
+// saveRefreshToken stores a refresh token for the test user's session and
+// returns its raw value.
+func (s *testServer) saveRefreshToken(t *testing.T, clientID, scope string) string {
+	t.Helper()
+	raw, err := utils.GenerateOpaqueToken(32)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = s.refreshTokens.Save(context.Background(), &model.RefreshToken{
+		TokenHash: utils.HashToken(raw),
+		ClientID:  clientID,
+		UserID:    s.user.ID,
+		SessionID: s.session.ID,
+		Scope:     scope,
+		ExpiresAt: s.session.ExpiresAt,
+	})
+	return raw
+}
+
+func postForm(handler http.HandlerFunc, form url.Values) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(form.Encode()))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	w := httptest.NewRecorder()
+	handler(w, r)
+	return w
+}
+
+func decodeJSON[T any](t *testing.T, w *httptest.ResponseRecorder) T {
+	t.Helper()
+	var v T
+	if err := json.NewDecoder(w.Body).Decode(&v); err != nil {
+		t.Fatal(err)
+	}
+	return v
+}
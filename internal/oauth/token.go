@@ -0,0 +1,291 @@
+package oauth
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/bekbek22/auth_service/internal/audit"
+	"github.com/bekbek22/auth_service/internal/middleware"
+	"github.com/bekbek22/auth_service/internal/model"
+	"github.com/bekbek22/auth_service/internal/utils"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type tokenResponse struct {
+	AccessToken  string `json:"access_token"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int64  `json:"expires_in"`
+	RefreshToken string `json:"refresh_token,omitempty"`
+	IDToken      string `json:"id_token,omitempty"`
+	Scope        string `json:"scope,omitempty"`
+}
+
+func (s *Server) handleToken(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_request", "invalid form")
+		return
+	}
+
+	grant := r.PostFormValue("grant_type")
+	switch grant {
+	case GrantAuthorizationCode, GrantClientCredentials, GrantRefreshToken:
+	default:
+		writeError(w, http.StatusBadRequest, "unsupported_grant_type", "")
+		return
+	}
+
+	// Only confidential clients can use client_credentials
+	client, ok := s.authenticateClient(r, grant != GrantClientCredentials)
+	if !ok {
+		writeError(w, http.StatusUnauthorized, "invalid_client", "client authentication failed")
+		return
+	}
+	if !client.AllowsGrant(grant) {
+		writeError(w, http.StatusBadRequest, "unauthorized_client", "grant type not allowed for this client")
+		return
+	}
+
+	ctx := middleware.WithHTTPClientInfo(r)
+	switch grant {
+	case GrantAuthorizationCode:
+		s.exchangeAuthorizationCode(ctx, w, r, client)
+	case GrantClientCredentials:
+		s.issueClientCredentials(ctx, w, r, client)
+	case GrantRefreshToken:
+		s.exchangeRefreshToken(ctx, w, r, client)
+	}
+}
+
+func (s *Server) exchangeAuthorizationCode(ctx context.Context, w http.ResponseWriter, r *http.Request, client *Client) {
+	code, err := s.codes.Consume(ctx, utils.HashToken(r.PostFormValue("code")))
+	if err != nil || code.ClientID != client.ID || code.RedirectURI != r.PostFormValue("redirect_uri") {
+		writeError(w, http.StatusBadRequest, "invalid_grant", "invalid or expired authorization code")
+		return
+	}
+	if !verifyPKCE(code.CodeChallenge, r.PostFormValue("code_verifier")) {
+		writeError(w, http.StatusBadRequest, "invalid_grant", "code_verifier does not match")
+		return
+	}
+
+	user, err := s.users.FindByID(ctx, code.UserID)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_grant", "user no longer exists")
+		return
+	}
+
+	session := &model.Session{
+		UserID:     user.ID,
+		DeviceName: "OAuth client " + client.ID,
+		ExpiresAt:  time.Now().Add(s.refreshTokenTTL(client)).Unix(),
+	}
+	session.IP, session.UserAgent = middleware.ClientInfoFromContext(ctx)
+	if err := s.sessions.Create(ctx, session); err != nil {
+		writeError(w, http.StatusInternalServerError, "server_error", "failed to create session")
+		return
+	}
+
+	s.issueUserTokens(ctx, w, client, user, userGrant{
+		sessionID:  session.ID,
+		scope:      code.Scope,
+		nonce:      code.Nonce,
+		authTime:   code.AuthTime,
+		refreshExp: session.ExpiresAt,
+	})
+}
+
+func (s *Server) issueClientCredentials(ctx context.Context, w http.ResponseWriter, r *http.Request, client *Client) {
+	scope, ok := resolveScope(client, r.PostFormValue("scope"))
+	if !ok {
+		writeError(w, http.StatusBadRequest, "invalid_scope", "requested scope is not allowed for this client")
+		return
+	}
+
+	claims := &utils.AuthClaims{Scope: scope, ClientID: client.ID, TenantID: client.TenantID}
+	claims.Subject = client.ID
+	ttl := s.cfg.AccessTokenTTLFor(nil, client.AccessTokenTTL)
+	accessToken, err := s.issuer.Issue(claims, ttl, client.ID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "server_error", "failed to generate token")
+		return
+	}
+
+	s.audit.Record(ctx, model.AuditEvent{
+		Action:  audit.ActionTokenIssue,
+		Outcome: audit.OutcomeSuccess,
+		Details: map[string]string{"client_id": client.ID, "grant_type": GrantClientCredentials, "scope": scope},
+	})
+	writeJSON(w, http.StatusOK, tokenResponse{
+		AccessToken: accessToken,
+		TokenType:   "Bearer",
+		ExpiresIn:   int64(ttl.Seconds()),
+		Scope:       scope,
+	})
+}
+
+// exchangeRefreshToken rotates a refresh token. Presenting a token that was
+// already rotated means it leaked, so the whole session is revoked.
+func (s *Server) exchangeRefreshToken(ctx context.Context, w http.ResponseWriter, r *http.Request, client *Client) {
+	old, err := s.refreshTokens.FindByHash(ctx, utils.HashToken(r.PostFormValue("refresh_token")))
+	if err != nil || old.ClientID != client.ID || old.ExpiresAt <= time.Now().Unix() {
+		writeError(w, http.StatusBadRequest, "invalid_grant", "invalid or expired refresh token")
+		return
+	}
+	if old.RotatedAt != 0 || old.RevokedAt != 0 {
+		s.revokeSession(ctx, old.UserID, old.SessionID)
+		writeError(w, http.StatusBadRequest, "invalid_grant", "refresh token already used")
+		return
+	}
+
+	scope := old.Scope
+	if requested := r.PostFormValue("scope"); requested != "" {
+		granted := strings.Fields(old.Scope)
+		for _, sc := range strings.Fields(requested) {
+			if !slices.Contains(granted, sc) {
+				writeError(w, http.StatusBadRequest, "invalid_scope", "scope exceeds the original grant")
+				return
+			}
+		}
+		scope = strings.Join(strings.Fields(requested), " ")
+	}
+
+	if _, err := s.sessions.FindActiveByID(ctx, old.SessionID); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_grant", "session expired or revoked")
+		return
+	}
+	user, err := s.users.FindByID(ctx, old.UserID)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_grant", "user no longer exists")
+		return
+	}
+
+	rotated, err := s.refreshTokens.MarkRotated(ctx, old.ID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "server_error", "failed to rotate refresh token")
+		return
+	}
+	if !rotated {
+		s.revokeSession(ctx, old.UserID, old.SessionID)
+		writeError(w, http.StatusBadRequest, "invalid_grant", "refresh token already used")
+		return
+	}
+
+	s.issueUserTokens(ctx, w, client, user, userGrant{
+		sessionID:  old.SessionID,
+		scope:      scope,
+		authTime:   old.AuthTime,
+		refreshExp: old.ExpiresAt,
+	})
+}
+
+// userGrant describes what a user authorized a client to receive.
+type userGrant struct {
+	sessionID  primitive.ObjectID
+	scope      string
+	nonce      string
+	authTime   int64
+	refreshExp int64
+}
+
+// issueUserTokens writes an access token for user, an ID token when the
+// openid scope was granted and, if the client may use it, a refresh token.
+func (s *Server) issueUserTokens(ctx context.Context, w http.ResponseWriter, client *Client, user *model.User, grant userGrant) {
+	sessionID, scope := grant.sessionID, grant.scope
+	// Grants stored before scopes were required may have none
+	if scope == "" {
+		writeError(w, http.StatusBadRequest, "invalid_scope", "the grant has no scope")
+		return
+	}
+	roles, err := s.groupRoles(ctx, user.ID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "server_error", "failed to resolve roles")
+		return
+	}
+	claims := &utils.AuthClaims{Role: user.Role, Roles: roles, Scope: scope, SessionID: sessionID.Hex(), ClientID: client.ID, TenantID: user.TenantID}
+	claims.Subject = user.ID.Hex()
+	ttl := s.cfg.AccessTokenTTLFor(claims.AllRoles(), client.AccessTokenTTL)
+	accessToken, err := s.issuer.Issue(claims, ttl, client.ID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "server_error", "failed to generate token")
+		return
+	}
+
+	resp := tokenResponse{
+		AccessToken: accessToken,
+		TokenType:   "Bearer",
+		ExpiresIn:   int64(ttl.Seconds()),
+		Scope:       scope,
+	}
+
+	if client.AllowsGrant(GrantRefreshToken) {
+		refreshToken, err := utils.GenerateOpaqueToken(32)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, "server_error", "failed to generate refresh token")
+			return
+		}
+		err = s.refreshTokens.Save(ctx, &model.RefreshToken{
+			TokenHash: utils.HashToken(refreshToken),
+			ClientID:  client.ID,
+			UserID:    user.ID,
+			SessionID: sessionID,
+			Scope:     scope,
+			AuthTime:  grant.authTime,
+			ExpiresAt: grant.refreshExp,
+		})
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, "server_error", "failed to save refresh token")
+			return
+		}
+		resp.RefreshToken = refreshToken
+	}
+
+	if hasScope(scope, ScopeOpenID) {
+		idToken, err := s.issueIDToken(client, user, grant, ttl)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, "server_error", "failed to generate ID token")
+			return
+		}
+		resp.IDToken = idToken
+	}
+
+	s.audit.Record(ctx, model.AuditEvent{
+		ActorID:   user.ID.Hex(),
+		SubjectID: user.ID.Hex(),
+		Action:    audit.ActionTokenIssue,
+		Outcome:   audit.OutcomeSuccess,
+		Details:   map[string]string{"client_id": client.ID, "session_id": sessionID.Hex(), "scope": scope},
+	})
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// revokeSession ends a session and every refresh token issued in it.
+func (s *Server) revokeSession(ctx context.Context, userID, sessionID primitive.ObjectID) {
+	_ = s.refreshTokens.RevokeBySession(ctx, sessionID)
+	if err := s.sessions.Revoke(ctx, sessionID, userID); err == nil {
+		_ = s.revocations.Refresh(ctx)
+	}
+}
+
+// verifyPKCE checks an S256 code challenge (RFC 7636).
+func verifyPKCE(challenge, verifier string) bool {
+	if len(verifier) < 43 || len(verifier) > 128 {
+		return false
+	}
+	sum := sha256.Sum256([]byte(verifier))
+	expected := base64.RawURLEncoding.EncodeToString(sum[:])
+	return subtle.ConstantTimeCompare([]byte(expected), []byte(challenge)) == 1
+}
+
+// refreshTokenTTL is how long a client's sessions, and so its refresh tokens,
+// last.
+func (s *Server) refreshTokenTTL(client *Client) time.Duration {
+	if client.RefreshTokenTTL > 0 {
+		return client.RefreshTokenTTL
+	}
+	return s.cfg.RefreshTokenTTL
+}
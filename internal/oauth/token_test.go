@@ -0,0 +1,151 @@
+package oauth
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestVerifyPKCE(t *testing.T) {
+	verifier := strings.Repeat("a", 43)
+	sum := sha256.Sum256([]byte(verifier))
+	challenge := base64.RawURLEncoding.EncodeToString(sum[:])
+
+	tests := []struct {
+		name      string
+		challenge string
+		verifier  string
+		want      bool
+	}{
+		{name: "matching verifier", challenge: challenge, verifier: verifier, want: true},
+		{name: "wrong verifier", challenge: challenge, verifier: strings.Repeat("b", 43), want: false},
+		{name: "plain challenge", challenge: verifier, verifier: verifier, want: false},
+		{name: "verifier too short", challenge: challenge, verifier: verifier[:42], want: false},
+		{name: "verifier too long", challenge: challenge, verifier: strings.Repeat("a", 129), want: false},
+		{name: "no challenge", challenge: "", verifier: verifier, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := verifyPKCE(tt.challenge, tt.verifier); got != tt.want {
+				t.Fatalf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestResolveScope(t *testing.T) {
+	client := &Client{Scopes: []string{"openid", "profile:read"}}
+	tests := []struct {
+		name      string
+		client    *Client
+		requested string
+		want      string
+		wantOK    bool
+	}{
+		{name: "all allowed scopes by default", client: client, requested: "", want: "openid profile:read", wantOK: true},
+		{name: "subset", client: client, requested: "profile:read", want: "profile:read", wantOK: true},
+		{name: "extra whitespace", client: client, requested: "  openid   profile:read ", want: "openid profile:read", wantOK: true},
+		{name: "disallowed scope", client: client, requested: "openid users:read", wantOK: false},
+		{name: "client without scopes", client: &Client{}, requested: "", wantOK: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := resolveScope(tt.client, tt.requested)
+			if ok != tt.wantOK || got != tt.want {
+				t.Fatalf("got (%q, %v), want (%q, %v)", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestRefreshTokenGrant(t *testing.T) {
+	tests := []struct {
+		name      string
+		clientID  string
+		scope     string
+		wantCode  int
+		wantError string
+		wantScope string
+	}{
+		{name: "rotates", clientID: "app", wantCode: http.StatusOK, wantScope: "profile:read sessions:read"},
+		{name: "narrows scope", clientID: "app", scope: "profile:read", wantCode: http.StatusOK, wantScope: "profile:read"},
+		{name: "widens scope", clientID: "app", scope: "profile:read users:read", wantCode: http.StatusBadRequest, wantError: "invalid_scope"},
+		{name: "another client's token", clientID: "other", wantCode: http.StatusBadRequest, wantError: "invalid_grant"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestServer(t)
+			raw := s.saveRefreshToken(t, "app", "profile:read sessions:read")
+
+			w := postForm(s.handleToken, url.Values{
+				"grant_type":    {GrantRefreshToken},
+				"client_id":     {tt.clientID},
+				"refresh_token": {raw},
+				"scope":         {tt.scope},
+			})
+			if w.Code != tt.wantCode {
+				t.Fatalf("got status %d, want %d: %s", w.Code, tt.wantCode, w.Body)
+			}
+			if tt.wantError != "" {
+				if got := decodeJSON[oauthError](t, w).Error; got != tt.wantError {
+					t.Fatalf("got error %q, want %q", got, tt.wantError)
+				}
+				return
+			}
+
+			resp := decodeJSON[tokenResponse](t, w)
+			if resp.Scope != tt.wantScope || resp.RefreshToken == "" || resp.RefreshToken == raw {
+				t.Fatalf("got scope %q and refresh token %q, want scope %q and a new refresh token", resp.Scope, resp.RefreshToken, tt.wantScope)
+			}
+			claims, err := s.issuer.Validate(resp.AccessToken)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if claims.ClientID != "app" || claims.Scope != tt.wantScope || claims.SessionID != s.session.ID.Hex() {
+				t.Fatalf("unexpected access token claims %+v", claims)
+			}
+		})
+	}
+}
+
+func TestRefreshTokenReuseRevokesSession(t *testing.T) {
+	s := newTestServer(t)
+	raw := s.saveRefreshToken(t, "app", "profile:read")
+	refresh := func(token string) *tokenResponse {
+		w := postForm(s.handleToken, url.Values{
+			"grant_type":    {GrantRefreshToken},
+			"client_id":     {"app"},
+			"refresh_token": {token},
+		})
+		if w.Code != http.StatusOK {
+			return nil
+		}
+		resp := decodeJSON[tokenResponse](t, w)
+		return &resp
+	}
+
+	rotated := refresh(raw)
+	if rotated == nil {
+		t.Fatal("first refresh failed")
+	}
+	// Replaying the rotated token means it leaked
+	if refresh(raw) != nil {
+		t.Fatal("rotated refresh token was accepted again")
+	}
+	if s.session.RevokedAt == 0 {
+		t.Fatal("session was not revoked on reuse")
+	}
+	if refresh(rotated.RefreshToken) != nil {
+		t.Fatal("refresh token issued before the reuse still works")
+	}
+	claims, err := s.issuer.Validate(rotated.AccessToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.accessTokenActive(t.Context(), claims) {
+		t.Fatal("access token of the revoked session is still active")
+	}
+}
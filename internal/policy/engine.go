@@ -0,0 +1,230 @@
+package policy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bekbek22/auth_service/internal/model"
+)
+
+const (
+	EffectAllow = "allow"
+	EffectDeny  = "deny"
+)
+
+// Store loads every policy.
+type Store interface {
+	ListAll(ctx context.Context) ([]model.Policy, error)
+}
+
+// Subject is who is asking: a user with their effective roles.
+type Subject struct {
+	ID       string
+	TenantID string
+	Roles    []string
+}
+
+// Resource is what is being acted on. Only Type is required; the other
+// attributes are needed by the conditions that check them.
+type Resource struct {
+	Type     string
+	ID       string
+	TenantID string
+	OwnerID  string
+}
+
+// Decision is the engine's answer. PolicyID names the policy that decided,
+// empty when nothing matched.
+type Decision struct {
+	Allowed  bool
+	PolicyID string
+	Reason   string
+}
+
+type compiledPolicy struct {
+	model.Policy
+	window *window
+}
+
+type window struct {
+	start, end int // minutes after midnight
+	loc        *time.Location
+}
+
+func (w *window) contains(t time.Time) bool {
+	t = t.In(w.loc)
+	m := t.Hour()*60 + t.Minute()
+	if w.start <= w.end {
+		return m >= w.start && m < w.end
+	}
+	return m >= w.start || m < w.end
+}
+
+// Engine evaluates policies kept in memory. It is reloaded from the store
+// periodically, which picks up changes made through other instances.
+type Engine struct {
+	store Store
+
+	mu       sync.RWMutex
+	policies []compiledPolicy
+}
+
+func NewEngine(store Store) *Engine {
+	return &Engine{store: store}
+}
+
+// Validate checks a policy can be compiled.
+func Validate(p *model.Policy) error {
+	_, err := compile(p)
+	return err
+}
+
+func compile(p *model.Policy) (compiledPolicy, error) {
+	c := compiledPolicy{Policy: *p}
+	if strings.TrimSpace(p.Name) == "" {
+		return c, errors.New("name is required")
+	}
+	if p.Effect != EffectAllow && p.Effect != EffectDeny {
+		return c, errors.New("effect must be allow or deny")
+	}
+	if len(p.Actions) == 0 {
+		return c, errors.New("at least one action is required")
+	}
+	if len(p.Resources) == 0 {
+		return c, errors.New("at least one resource type is required")
+	}
+	if tw := p.Conditions.TimeOfDay; tw != nil {
+		w := &window{loc: time.UTC}
+		var err error
+		if w.start, err = parseClock(tw.Start); err != nil {
+			return c, err
+		}
+		if w.end, err = parseClock(tw.End); err != nil {
+			return c, err
+		}
+		if tw.Timezone != "" {
+			if w.loc, err = time.LoadLocation(tw.Timezone); err != nil {
+				return c, fmt.Errorf("unknown timezone %q", tw.Timezone)
+			}
+		}
+		c.window = w
+	}
+	return c, nil
+}
+
+func parseClock(s string) (int, error) {
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid time %q, want HH:MM", s)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+// Reload replaces the policies in memory with the stored ones. Policies that
+// don't compile are skipped and logged.
+func (e *Engine) Reload(ctx context.Context) error {
+	stored, err := e.store.ListAll(ctx)
+	if err != nil {
+		return err
+	}
+
+	policies := make([]compiledPolicy, 0, len(stored))
+	for i := range stored {
+		c, err := compile(&stored[i])
+		if err != nil {
+			log.Printf("skipping policy %s: %v", stored[i].ID.Hex(), err)
+			continue
+		}
+		policies = append(policies, c)
+	}
+
+	e.mu.Lock()
+	e.policies = policies
+	e.mu.Unlock()
+	return nil
+}
+
+// Run reloads the policies every interval until ctx is done.
+func (e *Engine) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := e.Reload(ctx); err != nil {
+			log.Printf("policy reload failed: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Decide answers whether subject may perform action on resource at now. Deny
+// policies win over allow policies; without a matching allow the answer is no.
+func (e *Engine) Decide(subject Subject, action string, resource Resource, now time.Time) Decision {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	var allow *compiledPolicy
+	for i := range e.policies {
+		p := &e.policies[i]
+		if !p.matches(subject, action, resource, now) {
+			continue
+		}
+		if p.Effect == EffectDeny {
+			return Decision{PolicyID: p.ID.Hex(), Reason: "denied by policy " + p.Name}
+		}
+		if allow == nil {
+			allow = p
+		}
+	}
+	if allow != nil {
+		return Decision{Allowed: true, PolicyID: allow.ID.Hex(), Reason: "allowed by policy " + allow.Name}
+	}
+	return Decision{Reason: "no policy allows the action"}
+}
+
+func (p *compiledPolicy) matches(subject Subject, action string, resource Resource, now time.Time) bool {
+	if len(p.Roles) > 0 && !slices.ContainsFunc(subject.Roles, func(r string) bool { return slices.Contains(p.Roles, r) }) {
+		return false
+	}
+	if !slices.ContainsFunc(p.Actions, func(pattern string) bool { return matchAction(pattern, action) }) {
+		return false
+	}
+	if !slices.Contains(p.Resources, "*") && !slices.Contains(p.Resources, resource.Type) {
+		return false
+	}
+
+	cond := p.Conditions
+	if cond.SameTenant && resource.TenantID != subject.TenantID {
+		return false
+	}
+	if cond.Owner && (resource.OwnerID == "" || resource.OwnerID != subject.ID) {
+		return false
+	}
+	if p.window != nil && !p.window.contains(now) {
+		return false
+	}
+	return true
+}
+
+// matchAction matches an action against "*", an exact action or a
+// "documents:*" style prefix.
+func matchAction(pattern, action string) bool {
+	if pattern == "*" || pattern == action {
+		return true
+	}
+	if prefix, ok := strings.CutSuffix(pattern, "*"); ok {
+		return strings.HasPrefix(action, prefix)
+	}
+	return false
+}
@@ -0,0 +1,164 @@
+package policy
+
+import (
+	"context"
+	"testing"
+	"time"
+	_ "time/tzdata" // for the timezone cases on hosts without zoneinfo
+
+	"github.com/bekbek22/auth_service/internal/model"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type staticStore []model.Policy
+
+func (s staticStore) ListAll(ctx context.Context) ([]model.Policy, error) {
+	return s, nil
+}
+
+func newTestEngine(t *testing.T, policies ...model.Policy) *Engine {
+	t.Helper()
+	for i := range policies {
+		policies[i].ID = primitive.NewObjectID()
+	}
+	e := NewEngine(staticStore(policies))
+	if err := e.Reload(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	return e
+}
+
+func allowDocuments(cond model.PolicyConditions) model.Policy {
+	return model.Policy{
+		Name:       "editors",
+		Effect:     EffectAllow,
+		Roles:      []string{"editor"},
+		Actions:    []string{"documents:*"},
+		Resources:  []string{"document"},
+		Conditions: cond,
+	}
+}
+
+func TestDecideConditions(t *testing.T) {
+	editor := Subject{ID: "u1", TenantID: "t1", Roles: []string{"user", "editor"}}
+	noon := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+	night := time.Date(2026, 10, 19, 23, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		cond     model.PolicyConditions
+		subject  Subject
+		resource Resource
+		now      time.Time
+		want     bool
+	}{
+		{name: "no conditions", subject: editor, resource: Resource{Type: "document"}, now: noon, want: true},
+		{name: "missing role", subject: Subject{ID: "u1", Roles: []string{"user"}}, resource: Resource{Type: "document"}, now: noon, want: false},
+		{name: "other resource type", subject: editor, resource: Resource{Type: "invoice"}, now: noon, want: false},
+
+		{name: "same tenant", cond: model.PolicyConditions{SameTenant: true}, subject: editor, resource: Resource{Type: "document", TenantID: "t1"}, now: noon, want: true},
+		{name: "other tenant", cond: model.PolicyConditions{SameTenant: true}, subject: editor, resource: Resource{Type: "document", TenantID: "t2"}, now: noon, want: false},
+		{name: "owner", cond: model.PolicyConditions{Owner: true}, subject: editor, resource: Resource{Type: "document", OwnerID: "u1"}, now: noon, want: true},
+		{name: "not owner", cond: model.PolicyConditions{Owner: true}, subject: editor, resource: Resource{Type: "document", OwnerID: "u2"}, now: noon, want: false},
+		{name: "owner unknown", cond: model.PolicyConditions{Owner: true}, subject: editor, resource: Resource{Type: "document"}, now: noon, want: false},
+
+		{name: "inside window", cond: model.PolicyConditions{TimeOfDay: &model.TimeWindow{Start: "08:00", End: "18:00"}}, subject: editor, resource: Resource{Type: "document"}, now: noon, want: true},
+		{name: "outside window", cond: model.PolicyConditions{TimeOfDay: &model.TimeWindow{Start: "08:00", End: "18:00"}}, subject: editor, resource: Resource{Type: "document"}, now: night, want: false},
+		{name: "end is exclusive", cond: model.PolicyConditions{TimeOfDay: &model.TimeWindow{Start: "08:00", End: "12:00"}}, subject: editor, resource: Resource{Type: "document"}, now: noon, want: false},
+		{name: "window past midnight", cond: model.PolicyConditions{TimeOfDay: &model.TimeWindow{Start: "22:00", End: "06:00"}}, subject: editor, resource: Resource{Type: "document"}, now: night, want: true},
+		{name: "outside window past midnight", cond: model.PolicyConditions{TimeOfDay: &model.TimeWindow{Start: "22:00", End: "06:00"}}, subject: editor, resource: Resource{Type: "document"}, now: noon, want: false},
+		// 12:00 UTC is 21:00 in Tokyo
+		{name: "window in timezone", cond: model.PolicyConditions{TimeOfDay: &model.TimeWindow{Start: "08:00", End: "18:00", Timezone: "Asia/Tokyo"}}, subject: editor, resource: Resource{Type: "document"}, now: noon, want: false},
+
+		{
+			name:     "all conditions hold",
+			cond:     model.PolicyConditions{SameTenant: true, Owner: true, TimeOfDay: &model.TimeWindow{Start: "08:00", End: "18:00"}},
+			subject:  editor,
+			resource: Resource{Type: "document", TenantID: "t1", OwnerID: "u1"},
+			now:      noon,
+			want:     true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := newTestEngine(t, allowDocuments(tt.cond))
+			if got := e.Decide(tt.subject, "documents:update", tt.resource, tt.now); got.Allowed != tt.want {
+				t.Fatalf("got %+v, want allowed %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDecideDenyWins(t *testing.T) {
+	deny := model.Policy{Name: "freeze", Effect: EffectDeny, Actions: []string{"documents:delete"}, Resources: []string{"*"}}
+	e := newTestEngine(t, allowDocuments(model.PolicyConditions{}), deny)
+	editor := Subject{ID: "u1", Roles: []string{"editor"}}
+	now := time.Now()
+
+	if got := e.Decide(editor, "documents:delete", Resource{Type: "document"}, now); got.Allowed || got.Reason != "denied by policy freeze" {
+		t.Fatalf("got %+v, want the deny policy to decide", got)
+	}
+	if got := e.Decide(editor, "documents:read", Resource{Type: "document"}, now); !got.Allowed {
+		t.Fatalf("got %+v, want the allow policy to decide", got)
+	}
+	if got := e.Decide(editor, "invoices:read", Resource{Type: "document"}, now); got.Allowed || got.PolicyID != "" {
+		t.Fatalf("got %+v, want no policy to match", got)
+	}
+}
+
+func TestMatchAction(t *testing.T) {
+	tests := []struct {
+		pattern, action string
+		want            bool
+	}{
+		{"*", "documents:read", true},
+		{"documents:read", "documents:read", true},
+		{"documents:read", "documents:update", false},
+		{"documents:*", "documents:update", true},
+		{"documents:*", "invoices:read", false},
+	}
+	for _, tt := range tests {
+		if got := matchAction(tt.pattern, tt.action); got != tt.want {
+			t.Errorf("matchAction(%q, %q) = %v, want %v", tt.pattern, tt.action, got, tt.want)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	valid := allowDocuments(model.PolicyConditions{TimeOfDay: &model.TimeWindow{Start: "08:00", End: "18:00", Timezone: "Europe/Berlin"}})
+	tests := []struct {
+		name    string
+		mutate  func(p *model.Policy)
+		wantErr bool
+	}{
+		{name: "valid", mutate: func(p *model.Policy) {}},
+		{name: "no name", mutate: func(p *model.Policy) { p.Name = " " }, wantErr: true},
+		{name: "unknown effect", mutate: func(p *model.Policy) { p.Effect = "maybe" }, wantErr: true},
+		{name: "no actions", mutate: func(p *model.Policy) { p.Actions = nil }, wantErr: true},
+		{name: "no resources", mutate: func(p *model.Policy) { p.Resources = nil }, wantErr: true},
+		{name: "bad start", mutate: func(p *model.Policy) { p.Conditions.TimeOfDay.Start = "8am" }, wantErr: true},
+		{name: "bad end", mutate: func(p *model.Policy) { p.Conditions.TimeOfDay.End = "25:00" }, wantErr: true},
+		{name: "unknown timezone", mutate: func(p *model.Policy) { p.Conditions.TimeOfDay.Timezone = "Mars/Olympus" }, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := valid
+			window := *valid.Conditions.TimeOfDay
+			p.Conditions.TimeOfDay = &window
+			tt.mutate(&p)
+			if err := Validate(&p); (err != nil) != tt.wantErr {
+				t.Fatalf("got error %v, want error %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestReloadSkipsInvalidPolicies(t *testing.T) {
+	broken := allowDocuments(model.PolicyConditions{})
+	broken.Effect = "maybe"
+	e := newTestEngine(t, broken)
+
+	if got := e.Decide(Subject{Roles: []string{"editor"}}, "documents:read", Resource{Type: "document"}, time.Now()); got.Allowed {
+		t.Fatalf("got %+v, want the invalid policy skipped", got)
+	}
+}
@@ -0,0 +1,115 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/bekbek22/auth_service/internal/model"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type IAPIKeyRepository interface {
+	Create(ctx context.Context, key *model.APIKey) error
+	FindActiveByHash(ctx context.Context, keyHash string) (*model.APIKey, error)
+	FindByID(ctx context.Context, id primitive.ObjectID) (*model.APIKey, error)
+	ListByServiceAccount(ctx context.Context, accountID primitive.ObjectID) ([]model.APIKey, error)
+	TouchLastUsed(ctx context.Context, id primitive.ObjectID, at int64) error
+	Revoke(ctx context.Context, id primitive.ObjectID) error
+}
+
+type APIKeyRepository struct {
+	collection *mongo.Collection
+}
+
+func NewAPIKeyRepository(db *mongo.Database) *APIKeyRepository {
+	return &APIKeyRepository{
+		collection: db.Collection("api_keys"),
+	}
+}
+
+// EnsureIndexes makes key lookups, which happen on every API key call, an
+// index hit.
+func (r *APIKeyRepository) EnsureIndexes(ctx context.Context) error {
+	_, err := r.collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "key_hash", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	return err
+}
+
+func (r *APIKeyRepository) Create(ctx context.Context, key *model.APIKey) error {
+	key.ID = primitive.NewObjectID()
+	key.CreatedAt = time.Now().Unix()
+	_, err := r.collection.InsertOne(ctx, key)
+	return err
+}
+
+// FindActiveByHash returns an unrevoked, unexpired key.
+func (r *APIKeyRepository) FindActiveByHash(ctx context.Context, keyHash string) (*model.APIKey, error) {
+	var key model.APIKey
+	err := r.collection.FindOne(ctx, bson.M{
+		"key_hash":   keyHash,
+		"revoked_at": bson.M{"$exists": false},
+		"$or": bson.A{
+			bson.M{"expires_at": bson.M{"$exists": false}},
+			bson.M{"expires_at": bson.M{"$gt": time.Now().Unix()}},
+		},
+	}).Decode(&key)
+	if err != nil {
+		return nil, err
+	}
+	return &key, nil
+}
+
+func (r *APIKeyRepository) FindByID(ctx context.Context, id primitive.ObjectID) (*model.APIKey, error) {
+	var key model.APIKey
+	err := r.collection.FindOne(ctx, bson.M{"_id": id}).Decode(&key)
+	if err != nil {
+		return nil, err
+	}
+	return &key, nil
+}
+
+func (r *APIKeyRepository) ListByServiceAccount(ctx context.Context, accountID primitive.ObjectID) ([]model.APIKey, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}})
+	cursor, err := r.collection.Find(ctx, bson.M{"service_account_id": accountID}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var keys []model.APIKey
+	if err := cursor.All(ctx, &keys); err != nil {
+		return nil, err
+	}
+	return keys, nil
+}
+
+func (r *APIKeyRepository) TouchLastUsed(ctx context.Context, id primitive.ObjectID, at int64) error {
+	_, err := r.collection.UpdateOne(
+		ctx,
+		bson.M{"_id": id},
+		bson.M{"$set": bson.M{"last_used_at": at}},
+	)
+	return err
+}
+
+func (r *APIKeyRepository) Revoke(ctx context.Context, id primitive.ObjectID) error {
+	res, err := r.collection.UpdateOne(
+		ctx,
+		bson.M{"_id": id, "revoked_at": bson.M{"$exists": false}},
+		bson.M{"$set": bson.M{"revoked_at": time.Now().Unix()}},
+	)
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return errors.New("api key not found or already revoked")
+	}
+	return nil
+}
@@ -0,0 +1,158 @@
+package repository
+
+import (
+	"context"
+	"regexp"
+
+	"github.com/bekbek22/auth_service/internal/model"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type AuditFilter struct {
+	ActorID   string
+	SubjectID string
+	Action    string
+	Outcome   string
+	Since     int64
+	Until     int64
+}
+
+type IAuditRepository interface {
+	Write(ctx context.Context, event *model.AuditEvent) error
+	List(ctx context.Context, filter AuditFilter, cursor string, limit int64) ([]model.AuditEvent, string, error)
+	ListByUser(ctx context.Context, userID string) ([]model.AuditEvent, error)
+	AnonymizeByUser(ctx context.Context, userID, email string, before int64) error
+}
+
+// AuditRepository is append-only: the only update it exposes is the removal of
+// personal data once a deleted user's retention window has passed.
+type AuditRepository struct {
+	collection *mongo.Collection
+}
+
+func NewAuditRepository(db *mongo.Database) *AuditRepository {
+	return &AuditRepository{
+		collection: db.Collection("audit_events"),
+	}
+}
+
+func (r *AuditRepository) Write(ctx context.Context, event *model.AuditEvent) error {
+	event.ID = primitive.NewObjectID()
+	_, err := r.collection.InsertOne(ctx, event)
+	return err
+}
+
+// List returns events newest first. The cursor is the ID of the last event of
+// the previous page; the returned cursor is empty when there are no more pages.
+func (r *AuditRepository) List(ctx context.Context, filter AuditFilter, cursor string, limit int64) ([]model.AuditEvent, string, error) {
+	query := bson.M{}
+	if filter.ActorID != "" {
+		query["actor_id"] = filter.ActorID
+	}
+	if filter.SubjectID != "" {
+		query["subject_id"] = filter.SubjectID
+	}
+	if filter.Action != "" {
+		query["action"] = filter.Action
+	}
+	if filter.Outcome != "" {
+		query["outcome"] = filter.Outcome
+	}
+	if filter.Since > 0 || filter.Until > 0 {
+		ts := bson.M{}
+		if filter.Since > 0 {
+			ts["$gte"] = filter.Since
+		}
+		if filter.Until > 0 {
+			ts["$lte"] = filter.Until
+		}
+		query["timestamp"] = ts
+	}
+	if cursor != "" {
+		oid, err := primitive.ObjectIDFromHex(cursor)
+		if err != nil {
+			return nil, "", err
+		}
+		query["_id"] = bson.M{"$lt": oid}
+	}
+
+	// Fetch one extra to know whether another page exists
+	opts := options.Find().SetSort(bson.M{"_id": -1}).SetLimit(limit + 1)
+	cur, err := r.collection.Find(ctx, query, opts)
+	if err != nil {
+		return nil, "", err
+	}
+	defer cur.Close(ctx)
+
+	var events []model.AuditEvent
+	if err := cur.All(ctx, &events); err != nil {
+		return nil, "", err
+	}
+
+	next := ""
+	if int64(len(events)) > limit {
+		events = events[:limit]
+		next = events[len(events)-1].ID.Hex()
+	}
+	return events, next, nil
+}
+
+// byUser matches the events a user made or was the subject of.
+func byUser(userID string) bson.M {
+	return bson.M{"$or": bson.A{
+		bson.M{"actor_id": userID},
+		bson.M{"subject_id": userID},
+	}}
+}
+
+// ListByUser returns every event a user made or was the subject of, oldest
+// first.
+func (r *AuditRepository) ListByUser(ctx context.Context, userID string) ([]model.AuditEvent, error) {
+	opts := options.Find().SetSort(bson.M{"_id": 1})
+	cur, err := r.collection.Find(ctx, byUser(userID), opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+
+	var events []model.AuditEvent
+	if err := cur.All(ctx, &events); err != nil {
+		return nil, err
+	}
+	return events, nil
+}
+
+// emailDetails are the details that can name an email address, e.g. of a
+// failed login, an invitation or an email change.
+var emailDetails = []string{"email", "old_email", "new_email", "restored_email", "reverted_email"}
+
+// AnonymizeByUser keeps a user's events for the audit trail but removes the
+// IP, user agent and details, which may hold their email. The email is also
+// removed from events recorded up to before that don't name the user, such
+// as failed logins or invitations by others.
+func (r *AuditRepository) AnonymizeByUser(ctx context.Context, userID, email string, before int64) error {
+	_, err := r.collection.UpdateMany(ctx, byUser(userID), bson.M{
+		"$unset": bson.M{"ip": "", "user_agent": "", "details": ""},
+	})
+	if err != nil {
+		return err
+	}
+
+	// Failed logins record the email as typed, in any case
+	pattern := primitive.Regex{Pattern: "^" + regexp.QuoteMeta(email) + "$", Options: "i"}
+	for _, key := range emailDetails {
+		field := "details." + key
+		_, err := r.collection.UpdateMany(ctx, bson.M{
+			field:       pattern,
+			"timestamp": bson.M{"$lte": before},
+		}, bson.M{"$unset": bson.M{field: ""}})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
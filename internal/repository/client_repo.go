@@ -0,0 +1,101 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/bekbek22/auth_service/internal/model"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type IClientRepository interface {
+	Create(ctx context.Context, client *model.Client) error
+	FindByClientID(ctx context.Context, clientID string) (*model.Client, error)
+	List(ctx context.Context, page, limit int32) ([]model.Client, int32, error)
+	Update(ctx context.Context, clientID string, updates bson.M) error
+	UpdateSecret(ctx context.Context, clientID, secretHash string) error
+	Delete(ctx context.Context, clientID string) error
+}
+
+type ClientRepository struct {
+	collection *mongo.Collection
+}
+
+func NewClientRepository(db *mongo.Database) *ClientRepository {
+	return &ClientRepository{
+		collection: db.Collection("clients"),
+	}
+}
+
+var errClientNotFound = errors.New("client not found")
+
+func (r *ClientRepository) Create(ctx context.Context, client *model.Client) error {
+	now := time.Now().Unix()
+	client.CreatedAt = now
+	client.UpdatedAt = now
+	_, err := r.collection.InsertOne(ctx, client)
+	return err
+}
+
+func (r *ClientRepository) FindByClientID(ctx context.Context, clientID string) (*model.Client, error) {
+	var client model.Client
+	err := r.collection.FindOne(ctx, bson.M{"client_id": clientID}).Decode(&client)
+	if err != nil {
+		return nil, err
+	}
+	return &client, nil
+}
+
+func (r *ClientRepository) List(ctx context.Context, page, limit int32) ([]model.Client, int32, error) {
+	skip := int64((page - 1) * limit)
+	opts := options.Find().SetSkip(skip).SetLimit(int64(limit)).SetSort(bson.M{"created_at": 1})
+
+	cursor, err := r.collection.Find(ctx, bson.M{}, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer cursor.Close(ctx)
+
+	var clients []model.Client
+	if err := cursor.All(ctx, &clients); err != nil {
+		return nil, 0, err
+	}
+
+	count, _ := r.collection.CountDocuments(ctx, bson.M{})
+	return clients, int32(count), nil
+}
+
+func (r *ClientRepository) Update(ctx context.Context, clientID string, updates bson.M) error {
+	updates["updated_at"] = time.Now().Unix()
+	res, err := r.collection.UpdateOne(ctx, bson.M{"client_id": clientID}, bson.M{"$set": updates})
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return errClientNotFound
+	}
+	return nil
+}
+
+func (r *ClientRepository) UpdateSecret(ctx context.Context, clientID, secretHash string) error {
+	now := time.Now().Unix()
+	return r.Update(ctx, clientID, bson.M{
+		"secret_hash":       secretHash,
+		"secret_rotated_at": now,
+	})
+}
+
+func (r *ClientRepository) Delete(ctx context.Context, clientID string) error {
+	res, err := r.collection.DeleteOne(ctx, bson.M{"client_id": clientID})
+	if err != nil {
+		return err
+	}
+	if res.DeletedCount == 0 {
+		return errClientNotFound
+	}
+	return nil
+}
@@ -0,0 +1,110 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/bekbek22/auth_service/internal/model"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type IEmailChangeRepository interface {
+	CreatePending(ctx context.Context, change *model.EmailChange) error
+	FindPendingByConfirmToken(ctx context.Context, tokenHash string) (*model.EmailChange, error)
+	MarkConfirmed(ctx context.Context, id primitive.ObjectID, revertTokenHash string, revertExp int64) error
+	FindByRevertToken(ctx context.Context, tokenHash string) (*model.EmailChange, error)
+	DeleteByID(ctx context.Context, id primitive.ObjectID) error
+	DeletePendingByUser(ctx context.Context, userID primitive.ObjectID) error
+	FindByUser(ctx context.Context, userID primitive.ObjectID) ([]model.EmailChange, error)
+	DeleteByUser(ctx context.Context, userID primitive.ObjectID) error
+}
+
+type EmailChangeRepository struct {
+	collection *mongo.Collection
+}
+
+func NewEmailChangeRepository(db *mongo.Database) *EmailChangeRepository {
+	return &EmailChangeRepository{
+		collection: db.Collection("email_changes"),
+	}
+}
+
+func (r *EmailChangeRepository) CreatePending(ctx context.Context, change *model.EmailChange) error {
+	change.CreatedAt = time.Now().Unix()
+	_, err := r.collection.InsertOne(ctx, change)
+	return err
+}
+
+func (r *EmailChangeRepository) FindPendingByConfirmToken(ctx context.Context, tokenHash string) (*model.EmailChange, error) {
+	var change model.EmailChange
+	err := r.collection.FindOne(ctx, bson.M{
+		"confirm_token_hash": tokenHash,
+		"confirm_exp":        bson.M{"$gt": time.Now().Unix()},
+		"confirmed_at":       bson.M{"$exists": false},
+	}).Decode(&change)
+	if err != nil {
+		return nil, err
+	}
+	return &change, nil
+}
+
+func (r *EmailChangeRepository) MarkConfirmed(ctx context.Context, id primitive.ObjectID, revertTokenHash string, revertExp int64) error {
+	_, err := r.collection.UpdateOne(
+		ctx,
+		bson.M{"_id": id},
+		bson.M{"$set": bson.M{
+			"revert_token_hash": revertTokenHash,
+			"revert_exp":        revertExp,
+			"confirmed_at":      time.Now().Unix(),
+		}},
+	)
+	return err
+}
+
+func (r *EmailChangeRepository) FindByRevertToken(ctx context.Context, tokenHash string) (*model.EmailChange, error) {
+	var change model.EmailChange
+	err := r.collection.FindOne(ctx, bson.M{
+		"revert_token_hash": tokenHash,
+		"revert_exp":        bson.M{"$gt": time.Now().Unix()},
+	}).Decode(&change)
+	if err != nil {
+		return nil, err
+	}
+	return &change, nil
+}
+
+func (r *EmailChangeRepository) DeleteByID(ctx context.Context, id primitive.ObjectID) error {
+	_, err := r.collection.DeleteOne(ctx, bson.M{"_id": id})
+	return err
+}
+
+// DeletePendingByUser drops unconfirmed requests so only the latest one is valid.
+func (r *EmailChangeRepository) DeletePendingByUser(ctx context.Context, userID primitive.ObjectID) error {
+	_, err := r.collection.DeleteMany(ctx, bson.M{
+		"user_id":      userID,
+		"confirmed_at": bson.M{"$exists": false},
+	})
+	return err
+}
+
+func (r *EmailChangeRepository) FindByUser(ctx context.Context, userID primitive.ObjectID) ([]model.EmailChange, error) {
+	cursor, err := r.collection.Find(ctx, bson.M{"user_id": userID})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var changes []model.EmailChange
+	if err := cursor.All(ctx, &changes); err != nil {
+		return nil, err
+	}
+	return changes, nil
+}
+
+func (r *EmailChangeRepository) DeleteByUser(ctx context.Context, userID primitive.ObjectID) error {
+	_, err := r.collection.DeleteMany(ctx, bson.M{"user_id": userID})
+	return err
+}
@@ -0,0 +1,44 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/bekbek22/auth_service/internal/model"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type IFederationStateRepository interface {
+	Save(ctx context.Context, state *model.FederationState) error
+	Consume(ctx context.Context, stateHash string) (*model.FederationState, error)
+}
+
+type FederationStateRepository struct {
+	collection *mongo.Collection
+}
+
+func NewFederationStateRepository(db *mongo.Database) *FederationStateRepository {
+	return &FederationStateRepository{
+		collection: db.Collection("federation_states"),
+	}
+}
+
+func (r *FederationStateRepository) Save(ctx context.Context, state *model.FederationState) error {
+	_, err := r.collection.InsertOne(ctx, state)
+	return err
+}
+
+// Consume deletes and returns an unexpired state so a callback can't be replayed.
+func (r *FederationStateRepository) Consume(ctx context.Context, stateHash string) (*model.FederationState, error) {
+	var state model.FederationState
+	err := r.collection.FindOneAndDelete(ctx, bson.M{
+		"state_hash": stateHash,
+		"expires_at": bson.M{"$gt": time.Now().Unix()},
+	}).Decode(&state)
+	if err != nil {
+		return nil, err
+	}
+	return &state, nil
+}
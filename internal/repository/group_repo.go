@@ -0,0 +1,107 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/bekbek22/auth_service/internal/model"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type IGroupRepository interface {
+	Create(ctx context.Context, group *model.Group) error
+	FindByID(ctx context.Context, id primitive.ObjectID) (*model.Group, error)
+	FindByIDs(ctx context.Context, ids []primitive.ObjectID) ([]model.Group, error)
+	ListByTenant(ctx context.Context, tenantID string) ([]model.Group, error)
+	Update(ctx context.Context, id primitive.ObjectID, updates bson.M) error
+	Delete(ctx context.Context, id primitive.ObjectID) error
+}
+
+type GroupRepository struct {
+	collection *mongo.Collection
+}
+
+func NewGroupRepository(db *mongo.Database) *GroupRepository {
+	return &GroupRepository{
+		collection: db.Collection("groups"),
+	}
+}
+
+var errGroupNotFound = errors.New("group not found")
+
+// EnsureIndexes keeps group names unique within a tenant.
+func (r *GroupRepository) EnsureIndexes(ctx context.Context) error {
+	_, err := r.collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "tenant_id", Value: 1}, {Key: "name", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	return err
+}
+
+func (r *GroupRepository) Create(ctx context.Context, group *model.Group) error {
+	now := time.Now().Unix()
+	group.ID = primitive.NewObjectID()
+	group.CreatedAt = now
+	group.UpdatedAt = now
+	_, err := r.collection.InsertOne(ctx, group)
+	return err
+}
+
+func (r *GroupRepository) FindByID(ctx context.Context, id primitive.ObjectID) (*model.Group, error) {
+	var group model.Group
+	if err := r.collection.FindOne(ctx, bson.M{"_id": id}).Decode(&group); err != nil {
+		return nil, err
+	}
+	return &group, nil
+}
+
+func (r *GroupRepository) FindByIDs(ctx context.Context, ids []primitive.ObjectID) ([]model.Group, error) {
+	return r.find(ctx, bson.M{"_id": bson.M{"$in": ids}})
+}
+
+func (r *GroupRepository) ListByTenant(ctx context.Context, tenantID string) ([]model.Group, error) {
+	return r.find(ctx, bson.M{"tenant_id": tenantFilter(tenantID)})
+}
+
+func (r *GroupRepository) find(ctx context.Context, filter bson.M) ([]model.Group, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "name", Value: 1}})
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var groups []model.Group
+	if err := cursor.All(ctx, &groups); err != nil {
+		return nil, err
+	}
+	return groups, nil
+}
+
+func (r *GroupRepository) Update(ctx context.Context, id primitive.ObjectID, updates bson.M) error {
+	updates["updated_at"] = time.Now().Unix()
+	res, err := r.collection.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": updates})
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return errGroupNotFound
+	}
+	return nil
+}
+
+func (r *GroupRepository) Delete(ctx context.Context, id primitive.ObjectID) error {
+	res, err := r.collection.DeleteOne(ctx, bson.M{"_id": id})
+	if err != nil {
+		return err
+	}
+	if res.DeletedCount == 0 {
+		return errGroupNotFound
+	}
+	return nil
+}
@@ -0,0 +1,101 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/bekbek22/auth_service/internal/model"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type IGroupMemberRepository interface {
+	Add(ctx context.Context, member *model.GroupMember) error
+	Remove(ctx context.Context, groupID, userID primitive.ObjectID) error
+	ListByGroup(ctx context.Context, groupID primitive.ObjectID) ([]model.GroupMember, error)
+	ListByUser(ctx context.Context, userID primitive.ObjectID) ([]model.GroupMember, error)
+	DeleteByGroup(ctx context.Context, groupID primitive.ObjectID) error
+	DeleteByUser(ctx context.Context, userID primitive.ObjectID) error
+}
+
+type GroupMemberRepository struct {
+	collection *mongo.Collection
+}
+
+func NewGroupMemberRepository(db *mongo.Database) *GroupMemberRepository {
+	return &GroupMemberRepository{
+		collection: db.Collection("group_members"),
+	}
+}
+
+// EnsureIndexes allows a user in a group once, and makes resolving a user's
+// groups, which happens whenever a token is issued, an index hit.
+func (r *GroupMemberRepository) EnsureIndexes(ctx context.Context) error {
+	_, err := r.collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "group_id", Value: 1}, {Key: "user_id", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{Keys: bson.D{{Key: "user_id", Value: 1}}},
+	})
+	return err
+}
+
+// Add puts a user in a group; adding an existing member is not an error.
+func (r *GroupMemberRepository) Add(ctx context.Context, member *model.GroupMember) error {
+	member.ID = primitive.NewObjectID()
+	member.CreatedAt = time.Now().Unix()
+	_, err := r.collection.InsertOne(ctx, member)
+	if mongo.IsDuplicateKeyError(err) {
+		return nil
+	}
+	return err
+}
+
+func (r *GroupMemberRepository) Remove(ctx context.Context, groupID, userID primitive.ObjectID) error {
+	res, err := r.collection.DeleteOne(ctx, bson.M{"group_id": groupID, "user_id": userID})
+	if err != nil {
+		return err
+	}
+	if res.DeletedCount == 0 {
+		return errors.New("not a member of the group")
+	}
+	return nil
+}
+
+func (r *GroupMemberRepository) ListByGroup(ctx context.Context, groupID primitive.ObjectID) ([]model.GroupMember, error) {
+	return r.list(ctx, bson.M{"group_id": groupID})
+}
+
+func (r *GroupMemberRepository) ListByUser(ctx context.Context, userID primitive.ObjectID) ([]model.GroupMember, error) {
+	return r.list(ctx, bson.M{"user_id": userID})
+}
+
+func (r *GroupMemberRepository) list(ctx context.Context, filter bson.M) ([]model.GroupMember, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}})
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var members []model.GroupMember
+	if err := cursor.All(ctx, &members); err != nil {
+		return nil, err
+	}
+	return members, nil
+}
+
+func (r *GroupMemberRepository) DeleteByGroup(ctx context.Context, groupID primitive.ObjectID) error {
+	_, err := r.collection.DeleteMany(ctx, bson.M{"group_id": groupID})
+	return err
+}
+
+func (r *GroupMemberRepository) DeleteByUser(ctx context.Context, userID primitive.ObjectID) error {
+	_, err := r.collection.DeleteMany(ctx, bson.M{"user_id": userID})
+	return err
+}
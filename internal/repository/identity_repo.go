@@ -0,0 +1,122 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/bekbek22/auth_service/internal/model"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type IIdentityRepository interface {
+	Create(ctx context.Context, identity *model.Identity) error
+	FindByProviderSubject(ctx context.Context, provider, subject string) (*model.Identity, error)
+	ListByUser(ctx context.Context, userID primitive.ObjectID) ([]model.Identity, error)
+	TouchLogin(ctx context.Context, id primitive.ObjectID) error
+	UpdatePasskeyUsage(ctx context.Context, id primitive.ObjectID, signCount uint32, cloneWarning bool, flags byte) error
+	DeleteByID(ctx context.Context, userID, id primitive.ObjectID) error
+	DeleteByUser(ctx context.Context, userID primitive.ObjectID) error
+}
+
+type IdentityRepository struct {
+	collection *mongo.Collection
+}
+
+func NewIdentityRepository(db *mongo.Database) *IdentityRepository {
+	return &IdentityRepository{
+		collection: db.Collection("identities"),
+	}
+}
+
+// EnsureIndexes stops the same external account from being linked twice.
+func (r *IdentityRepository) EnsureIndexes(ctx context.Context) error {
+	_, err := r.collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "provider", Value: 1}, {Key: "subject", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	return err
+}
+
+func (r *IdentityRepository) Create(ctx context.Context, identity *model.Identity) error {
+	now := time.Now().Unix()
+	identity.ID = primitive.NewObjectID()
+	identity.CreatedAt = now
+	identity.LastLoginAt = now
+	_, err := r.collection.InsertOne(ctx, identity)
+	return err
+}
+
+func (r *IdentityRepository) FindByProviderSubject(ctx context.Context, provider, subject string) (*model.Identity, error) {
+	var identity model.Identity
+	err := r.collection.FindOne(ctx, bson.M{
+		"provider": provider,
+		"subject":  subject,
+	}).Decode(&identity)
+	if err != nil {
+		return nil, err
+	}
+	return &identity, nil
+}
+
+func (r *IdentityRepository) ListByUser(ctx context.Context, userID primitive.ObjectID) ([]model.Identity, error) {
+	cursor, err := r.collection.Find(ctx, bson.M{"user_id": userID})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var identities []model.Identity
+	if err := cursor.All(ctx, &identities); err != nil {
+		return nil, err
+	}
+	return identities, nil
+}
+
+func (r *IdentityRepository) TouchLogin(ctx context.Context, id primitive.ObjectID) error {
+	_, err := r.collection.UpdateOne(
+		ctx,
+		bson.M{"_id": id},
+		bson.M{"$set": bson.M{"last_login_at": time.Now().Unix()}},
+	)
+	return err
+}
+
+// UpdatePasskeyUsage stores the authenticator state reported by a passkey login.
+func (r *IdentityRepository) UpdatePasskeyUsage(ctx context.Context, id primitive.ObjectID, signCount uint32, cloneWarning bool, flags byte) error {
+	_, err := r.collection.UpdateOne(
+		ctx,
+		bson.M{"_id": id},
+		bson.M{"$set": bson.M{
+			"passkey.sign_count":    signCount,
+			"passkey.clone_warning": cloneWarning,
+			"passkey.flags":         flags,
+			"last_login_at":         time.Now().Unix(),
+		}},
+	)
+	return err
+}
+
+// DeleteByID removes one identity, scoped to its owner.
+func (r *IdentityRepository) DeleteByID(ctx context.Context, userID, id primitive.ObjectID) error {
+	res, err := r.collection.DeleteOne(ctx, bson.M{
+		"_id":     id,
+		"user_id": userID,
+	})
+	if err != nil {
+		return err
+	}
+	if res.DeletedCount == 0 {
+		return errors.New("login method not found")
+	}
+	return nil
+}
+
+func (r *IdentityRepository) DeleteByUser(ctx context.Context, userID primitive.ObjectID) error {
+	_, err := r.collection.DeleteMany(ctx, bson.M{"user_id": userID})
+	return err
+}
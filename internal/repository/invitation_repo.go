@@ -0,0 +1,115 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/bekbek22/auth_service/internal/model"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type IInvitationRepository interface {
+	Create(ctx context.Context, inv *model.Invitation) error
+	FindPendingByHash(ctx context.Context, tokenHash string) (*model.Invitation, error)
+	ListPendingByOrg(ctx context.Context, orgID primitive.ObjectID) ([]model.Invitation, error)
+	Delete(ctx context.Context, orgID, id primitive.ObjectID) error
+	DeleteByEmail(ctx context.Context, orgID primitive.ObjectID, email string) error
+	DeleteByOrg(ctx context.Context, orgID primitive.ObjectID) error
+	DeleteAllByEmail(ctx context.Context, email string, createdBefore int64) error
+}
+
+type InvitationRepository struct {
+	collection *mongo.Collection
+}
+
+func NewInvitationRepository(db *mongo.Database) *InvitationRepository {
+	return &InvitationRepository{
+		collection: db.Collection("invitations"),
+	}
+}
+
+// EnsureIndexes makes accepting an invite an index hit.
+func (r *InvitationRepository) EnsureIndexes(ctx context.Context) error {
+	_, err := r.collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "token_hash", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	return err
+}
+
+func (r *InvitationRepository) Create(ctx context.Context, inv *model.Invitation) error {
+	inv.ID = primitive.NewObjectID()
+	inv.CreatedAt = time.Now().Unix()
+	_, err := r.collection.InsertOne(ctx, inv)
+	return err
+}
+
+func (r *InvitationRepository) FindPendingByHash(ctx context.Context, tokenHash string) (*model.Invitation, error) {
+	var inv model.Invitation
+	err := r.collection.FindOne(ctx, bson.M{
+		"token_hash": tokenHash,
+		"expires_at": bson.M{"$gt": time.Now().Unix()},
+	}).Decode(&inv)
+	if err != nil {
+		return nil, err
+	}
+	return &inv, nil
+}
+
+func (r *InvitationRepository) ListPendingByOrg(ctx context.Context, orgID primitive.ObjectID) ([]model.Invitation, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}})
+	cursor, err := r.collection.Find(ctx, bson.M{
+		"org_id":     orgID,
+		"expires_at": bson.M{"$gt": time.Now().Unix()},
+	}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var invitations []model.Invitation
+	if err := cursor.All(ctx, &invitations); err != nil {
+		return nil, err
+	}
+	return invitations, nil
+}
+
+// Delete removes an invitation. Accepting one deletes it first, so that only
+// one caller can use it.
+func (r *InvitationRepository) Delete(ctx context.Context, orgID, id primitive.ObjectID) error {
+	res, err := r.collection.DeleteOne(ctx, bson.M{"_id": id, "org_id": orgID})
+	if err != nil {
+		return err
+	}
+	if res.DeletedCount == 0 {
+		return errors.New("invitation not found")
+	}
+	return nil
+}
+
+// DeleteByEmail drops earlier invites of the same address, so only the
+// newest one works.
+func (r *InvitationRepository) DeleteByEmail(ctx context.Context, orgID primitive.ObjectID, email string) error {
+	_, err := r.collection.DeleteMany(ctx, bson.M{"org_id": orgID, "email": email})
+	return err
+}
+
+func (r *InvitationRepository) DeleteByOrg(ctx context.Context, orgID primitive.ObjectID) error {
+	_, err := r.collection.DeleteMany(ctx, bson.M{"org_id": orgID})
+	return err
+}
+
+// DeleteAllByEmail drops the invites of an address in every organization that
+// were sent up to createdBefore.
+func (r *InvitationRepository) DeleteAllByEmail(ctx context.Context, email string, createdBefore int64) error {
+	_, err := r.collection.DeleteMany(ctx, bson.M{
+		"email":      email,
+		"created_at": bson.M{"$lte": createdBefore},
+	})
+	return err
+}
@@ -0,0 +1,66 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type IMagicLinkRepository interface {
+	SaveToken(ctx context.Context, tenantID, email, tokenHash string, exp int64) error
+	ConsumeToken(ctx context.Context, tokenHash string) (tenantID, email string, err error)
+	DeleteByEmail(ctx context.Context, tenantID, email string) error
+}
+
+// MagicLinkRepository stores sign-in links the same way password reset tokens
+// are stored, except that only a hash of the token is kept.
+type MagicLinkRepository struct {
+	collection *mongo.Collection
+}
+
+func NewMagicLinkRepository(db *mongo.Database) *MagicLinkRepository {
+	return &MagicLinkRepository{
+		collection: db.Collection("magic_links"),
+	}
+}
+
+func (r *MagicLinkRepository) SaveToken(ctx context.Context, tenantID, email, tokenHash string, exp int64) error {
+	doc := map[string]interface{}{
+		"email":      email,
+		"token_hash": tokenHash,
+		"exp":        exp,
+	}
+	if tenantID != "" {
+		doc["tenant_id"] = tenantID
+	}
+	_, err := r.collection.InsertOne(ctx, doc)
+	return err
+}
+
+// ConsumeToken deletes an unexpired token and returns its tenant and email,
+// so each link works only once.
+func (r *MagicLinkRepository) ConsumeToken(ctx context.Context, tokenHash string) (string, string, error) {
+	now := time.Now().Unix()
+	var result struct {
+		TenantID string `bson:"tenant_id"`
+		Email    string `bson:"email"`
+		Exp      int64  `bson:"exp"`
+	}
+	err := r.collection.FindOneAndDelete(ctx, map[string]interface{}{
+		"token_hash": tokenHash,
+		"exp":        map[string]interface{}{"$gt": now},
+	}).Decode(&result)
+	if err != nil {
+		return "", "", err
+	}
+	return result.TenantID, result.Email, nil
+}
+
+func (r *MagicLinkRepository) DeleteByEmail(ctx context.Context, tenantID, email string) error {
+	_, err := r.collection.DeleteMany(ctx, map[string]interface{}{
+		"tenant_id": tenantFilter(tenantID),
+		"email":     email,
+	})
+	return err
+}
@@ -0,0 +1,131 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/bekbek22/auth_service/internal/model"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type IMembershipRepository interface {
+	Create(ctx context.Context, m *model.Membership) error
+	Find(ctx context.Context, orgID, userID primitive.ObjectID) (*model.Membership, error)
+	ListByOrg(ctx context.Context, orgID primitive.ObjectID) ([]model.Membership, error)
+	ListByUser(ctx context.Context, userID primitive.ObjectID) ([]model.Membership, error)
+	CountByRole(ctx context.Context, orgID primitive.ObjectID, role string) (int64, error)
+	UpdateRole(ctx context.Context, orgID, userID primitive.ObjectID, role string) error
+	Delete(ctx context.Context, orgID, userID primitive.ObjectID) error
+	DeleteByOrg(ctx context.Context, orgID primitive.ObjectID) error
+	DeleteByUser(ctx context.Context, userID primitive.ObjectID) error
+}
+
+type MembershipRepository struct {
+	collection *mongo.Collection
+}
+
+func NewMembershipRepository(db *mongo.Database) *MembershipRepository {
+	return &MembershipRepository{
+		collection: db.Collection("memberships"),
+	}
+}
+
+var errMembershipNotFound = errors.New("membership not found")
+
+// EnsureIndexes allows one membership per user and organization, and makes
+// listing a user's organizations an index hit.
+func (r *MembershipRepository) EnsureIndexes(ctx context.Context) error {
+	_, err := r.collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "org_id", Value: 1}, {Key: "user_id", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{Keys: bson.D{{Key: "user_id", Value: 1}}},
+	})
+	return err
+}
+
+func (r *MembershipRepository) Create(ctx context.Context, m *model.Membership) error {
+	now := time.Now().Unix()
+	m.ID = primitive.NewObjectID()
+	m.CreatedAt = now
+	m.UpdatedAt = now
+	_, err := r.collection.InsertOne(ctx, m)
+	return err
+}
+
+func (r *MembershipRepository) Find(ctx context.Context, orgID, userID primitive.ObjectID) (*model.Membership, error) {
+	var m model.Membership
+	if err := r.collection.FindOne(ctx, bson.M{"org_id": orgID, "user_id": userID}).Decode(&m); err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
+func (r *MembershipRepository) ListByOrg(ctx context.Context, orgID primitive.ObjectID) ([]model.Membership, error) {
+	return r.list(ctx, bson.M{"org_id": orgID})
+}
+
+func (r *MembershipRepository) ListByUser(ctx context.Context, userID primitive.ObjectID) ([]model.Membership, error) {
+	return r.list(ctx, bson.M{"user_id": userID})
+}
+
+func (r *MembershipRepository) list(ctx context.Context, filter bson.M) ([]model.Membership, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}})
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var memberships []model.Membership
+	if err := cursor.All(ctx, &memberships); err != nil {
+		return nil, err
+	}
+	return memberships, nil
+}
+
+func (r *MembershipRepository) CountByRole(ctx context.Context, orgID primitive.ObjectID, role string) (int64, error) {
+	return r.collection.CountDocuments(ctx, bson.M{"org_id": orgID, "role": role})
+}
+
+func (r *MembershipRepository) UpdateRole(ctx context.Context, orgID, userID primitive.ObjectID, role string) error {
+	res, err := r.collection.UpdateOne(
+		ctx,
+		bson.M{"org_id": orgID, "user_id": userID},
+		bson.M{"$set": bson.M{"role": role, "updated_at": time.Now().Unix()}},
+	)
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return errMembershipNotFound
+	}
+	return nil
+}
+
+func (r *MembershipRepository) Delete(ctx context.Context, orgID, userID primitive.ObjectID) error {
+	res, err := r.collection.DeleteOne(ctx, bson.M{"org_id": orgID, "user_id": userID})
+	if err != nil {
+		return err
+	}
+	if res.DeletedCount == 0 {
+		return errMembershipNotFound
+	}
+	return nil
+}
+
+func (r *MembershipRepository) DeleteByOrg(ctx context.Context, orgID primitive.ObjectID) error {
+	_, err := r.collection.DeleteMany(ctx, bson.M{"org_id": orgID})
+	return err
+}
+
+func (r *MembershipRepository) DeleteByUser(ctx context.Context, userID primitive.ObjectID) error {
+	_, err := r.collection.DeleteMany(ctx, bson.M{"user_id": userID})
+	return err
+}
@@ -0,0 +1,66 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/bekbek22/auth_service/internal/model"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type IMFAChallengeRepository interface {
+	Create(ctx context.Context, challenge *model.MFAChallenge) error
+	FindActive(ctx context.Context, tokenHash string) (*model.MFAChallenge, error)
+	Delete(ctx context.Context, id primitive.ObjectID) error
+	DeleteByUser(ctx context.Context, userID primitive.ObjectID) error
+}
+
+type MFAChallengeRepository struct {
+	collection *mongo.Collection
+}
+
+func NewMFAChallengeRepository(db *mongo.Database) *MFAChallengeRepository {
+	return &MFAChallengeRepository{
+		collection: db.Collection("mfa_challenges"),
+	}
+}
+
+func (r *MFAChallengeRepository) Create(ctx context.Context, challenge *model.MFAChallenge) error {
+	challenge.ID = primitive.NewObjectID()
+	challenge.CreatedAt = time.Now().Unix()
+	_, err := r.collection.InsertOne(ctx, challenge)
+	return err
+}
+
+func (r *MFAChallengeRepository) FindActive(ctx context.Context, tokenHash string) (*model.MFAChallenge, error) {
+	var challenge model.MFAChallenge
+	err := r.collection.FindOne(ctx, bson.M{
+		"token_hash": tokenHash,
+		"expires_at": bson.M{"$gt": time.Now().Unix()},
+	}).Decode(&challenge)
+	if err != nil {
+		return nil, err
+	}
+	return &challenge, nil
+}
+
+// Delete removes a completed challenge. It fails if the challenge is already
+// gone so two concurrent completions can't both succeed.
+func (r *MFAChallengeRepository) Delete(ctx context.Context, id primitive.ObjectID) error {
+	res, err := r.collection.DeleteOne(ctx, bson.M{"_id": id})
+	if err != nil {
+		return err
+	}
+	if res.DeletedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+func (r *MFAChallengeRepository) DeleteByUser(ctx context.Context, userID primitive.ObjectID) error {
+	_, err := r.collection.DeleteMany(ctx, bson.M{"user_id": userID})
+	return err
+}
@@ -0,0 +1,51 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/bekbek22/auth_service/internal/model"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type IOAuthCodeRepository interface {
+	Save(ctx context.Context, code *model.AuthorizationCode) error
+	Consume(ctx context.Context, codeHash string) (*model.AuthorizationCode, error)
+	DeleteByClient(ctx context.Context, clientID string) error
+}
+
+type OAuthCodeRepository struct {
+	collection *mongo.Collection
+}
+
+func NewOAuthCodeRepository(db *mongo.Database) *OAuthCodeRepository {
+	return &OAuthCodeRepository{
+		collection: db.Collection("oauth_codes"),
+	}
+}
+
+func (r *OAuthCodeRepository) Save(ctx context.Context, code *model.AuthorizationCode) error {
+	code.CreatedAt = time.Now().Unix()
+	_, err := r.collection.InsertOne(ctx, code)
+	return err
+}
+
+// Consume deletes and returns an unexpired code, so it can only be used once.
+func (r *OAuthCodeRepository) Consume(ctx context.Context, codeHash string) (*model.AuthorizationCode, error) {
+	var code model.AuthorizationCode
+	err := r.collection.FindOneAndDelete(ctx, bson.M{
+		"code_hash":  codeHash,
+		"expires_at": bson.M{"$gt": time.Now().Unix()},
+	}).Decode(&code)
+	if err != nil {
+		return nil, err
+	}
+	return &code, nil
+}
+
+func (r *OAuthCodeRepository) DeleteByClient(ctx context.Context, clientID string) error {
+	_, err := r.collection.DeleteMany(ctx, bson.M{"client_id": clientID})
+	return err
+}
@@ -0,0 +1,72 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/bekbek22/auth_service/internal/model"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type IOrganizationRepository interface {
+	Create(ctx context.Context, org *model.Organization) error
+	FindByID(ctx context.Context, id primitive.ObjectID) (*model.Organization, error)
+	FindByIDs(ctx context.Context, ids []primitive.ObjectID) ([]model.Organization, error)
+	Delete(ctx context.Context, id primitive.ObjectID) error
+}
+
+type OrganizationRepository struct {
+	collection *mongo.Collection
+}
+
+func NewOrganizationRepository(db *mongo.Database) *OrganizationRepository {
+	return &OrganizationRepository{
+		collection: db.Collection("organizations"),
+	}
+}
+
+func (r *OrganizationRepository) Create(ctx context.Context, org *model.Organization) error {
+	now := time.Now().Unix()
+	org.ID = primitive.NewObjectID()
+	org.CreatedAt = now
+	org.UpdatedAt = now
+	_, err := r.collection.InsertOne(ctx, org)
+	return err
+}
+
+func (r *OrganizationRepository) FindByID(ctx context.Context, id primitive.ObjectID) (*model.Organization, error) {
+	var org model.Organization
+	if err := r.collection.FindOne(ctx, bson.M{"_id": id}).Decode(&org); err != nil {
+		return nil, err
+	}
+	return &org, nil
+}
+
+func (r *OrganizationRepository) FindByIDs(ctx context.Context, ids []primitive.ObjectID) ([]model.Organization, error) {
+	cursor, err := r.collection.Find(ctx, bson.M{"_id": bson.M{"$in": ids}})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var orgs []model.Organization
+	if err := cursor.All(ctx, &orgs); err != nil {
+		return nil, err
+	}
+	return orgs, nil
+}
+
+func (r *OrganizationRepository) Delete(ctx context.Context, id primitive.ObjectID) error {
+	res, err := r.collection.DeleteOne(ctx, bson.M{"_id": id})
+	if err != nil {
+		return err
+	}
+	if res.DeletedCount == 0 {
+		return errors.New("organization not found")
+	}
+	return nil
+}
@@ -0,0 +1,96 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/bekbek22/auth_service/internal/model"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type IOTPCodeRepository interface {
+	Replace(ctx context.Context, code *model.OTPCode) error
+	FindActive(ctx context.Context, userID primitive.ObjectID, purpose string) (*model.OTPCode, error)
+	UseAttempt(ctx context.Context, id primitive.ObjectID, maxAttempts int) error
+	Delete(ctx context.Context, id primitive.ObjectID) error
+	DeleteByUser(ctx context.Context, userID primitive.ObjectID) error
+}
+
+type OTPCodeRepository struct {
+	collection *mongo.Collection
+}
+
+func NewOTPCodeRepository(db *mongo.Database) *OTPCodeRepository {
+	return &OTPCodeRepository{
+		collection: db.Collection("otp_codes"),
+	}
+}
+
+// Replace stores a newly sent code, dropping any earlier code of the same
+// user and purpose so only the latest one can be entered.
+func (r *OTPCodeRepository) Replace(ctx context.Context, code *model.OTPCode) error {
+	_, err := r.collection.DeleteMany(ctx, bson.M{
+		"user_id": code.UserID,
+		"purpose": code.Purpose,
+	})
+	if err != nil {
+		return err
+	}
+
+	code.ID = primitive.NewObjectID()
+	code.CreatedAt = time.Now().Unix()
+	_, err = r.collection.InsertOne(ctx, code)
+	return err
+}
+
+func (r *OTPCodeRepository) FindActive(ctx context.Context, userID primitive.ObjectID, purpose string) (*model.OTPCode, error) {
+	var code model.OTPCode
+	err := r.collection.FindOne(ctx, bson.M{
+		"user_id":    userID,
+		"purpose":    purpose,
+		"expires_at": bson.M{"$gt": time.Now().Unix()},
+	}).Decode(&code)
+	if err != nil {
+		return nil, err
+	}
+	return &code, nil
+}
+
+// UseAttempt counts one guess at a code before it is checked, and fails once
+// maxAttempts guesses have been made. Counting first keeps parallel guesses
+// from getting past the limit.
+func (r *OTPCodeRepository) UseAttempt(ctx context.Context, id primitive.ObjectID, maxAttempts int) error {
+	res, err := r.collection.UpdateOne(
+		ctx,
+		bson.M{"_id": id, "attempts": bson.M{"$lt": maxAttempts}},
+		bson.M{"$inc": bson.M{"attempts": 1}},
+	)
+	if err != nil {
+		return err
+	}
+	if res.ModifiedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+// Delete removes a code once it has been used. It fails if the code is
+// already gone so the same code can't be redeemed twice.
+func (r *OTPCodeRepository) Delete(ctx context.Context, id primitive.ObjectID) error {
+	res, err := r.collection.DeleteOne(ctx, bson.M{"_id": id})
+	if err != nil {
+		return err
+	}
+	if res.DeletedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+func (r *OTPCodeRepository) DeleteByUser(ctx context.Context, userID primitive.ObjectID) error {
+	_, err := r.collection.DeleteMany(ctx, bson.M{"user_id": userID})
+	return err
+}
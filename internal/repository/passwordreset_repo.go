@@ -8,9 +8,11 @@ import (
 )
 
 type IPasswordResetRepository interface {
-	SaveToken(ctx context.Context, email, token string, exp int64) error
-	GetEmailByToken(ctx context.Context, token string) (string, error)
+	SaveToken(ctx context.Context, tenantID, email, token string, exp int64) error
+	GetEmailByToken(ctx context.Context, token string) (tenantID, email string, err error)
 	DeleteToken(ctx context.Context, token string) error
+	FindExpiriesByEmail(ctx context.Context, tenantID, email string) ([]int64, error)
+	DeleteByEmail(ctx context.Context, tenantID, email string) error
 }
 
 type PasswordResetRepository struct {
@@ -23,30 +25,35 @@ func NewPasswordResetRepository(db *mongo.Database) *PasswordResetRepository {
 	}
 }
 
-func (r *PasswordResetRepository) SaveToken(ctx context.Context, email, token string, exp int64) error {
+func (r *PasswordResetRepository) SaveToken(ctx context.Context, tenantID, email, token string, exp int64) error {
 	doc := map[string]interface{}{
 		"email": email,
 		"token": token,
 		"exp":   exp,
 	}
+	if tenantID != "" {
+		doc["tenant_id"] = tenantID
+	}
 	_, err := r.collection.InsertOne(ctx, doc)
 	return err
 }
 
-func (r *PasswordResetRepository) GetEmailByToken(ctx context.Context, token string) (string, error) {
+// GetEmailByToken returns the tenant and email an unexpired token was issued to.
+func (r *PasswordResetRepository) GetEmailByToken(ctx context.Context, token string) (string, string, error) {
 	now := time.Now().Unix()
 	var result struct {
-		Email string `bson:"email"`
-		Exp   int64  `bson:"exp"`
+		TenantID string `bson:"tenant_id"`
+		Email    string `bson:"email"`
+		Exp      int64  `bson:"exp"`
 	}
 	err := r.collection.FindOne(ctx, map[string]interface{}{
 		"token": token,
 		"exp":   map[string]interface{}{"$gt": now},
 	}).Decode(&result)
 	if err != nil {
-		return "", err
+		return "", "", err
 	}
-	return result.Email, nil
+	return result.TenantID, result.Email, nil
 }
 
 func (r *PasswordResetRepository) DeleteToken(ctx context.Context, token string) error {
@@ -55,3 +62,36 @@ func (r *PasswordResetRepository) DeleteToken(ctx context.Context, token string)
 	})
 	return err
 }
+
+// FindExpiriesByEmail lists the expiry of every reset token issued to an email.
+func (r *PasswordResetRepository) FindExpiriesByEmail(ctx context.Context, tenantID, email string) ([]int64, error) {
+	cursor, err := r.collection.Find(ctx, map[string]interface{}{
+		"tenant_id": tenantFilter(tenantID),
+		"email":     email,
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var results []struct {
+		Exp int64 `bson:"exp"`
+	}
+	if err := cursor.All(ctx, &results); err != nil {
+		return nil, err
+	}
+
+	exps := make([]int64, 0, len(results))
+	for _, r := range results {
+		exps = append(exps, r.Exp)
+	}
+	return exps, nil
+}
+
+func (r *PasswordResetRepository) DeleteByEmail(ctx context.Context, tenantID, email string) error {
+	_, err := r.collection.DeleteMany(ctx, map[string]interface{}{
+		"tenant_id": tenantFilter(tenantID),
+		"email":     email,
+	})
+	return err
+}
@@ -0,0 +1,118 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/bekbek22/auth_service/internal/model"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type IPersonalAccessTokenRepository interface {
+	Create(ctx context.Context, token *model.PersonalAccessToken) error
+	FindActiveByHash(ctx context.Context, tokenHash string) (*model.PersonalAccessToken, error)
+	ListByUser(ctx context.Context, userID primitive.ObjectID) ([]model.PersonalAccessToken, error)
+	TouchLastUsed(ctx context.Context, id primitive.ObjectID, at int64) error
+	Revoke(ctx context.Context, userID, id primitive.ObjectID) error
+	RevokeAllByUser(ctx context.Context, userID primitive.ObjectID) error
+	DeleteByUser(ctx context.Context, userID primitive.ObjectID) error
+}
+
+type PersonalAccessTokenRepository struct {
+	collection *mongo.Collection
+}
+
+func NewPersonalAccessTokenRepository(db *mongo.Database) *PersonalAccessTokenRepository {
+	return &PersonalAccessTokenRepository{
+		collection: db.Collection("personal_access_tokens"),
+	}
+}
+
+// EnsureIndexes makes token lookups, which happen on every call made with a
+// personal access token, an index hit.
+func (r *PersonalAccessTokenRepository) EnsureIndexes(ctx context.Context) error {
+	_, err := r.collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "token_hash", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	return err
+}
+
+func (r *PersonalAccessTokenRepository) Create(ctx context.Context, token *model.PersonalAccessToken) error {
+	token.ID = primitive.NewObjectID()
+	token.CreatedAt = time.Now().Unix()
+	_, err := r.collection.InsertOne(ctx, token)
+	return err
+}
+
+// FindActiveByHash returns an unrevoked, unexpired token.
+func (r *PersonalAccessTokenRepository) FindActiveByHash(ctx context.Context, tokenHash string) (*model.PersonalAccessToken, error) {
+	var token model.PersonalAccessToken
+	err := r.collection.FindOne(ctx, bson.M{
+		"token_hash": tokenHash,
+		"revoked_at": bson.M{"$exists": false},
+		"expires_at": bson.M{"$gt": time.Now().Unix()},
+	}).Decode(&token)
+	if err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
+
+func (r *PersonalAccessTokenRepository) ListByUser(ctx context.Context, userID primitive.ObjectID) ([]model.PersonalAccessToken, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}})
+	cursor, err := r.collection.Find(ctx, bson.M{"user_id": userID}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var tokens []model.PersonalAccessToken
+	if err := cursor.All(ctx, &tokens); err != nil {
+		return nil, err
+	}
+	return tokens, nil
+}
+
+func (r *PersonalAccessTokenRepository) TouchLastUsed(ctx context.Context, id primitive.ObjectID, at int64) error {
+	_, err := r.collection.UpdateOne(
+		ctx,
+		bson.M{"_id": id},
+		bson.M{"$set": bson.M{"last_used_at": at}},
+	)
+	return err
+}
+
+func (r *PersonalAccessTokenRepository) Revoke(ctx context.Context, userID, id primitive.ObjectID) error {
+	res, err := r.collection.UpdateOne(
+		ctx,
+		bson.M{"_id": id, "user_id": userID, "revoked_at": bson.M{"$exists": false}},
+		bson.M{"$set": bson.M{"revoked_at": time.Now().Unix()}},
+	)
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return errors.New("token not found or already revoked")
+	}
+	return nil
+}
+
+func (r *PersonalAccessTokenRepository) RevokeAllByUser(ctx context.Context, userID primitive.ObjectID) error {
+	_, err := r.collection.UpdateMany(
+		ctx,
+		bson.M{"user_id": userID, "revoked_at": bson.M{"$exists": false}},
+		bson.M{"$set": bson.M{"revoked_at": time.Now().Unix()}},
+	)
+	return err
+}
+
+func (r *PersonalAccessTokenRepository) DeleteByUser(ctx context.Context, userID primitive.ObjectID) error {
+	_, err := r.collection.DeleteMany(ctx, bson.M{"user_id": userID})
+	return err
+}
@@ -0,0 +1,100 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/bekbek22/auth_service/internal/model"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type IPolicyRepository interface {
+	Create(ctx context.Context, policy *model.Policy) error
+	FindByID(ctx context.Context, id primitive.ObjectID) (*model.Policy, error)
+	ListAll(ctx context.Context) ([]model.Policy, error)
+	Replace(ctx context.Context, policy *model.Policy) error
+	Delete(ctx context.Context, id primitive.ObjectID) error
+}
+
+type PolicyRepository struct {
+	collection *mongo.Collection
+}
+
+func NewPolicyRepository(db *mongo.Database) *PolicyRepository {
+	return &PolicyRepository{
+		collection: db.Collection("policies"),
+	}
+}
+
+var errPolicyNotFound = errors.New("policy not found")
+
+func (r *PolicyRepository) Create(ctx context.Context, policy *model.Policy) error {
+	now := time.Now().Unix()
+	policy.ID = primitive.NewObjectID()
+	policy.CreatedAt = now
+	policy.UpdatedAt = now
+	_, err := r.collection.InsertOne(ctx, policy)
+	return err
+}
+
+func (r *PolicyRepository) FindByID(ctx context.Context, id primitive.ObjectID) (*model.Policy, error) {
+	var policy model.Policy
+	if err := r.collection.FindOne(ctx, bson.M{"_id": id}).Decode(&policy); err != nil {
+		return nil, err
+	}
+	return &policy, nil
+}
+
+// ListAll returns every policy; the policy engine keeps them all in memory.
+func (r *PolicyRepository) ListAll(ctx context.Context) ([]model.Policy, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}})
+	cursor, err := r.collection.Find(ctx, bson.M{}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var policies []model.Policy
+	if err := cursor.All(ctx, &policies); err != nil {
+		return nil, err
+	}
+	return policies, nil
+}
+
+// Replace overwrites a policy, keeping its creation time.
+func (r *PolicyRepository) Replace(ctx context.Context, policy *model.Policy) error {
+	policy.UpdatedAt = time.Now().Unix()
+	res, err := r.collection.UpdateOne(ctx, bson.M{"_id": policy.ID}, bson.M{"$set": bson.M{
+		"name":        policy.Name,
+		"description": policy.Description,
+		"effect":      policy.Effect,
+		"roles":       policy.Roles,
+		"actions":     policy.Actions,
+		"resources":   policy.Resources,
+		"conditions":  policy.Conditions,
+		"updated_at":  policy.UpdatedAt,
+	}})
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return errPolicyNotFound
+	}
+	return nil
+}
+
+func (r *PolicyRepository) Delete(ctx context.Context, id primitive.ObjectID) error {
+	res, err := r.collection.DeleteOne(ctx, bson.M{"_id": id})
+	if err != nil {
+		return err
+	}
+	if res.DeletedCount == 0 {
+		return errPolicyNotFound
+	}
+	return nil
+}
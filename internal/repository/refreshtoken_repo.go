@@ -0,0 +1,99 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/bekbek22/auth_service/internal/model"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type IRefreshTokenRepository interface {
+	Save(ctx context.Context, token *model.RefreshToken) error
+	FindByHash(ctx context.Context, tokenHash string) (*model.RefreshToken, error)
+	MarkRotated(ctx context.Context, id primitive.ObjectID) (bool, error)
+	RevokeBySession(ctx context.Context, sessionID primitive.ObjectID) error
+	DeleteByUser(ctx context.Context, userID primitive.ObjectID) error
+	ListByClient(ctx context.Context, clientID string) ([]model.RefreshToken, error)
+	DeleteByClient(ctx context.Context, clientID string) error
+}
+
+type RefreshTokenRepository struct {
+	collection *mongo.Collection
+}
+
+func NewRefreshTokenRepository(db *mongo.Database) *RefreshTokenRepository {
+	return &RefreshTokenRepository{
+		collection: db.Collection("refresh_tokens"),
+	}
+}
+
+func (r *RefreshTokenRepository) Save(ctx context.Context, token *model.RefreshToken) error {
+	token.CreatedAt = time.Now().Unix()
+	_, err := r.collection.InsertOne(ctx, token)
+	return err
+}
+
+// FindByHash also returns rotated and revoked tokens so callers can detect reuse.
+func (r *RefreshTokenRepository) FindByHash(ctx context.Context, tokenHash string) (*model.RefreshToken, error) {
+	var token model.RefreshToken
+	if err := r.collection.FindOne(ctx, bson.M{"token_hash": tokenHash}).Decode(&token); err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
+
+// MarkRotated reports false if the token was already rotated or revoked by a
+// concurrent request.
+func (r *RefreshTokenRepository) MarkRotated(ctx context.Context, id primitive.ObjectID) (bool, error) {
+	res, err := r.collection.UpdateOne(
+		ctx,
+		bson.M{
+			"_id":        id,
+			"rotated_at": bson.M{"$exists": false},
+			"revoked_at": bson.M{"$exists": false},
+		},
+		bson.M{"$set": bson.M{"rotated_at": time.Now().Unix()}},
+	)
+	if err != nil {
+		return false, err
+	}
+	return res.ModifiedCount == 1, nil
+}
+
+func (r *RefreshTokenRepository) RevokeBySession(ctx context.Context, sessionID primitive.ObjectID) error {
+	_, err := r.collection.UpdateMany(
+		ctx,
+		bson.M{"session_id": sessionID, "revoked_at": bson.M{"$exists": false}},
+		bson.M{"$set": bson.M{"revoked_at": time.Now().Unix()}},
+	)
+	return err
+}
+
+func (r *RefreshTokenRepository) DeleteByUser(ctx context.Context, userID primitive.ObjectID) error {
+	_, err := r.collection.DeleteMany(ctx, bson.M{"user_id": userID})
+	return err
+}
+
+// ListByClient returns every refresh token issued to a client, used or not.
+func (r *RefreshTokenRepository) ListByClient(ctx context.Context, clientID string) ([]model.RefreshToken, error) {
+	cursor, err := r.collection.Find(ctx, bson.M{"client_id": clientID})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var tokens []model.RefreshToken
+	if err := cursor.All(ctx, &tokens); err != nil {
+		return nil, err
+	}
+	return tokens, nil
+}
+
+func (r *RefreshTokenRepository) DeleteByClient(ctx context.Context, clientID string) error {
+	_, err := r.collection.DeleteMany(ctx, bson.M{"client_id": clientID})
+	return err
+}
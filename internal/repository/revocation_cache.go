@@ -0,0 +1,215 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Entries written by other instances may carry a slightly older timestamp
+const revocationSyncSkew = 5 * time.Second
+
+// RevocationCache keeps revoked token JTIs and session IDs in memory so the
+// auth interceptor doesn't query Mongo on every RPC. It is refreshed from the
+// store periodically, which also picks up revocations made by other instances.
+//
+// The cache is bounded: when full, the entries closest to expiry are evicted
+// and, until those would have expired, cache misses are confirmed in the store.
+type RevocationCache struct {
+	tokens   ITokenRepository
+	sessions ISessionRepository
+	maxSize  int
+
+	mu           sync.RWMutex
+	jtis         map[string]time.Time
+	sids         map[string]time.Time
+	evictedUntil time.Time
+	lastSync     time.Time
+	ready        bool
+}
+
+func NewRevocationCache(tokens ITokenRepository, sessions ISessionRepository, maxSize int) *RevocationCache {
+	return &RevocationCache{
+		tokens:   tokens,
+		sessions: sessions,
+		maxSize:  maxSize,
+		jtis:     make(map[string]time.Time),
+		sids:     make(map[string]time.Time),
+	}
+}
+
+func (c *RevocationCache) IsTokenRevoked(ctx context.Context, jti string) (bool, error) {
+	hit, trusted := c.lookup(c.jtis, jti)
+	if hit || trusted {
+		return hit, nil
+	}
+	return c.tokens.IsTokenBlacklisted(ctx, jti)
+}
+
+// ClientRevocationKey is the blacklist entry that revokes every access token
+// issued to a deleted client. Token JTIs are UUIDs, so it can't collide.
+func ClientRevocationKey(clientID string) string {
+	return "client:" + clientID
+}
+
+// IsClientRevoked reports whether the client a token was issued to was
+// deleted.
+func (c *RevocationCache) IsClientRevoked(ctx context.Context, clientID string) (bool, error) {
+	return c.IsTokenRevoked(ctx, ClientRevocationKey(clientID))
+}
+
+func (c *RevocationCache) IsSessionRevoked(ctx context.Context, sid string) (bool, error) {
+	hit, trusted := c.lookup(c.sids, sid)
+	if hit || trusted {
+		return hit, nil
+	}
+
+	oid, err := primitive.ObjectIDFromHex(sid)
+	if err != nil {
+		return true, nil
+	}
+	_, err = c.sessions.FindActiveByID(ctx, oid)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return true, nil
+	}
+	return false, err
+}
+
+// lookup reports whether key is cached as revoked and whether a miss can be
+// trusted without asking the store.
+func (c *RevocationCache) lookup(entries map[string]time.Time, key string) (hit, trusted bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	now := time.Now()
+	if exp, ok := entries[key]; ok && exp.After(now) {
+		return true, true
+	}
+	return false, c.ready && !now.Before(c.evictedUntil)
+}
+
+func (c *RevocationCache) AddToken(jti string, expiresAt time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.add(c.jtis, jti, expiresAt)
+}
+
+func (c *RevocationCache) AddSession(sid string, expiresAt time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.add(c.sids, sid, expiresAt)
+}
+
+// add must be called with c.mu held.
+func (c *RevocationCache) add(entries map[string]time.Time, key string, expiresAt time.Time) {
+	if _, ok := entries[key]; !ok && len(c.jtis)+len(c.sids) >= c.maxSize {
+		c.evict()
+	}
+	entries[key] = expiresAt
+}
+
+// evict drops expired entries, or the one closest to expiry if none expired.
+// It must be called with c.mu held.
+func (c *RevocationCache) evict() {
+	now := time.Now()
+	removed := false
+	for _, entries := range []map[string]time.Time{c.jtis, c.sids} {
+		for k, exp := range entries {
+			if !exp.After(now) {
+				delete(entries, k)
+				removed = true
+			}
+		}
+	}
+	if removed {
+		return
+	}
+
+	var oldestMap map[string]time.Time
+	var oldestKey string
+	var oldestExp time.Time
+	for _, entries := range []map[string]time.Time{c.jtis, c.sids} {
+		for k, exp := range entries {
+			if oldestMap == nil || exp.Before(oldestExp) {
+				oldestMap, oldestKey, oldestExp = entries, k, exp
+			}
+		}
+	}
+	if oldestMap == nil {
+		return
+	}
+	delete(oldestMap, oldestKey)
+	c.markIncomplete(oldestExp)
+}
+
+// markIncomplete must be called with c.mu held.
+func (c *RevocationCache) markIncomplete(until time.Time) {
+	if until.After(c.evictedUntil) {
+		c.evictedUntil = until
+	}
+}
+
+// Refresh loads revocations recorded since the previous refresh.
+func (c *RevocationCache) Refresh(ctx context.Context) error {
+	c.mu.RLock()
+	since := c.lastSync
+	c.mu.RUnlock()
+	if !since.IsZero() {
+		since = since.Add(-revocationSyncSkew)
+	}
+	startedAt := time.Now()
+
+	tokens, err := c.tokens.FindBlacklistedSince(ctx, since, int64(c.maxSize))
+	if err != nil {
+		return err
+	}
+	sessions, err := c.sessions.FindRevokedSince(ctx, since.Unix(), int64(c.maxSize))
+	if err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	// A full page means older entries were left in the store; they expire no
+	// later than the last one returned
+	if len(tokens) == c.maxSize {
+		c.markIncomplete(tokens[len(tokens)-1].ExpiresAt)
+	}
+	if len(sessions) == c.maxSize {
+		c.markIncomplete(time.Unix(sessions[len(sessions)-1].ExpiresAt, 0))
+	}
+
+	for _, t := range tokens {
+		c.add(c.jtis, t.JTI, t.ExpiresAt)
+	}
+	for _, s := range sessions {
+		c.add(c.sids, s.ID.Hex(), time.Unix(s.ExpiresAt, 0))
+	}
+	c.lastSync = startedAt
+	c.ready = true
+	return nil
+}
+
+// Run refreshes the cache every interval until ctx is done.
+func (c *RevocationCache) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := c.Refresh(ctx); err != nil {
+			log.Printf("revocation cache refresh failed: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,203 @@
+package repository
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/bekbek22/auth_service/internal/model"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// fakeBlacklist is the token store behind the cache. It counts lookups so
+// tests can tell whether a miss was trusted.
+type fakeBlacklist struct {
+	ITokenRepository
+
+	tokens  []model.BlacklistedToken
+	lookups int
+}
+
+func (f *fakeBlacklist) IsTokenBlacklisted(ctx context.Context, jti string) (bool, error) {
+	f.lookups++
+	return slices.ContainsFunc(f.tokens, func(t model.BlacklistedToken) bool { return t.JTI == jti }), nil
+}
+
+// FindBlacklistedSince returns the latest-expiring tokens first, like the
+// Mongo query.
+func (f *fakeBlacklist) FindBlacklistedSince(ctx context.Context, since time.Time, limit int64) ([]model.BlacklistedToken, error) {
+	tokens := slices.Clone(f.tokens)
+	slices.SortFunc(tokens, func(a, b model.BlacklistedToken) int { return b.ExpiresAt.Compare(a.ExpiresAt) })
+	if len(tokens) > int(limit) {
+		tokens = tokens[:limit]
+	}
+	return tokens, nil
+}
+
+// fakeSessions knows no active sessions.
+type fakeSessions struct {
+	ISessionRepository
+
+	lookups int
+}
+
+func (f *fakeSessions) FindActiveByID(ctx context.Context, id primitive.ObjectID) (*model.Session, error) {
+	f.lookups++
+	return nil, mongo.ErrNoDocuments
+}
+
+func (f *fakeSessions) FindRevokedSince(ctx context.Context, since int64, limit int64) ([]model.Session, error) {
+	return nil, nil
+}
+
+func TestRevocationCacheLookup(t *testing.T) {
+	ctx := context.Background()
+	tests := []struct {
+		name        string
+		refresh     bool
+		cached      []string
+		stored      []string
+		jti         string
+		want        bool
+		wantLookups int
+	}{
+		{name: "cached", refresh: true, cached: []string{"a"}, jti: "a", want: true},
+		{name: "trusted miss", refresh: true, stored: []string{"b"}, jti: "b", want: false},
+		{name: "miss before first refresh", stored: []string{"b"}, jti: "b", want: true, wantLookups: 1},
+		{name: "cached before first refresh", cached: []string{"a"}, jti: "a", want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := &fakeBlacklist{}
+			c := NewRevocationCache(store, &fakeSessions{}, 10)
+			if tt.refresh {
+				if err := c.Refresh(ctx); err != nil {
+					t.Fatal(err)
+				}
+			}
+			for _, jti := range tt.cached {
+				c.AddToken(jti, time.Now().Add(time.Hour))
+			}
+			// Stored after the refresh, so only a lookup can find them
+			for _, jti := range tt.stored {
+				store.tokens = append(store.tokens, model.BlacklistedToken{JTI: jti, ExpiresAt: time.Now().Add(time.Hour)})
+			}
+
+			got, err := c.IsTokenRevoked(ctx, tt.jti)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want || store.lookups != tt.wantLookups {
+				t.Fatalf("got revoked %v with %d lookups, want %v with %d", got, store.lookups, tt.want, tt.wantLookups)
+			}
+		})
+	}
+}
+
+func TestRevocationCacheEviction(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	tests := []struct {
+		name             string
+		entries          map[string]time.Time // added as a, b, c
+		wantKept         []string
+		wantEvictedUntil time.Time
+	}{
+		{
+			name:             "closest to expiry evicted",
+			entries:          map[string]time.Time{"a": now.Add(2 * time.Hour), "b": now.Add(time.Hour), "c": now.Add(3 * time.Hour)},
+			wantKept:         []string{"a", "c"},
+			wantEvictedUntil: now.Add(time.Hour),
+		},
+		{
+			name:     "expired entries evicted first",
+			entries:  map[string]time.Time{"a": now.Add(2 * time.Hour), "b": now.Add(-time.Minute), "c": now.Add(3 * time.Hour)},
+			wantKept: []string{"a", "c"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewRevocationCache(&fakeBlacklist{}, &fakeSessions{}, 2)
+			if err := c.Refresh(ctx); err != nil {
+				t.Fatal(err)
+			}
+			for _, jti := range []string{"a", "b", "c"} {
+				c.AddToken(jti, tt.entries[jti])
+			}
+
+			var kept []string
+			for jti := range c.jtis {
+				kept = append(kept, jti)
+			}
+			slices.Sort(kept)
+			if !slices.Equal(kept, tt.wantKept) {
+				t.Fatalf("kept %v, want %v", kept, tt.wantKept)
+			}
+			if !c.evictedUntil.Equal(tt.wantEvictedUntil) {
+				t.Fatalf("evictedUntil %v, want %v", c.evictedUntil, tt.wantEvictedUntil)
+			}
+		})
+	}
+}
+
+func TestRevocationCacheEvictedUntilFallback(t *testing.T) {
+	ctx := context.Background()
+	store := &fakeBlacklist{}
+	sessions := &fakeSessions{}
+	c := NewRevocationCache(store, sessions, 1)
+	if err := c.Refresh(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	// Adding b evicts a, which is still blacklisted in the store
+	store.tokens = []model.BlacklistedToken{{JTI: "a", ExpiresAt: time.Now().Add(time.Hour)}}
+	c.AddToken("a", time.Now().Add(time.Hour))
+	c.AddToken("b", time.Now().Add(2*time.Hour))
+
+	revoked, err := c.IsTokenRevoked(ctx, "a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !revoked || store.lookups != 1 {
+		t.Fatalf("got revoked %v with %d lookups, want the store to confirm it", revoked, store.lookups)
+	}
+	sid := primitive.NewObjectID().Hex()
+	if revoked, _ := c.IsSessionRevoked(ctx, sid); !revoked || sessions.lookups != 1 {
+		t.Fatalf("got session revoked %v with %d lookups, want the store to be asked", revoked, sessions.lookups)
+	}
+
+	// Once everything evicted would have expired, misses are trusted again
+	c.evictedUntil = time.Now().Add(-time.Second)
+	if revoked, _ := c.IsTokenRevoked(ctx, "a"); revoked || store.lookups != 1 {
+		t.Fatalf("got revoked %v with %d lookups, want a trusted miss", revoked, store.lookups)
+	}
+}
+
+func TestRevocationCacheRefreshFullPage(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	store := &fakeBlacklist{tokens: []model.BlacklistedToken{
+		{JTI: "a", ExpiresAt: now.Add(time.Hour)},
+		{JTI: "b", ExpiresAt: now.Add(2 * time.Hour)},
+		{JTI: "c", ExpiresAt: now.Add(3 * time.Hour)},
+	}}
+	c := NewRevocationCache(store, &fakeSessions{}, 2)
+	if err := c.Refresh(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	// b and c fit; a was left in the store, so misses aren't trusted until
+	// it expires
+	if !c.evictedUntil.Equal(now.Add(2 * time.Hour)) {
+		t.Fatalf("evictedUntil %v, want the expiry of the last token loaded", c.evictedUntil)
+	}
+	revoked, err := c.IsTokenRevoked(ctx, "a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !revoked || store.lookups != 1 {
+		t.Fatalf("got revoked %v with %d lookups, want the store to confirm it", revoked, store.lookups)
+	}
+}
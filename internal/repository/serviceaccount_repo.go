@@ -0,0 +1,60 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/bekbek22/auth_service/internal/model"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type IServiceAccountRepository interface {
+	Create(ctx context.Context, account *model.ServiceAccount) error
+	FindByID(ctx context.Context, id primitive.ObjectID) (*model.ServiceAccount, error)
+	List(ctx context.Context) ([]model.ServiceAccount, error)
+}
+
+type ServiceAccountRepository struct {
+	collection *mongo.Collection
+}
+
+func NewServiceAccountRepository(db *mongo.Database) *ServiceAccountRepository {
+	return &ServiceAccountRepository{
+		collection: db.Collection("service_accounts"),
+	}
+}
+
+func (r *ServiceAccountRepository) Create(ctx context.Context, account *model.ServiceAccount) error {
+	account.ID = primitive.NewObjectID()
+	account.CreatedAt = time.Now().Unix()
+	_, err := r.collection.InsertOne(ctx, account)
+	return err
+}
+
+func (r *ServiceAccountRepository) FindByID(ctx context.Context, id primitive.ObjectID) (*model.ServiceAccount, error) {
+	var account model.ServiceAccount
+	err := r.collection.FindOne(ctx, bson.M{"_id": id}).Decode(&account)
+	if err != nil {
+		return nil, err
+	}
+	return &account, nil
+}
+
+func (r *ServiceAccountRepository) List(ctx context.Context) ([]model.ServiceAccount, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}})
+	cursor, err := r.collection.Find(ctx, bson.M{}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var accounts []model.ServiceAccount
+	if err := cursor.All(ctx, &accounts); err != nil {
+		return nil, err
+	}
+	return accounts, nil
+}
@@ -0,0 +1,146 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/bekbek22/auth_service/internal/model"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type ISessionRepository interface {
+	Create(ctx context.Context, session *model.Session) error
+	FindActiveByID(ctx context.Context, id primitive.ObjectID) (*model.Session, error)
+	ListActiveByUser(ctx context.Context, userID primitive.ObjectID) ([]model.Session, error)
+	ListByUser(ctx context.Context, userID primitive.ObjectID) ([]model.Session, error)
+	Touch(ctx context.Context, id primitive.ObjectID, lastSeen int64) error
+	Revoke(ctx context.Context, id, userID primitive.ObjectID) error
+	RevokeAllByUser(ctx context.Context, userID primitive.ObjectID) error
+	DeleteByUser(ctx context.Context, userID primitive.ObjectID) error
+	FindRevokedSince(ctx context.Context, since int64, limit int64) ([]model.Session, error)
+}
+
+type SessionRepository struct {
+	collection *mongo.Collection
+}
+
+func NewSessionRepository(db *mongo.Database) *SessionRepository {
+	return &SessionRepository{
+		collection: db.Collection("sessions"),
+	}
+}
+
+func activeSessionFilter() bson.M {
+	return bson.M{
+		"revoked_at": bson.M{"$exists": false},
+		"expires_at": bson.M{"$gt": time.Now().Unix()},
+	}
+}
+
+func (r *SessionRepository) Create(ctx context.Context, session *model.Session) error {
+	now := time.Now().Unix()
+	session.ID = primitive.NewObjectID()
+	session.CreatedAt = now
+	session.LastSeenAt = now
+	_, err := r.collection.InsertOne(ctx, session)
+	return err
+}
+
+func (r *SessionRepository) FindActiveByID(ctx context.Context, id primitive.ObjectID) (*model.Session, error) {
+	filter := activeSessionFilter()
+	filter["_id"] = id
+
+	var session model.Session
+	if err := r.collection.FindOne(ctx, filter).Decode(&session); err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+func (r *SessionRepository) ListActiveByUser(ctx context.Context, userID primitive.ObjectID) ([]model.Session, error) {
+	filter := activeSessionFilter()
+	filter["user_id"] = userID
+	return r.find(ctx, filter)
+}
+
+// ListByUser includes revoked and expired sessions.
+func (r *SessionRepository) ListByUser(ctx context.Context, userID primitive.ObjectID) ([]model.Session, error) {
+	return r.find(ctx, bson.M{"user_id": userID})
+}
+
+func (r *SessionRepository) find(ctx context.Context, filter bson.M) ([]model.Session, error) {
+	opts := options.Find().SetSort(bson.M{"last_seen_at": -1})
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var sessions []model.Session
+	if err := cursor.All(ctx, &sessions); err != nil {
+		return nil, err
+	}
+	return sessions, nil
+}
+
+func (r *SessionRepository) Touch(ctx context.Context, id primitive.ObjectID, lastSeen int64) error {
+	_, err := r.collection.UpdateOne(
+		ctx,
+		bson.M{"_id": id},
+		bson.M{"$set": bson.M{"last_seen_at": lastSeen}},
+	)
+	return err
+}
+
+// Revoke only matches sessions owned by userID so users can't revoke others'.
+func (r *SessionRepository) Revoke(ctx context.Context, id, userID primitive.ObjectID) error {
+	filter := activeSessionFilter()
+	filter["_id"] = id
+	filter["user_id"] = userID
+
+	res, err := r.collection.UpdateOne(ctx, filter, bson.M{"$set": bson.M{"revoked_at": time.Now().Unix()}})
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return errors.New("session not found")
+	}
+	return nil
+}
+
+func (r *SessionRepository) RevokeAllByUser(ctx context.Context, userID primitive.ObjectID) error {
+	filter := activeSessionFilter()
+	filter["user_id"] = userID
+
+	_, err := r.collection.UpdateMany(ctx, filter, bson.M{"$set": bson.M{"revoked_at": time.Now().Unix()}})
+	return err
+}
+
+func (r *SessionRepository) DeleteByUser(ctx context.Context, userID primitive.ObjectID) error {
+	_, err := r.collection.DeleteMany(ctx, bson.M{"user_id": userID})
+	return err
+}
+
+// FindRevokedSince returns unexpired sessions revoked at or after since.
+func (r *SessionRepository) FindRevokedSince(ctx context.Context, since int64, limit int64) ([]model.Session, error) {
+	opts := options.Find().SetSort(bson.M{"expires_at": -1}).SetLimit(limit)
+	cursor, err := r.collection.Find(ctx, bson.M{
+		"revoked_at": bson.M{"$gte": since},
+		"expires_at": bson.M{"$gt": time.Now().Unix()},
+	}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var sessions []model.Session
+	if err := cursor.All(ctx, &sessions); err != nil {
+		return nil, err
+	}
+	return sessions, nil
+}
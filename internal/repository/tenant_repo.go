@@ -0,0 +1,111 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/bekbek22/auth_service/internal/model"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type ITenantRepository interface {
+	Create(ctx context.Context, tenant *model.Tenant) error
+	FindByID(ctx context.Context, id primitive.ObjectID) (*model.Tenant, error)
+	FindBySlug(ctx context.Context, slug string) (*model.Tenant, error)
+	List(ctx context.Context, page, limit int32) ([]model.Tenant, int32, error)
+	Update(ctx context.Context, id primitive.ObjectID, updates bson.M) error
+	Delete(ctx context.Context, id primitive.ObjectID) error
+}
+
+type TenantRepository struct {
+	collection *mongo.Collection
+}
+
+func NewTenantRepository(db *mongo.Database) *TenantRepository {
+	return &TenantRepository{
+		collection: db.Collection("tenants"),
+	}
+}
+
+var errTenantNotFound = errors.New("tenant not found")
+
+// EnsureIndexes keeps slugs unique, since they are how requests name a tenant.
+func (r *TenantRepository) EnsureIndexes(ctx context.Context) error {
+	_, err := r.collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "slug", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	return err
+}
+
+func (r *TenantRepository) Create(ctx context.Context, tenant *model.Tenant) error {
+	now := time.Now().Unix()
+	tenant.ID = primitive.NewObjectID()
+	tenant.CreatedAt = now
+	tenant.UpdatedAt = now
+	_, err := r.collection.InsertOne(ctx, tenant)
+	return err
+}
+
+func (r *TenantRepository) FindByID(ctx context.Context, id primitive.ObjectID) (*model.Tenant, error) {
+	var tenant model.Tenant
+	if err := r.collection.FindOne(ctx, bson.M{"_id": id}).Decode(&tenant); err != nil {
+		return nil, err
+	}
+	return &tenant, nil
+}
+
+func (r *TenantRepository) FindBySlug(ctx context.Context, slug string) (*model.Tenant, error) {
+	var tenant model.Tenant
+	if err := r.collection.FindOne(ctx, bson.M{"slug": slug}).Decode(&tenant); err != nil {
+		return nil, err
+	}
+	return &tenant, nil
+}
+
+func (r *TenantRepository) List(ctx context.Context, page, limit int32) ([]model.Tenant, int32, error) {
+	skip := int64((page - 1) * limit)
+	opts := options.Find().SetSkip(skip).SetLimit(int64(limit)).SetSort(bson.M{"created_at": 1})
+
+	cursor, err := r.collection.Find(ctx, bson.M{}, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer cursor.Close(ctx)
+
+	var tenants []model.Tenant
+	if err := cursor.All(ctx, &tenants); err != nil {
+		return nil, 0, err
+	}
+
+	count, _ := r.collection.CountDocuments(ctx, bson.M{})
+	return tenants, int32(count), nil
+}
+
+func (r *TenantRepository) Update(ctx context.Context, id primitive.ObjectID, updates bson.M) error {
+	updates["updated_at"] = time.Now().Unix()
+	res, err := r.collection.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": updates})
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return errTenantNotFound
+	}
+	return nil
+}
+
+func (r *TenantRepository) Delete(ctx context.Context, id primitive.ObjectID) error {
+	res, err := r.collection.DeleteOne(ctx, bson.M{"_id": id})
+	if err != nil {
+		return err
+	}
+	if res.DeletedCount == 0 {
+		return errTenantNotFound
+	}
+	return nil
+}
@@ -2,13 +2,19 @@ package repository
 
 import (
 	"context"
+	"time"
 
+	"github.com/bekbek22/auth_service/internal/model"
+
+	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 type ITokenRepository interface {
-	BlacklistToken(ctx context.Context, token string, exp int64) error
-	IsTokenBlacklisted(ctx context.Context, token string) (bool, error)
+	BlacklistToken(ctx context.Context, jti string, expiresAt time.Time) error
+	IsTokenBlacklisted(ctx context.Context, jti string) (bool, error)
+	FindBlacklistedSince(ctx context.Context, since time.Time, limit int64) ([]model.BlacklistedToken, error)
 }
 type TokenRepository struct {
 	collection *mongo.Collection
@@ -20,18 +26,55 @@ func NewTokenRepository(db *mongo.Database) *TokenRepository {
 	}
 }
 
-func (r *TokenRepository) BlacklistToken(ctx context.Context, token string, exp int64) error {
-	doc := map[string]interface{}{
-		"token": token,
-		"exp":   exp,
-	}
-	_, err := r.collection.InsertOne(ctx, doc)
+// EnsureIndexes makes jti unique and lets Mongo drop entries once the token
+// they revoke has expired anyway.
+func (r *TokenRepository) EnsureIndexes(ctx context.Context) error {
+	_, err := r.collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "jti", Value: 1}}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.D{{Key: "expires_at", Value: 1}}, Options: options.Index().SetExpireAfterSeconds(0)},
+		{Keys: bson.D{{Key: "blacklisted_at", Value: 1}}},
+	})
+	return err
+}
+
+func (r *TokenRepository) BlacklistToken(ctx context.Context, jti string, expiresAt time.Time) error {
+	_, err := r.collection.UpdateOne(
+		ctx,
+		bson.M{"jti": jti},
+		bson.M{"$setOnInsert": model.BlacklistedToken{
+			JTI:           jti,
+			ExpiresAt:     expiresAt,
+			BlacklistedAt: time.Now(),
+		}},
+		options.Update().SetUpsert(true),
+	)
 	return err
 }
 
-func (r *TokenRepository) IsTokenBlacklisted(ctx context.Context, token string) (bool, error) {
-	count, err := r.collection.CountDocuments(ctx, map[string]interface{}{
-		"token": token,
+func (r *TokenRepository) IsTokenBlacklisted(ctx context.Context, jti string) (bool, error) {
+	count, err := r.collection.CountDocuments(ctx, bson.M{
+		"jti":        jti,
+		"expires_at": bson.M{"$gt": time.Now()},
 	})
 	return count > 0, err
 }
+
+// FindBlacklistedSince returns still-relevant entries added at or after since,
+// newest expiry first.
+func (r *TokenRepository) FindBlacklistedSince(ctx context.Context, since time.Time, limit int64) ([]model.BlacklistedToken, error) {
+	opts := options.Find().SetSort(bson.M{"expires_at": -1}).SetLimit(limit)
+	cursor, err := r.collection.Find(ctx, bson.M{
+		"blacklisted_at": bson.M{"$gte": since},
+		"expires_at":     bson.M{"$gt": time.Now()},
+	}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var tokens []model.BlacklistedToken
+	if err := cursor.All(ctx, &tokens); err != nil {
+		return nil, err
+	}
+	return tokens, nil
+}
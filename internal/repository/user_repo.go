@@ -13,11 +13,16 @@ import (
 )
 
 type IUserRepository interface {
-	FindByEmail(ctx context.Context, email string) (*model.User, error)
+	FindByEmail(ctx context.Context, tenantID, email string) (*model.User, error)
+	FindUsers(ctx context.Context, tenantID, name, email string, page, limit int32) ([]model.User, int32, error)
+	CountByTenant(ctx context.Context, tenantID string) (int64, error)
 	CreateUser(ctx context.Context, user *model.User) error
 	FindByID(ctx context.Context, id primitive.ObjectID) (*model.User, error)
-	UpdateUserByID(ctx context.Context, id primitive.ObjectID, update interface{}) error
+	UpdateUserByID(ctx context.Context, id primitive.ObjectID, updates bson.M) error
 	SoftDeleteUserByID(ctx context.Context, id primitive.ObjectID) error
+	FindDeletedBefore(ctx context.Context, before int64, limit int64) ([]model.User, error)
+	AnonymizeUserByID(ctx context.Context, id primitive.ObjectID) error
+	HardDeleteUserByID(ctx context.Context, id primitive.ObjectID) error
 }
 type UserRepository struct {
 	collection *mongo.Collection
@@ -29,15 +34,43 @@ func NewUserRepository(db *mongo.Database) *UserRepository {
 	}
 }
 
+// tenantFilter matches documents of tenantID. Documents of the default
+// tenant have no tenant_id, which a nil filter matches.
+func tenantFilter(tenantID string) interface{} {
+	if tenantID == "" {
+		return nil
+	}
+	return tenantID
+}
+
+// EnsureIndexes makes emails unique within a tenant. Deleted users keep their
+// email until anonymized, so they are left out.
+func (r *UserRepository) EnsureIndexes(ctx context.Context) error {
+	_, err := r.collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "tenant_id", Value: 1}, {Key: "email", Value: 1}},
+		Options: options.Index().
+			SetUnique(true).
+			SetPartialFilterExpression(bson.M{"is_deleted": false}),
+	})
+	return err
+}
+
 func (r *UserRepository) CreateUser(ctx context.Context, user *model.User) error {
 	user.CreatedAt = time.Now().Unix()
-	_, err := r.collection.InsertOne(ctx, user)
-	return err
+	res, err := r.collection.InsertOne(ctx, user)
+	if err != nil {
+		return err
+	}
+	if oid, ok := res.InsertedID.(primitive.ObjectID); ok {
+		user.ID = oid
+	}
+	return nil
 }
 
-func (r *UserRepository) FindByEmail(ctx context.Context, email string) (*model.User, error) {
+func (r *UserRepository) FindByEmail(ctx context.Context, tenantID, email string) (*model.User, error) {
 	var user model.User
 	err := r.collection.FindOne(ctx, bson.M{
+		"tenant_id":  tenantFilter(tenantID),
 		"email":      email,
 		"is_deleted": bson.M{"$ne": true},
 	}).Decode(&user)
@@ -47,8 +80,9 @@ func (r *UserRepository) FindByEmail(ctx context.Context, email string) (*model.
 	return &user, nil
 }
 
-func (r *UserRepository) FindUsers(ctx context.Context, name, email string, page, limit int32) ([]model.User, int32, error) {
+func (r *UserRepository) FindUsers(ctx context.Context, tenantID, name, email string, page, limit int32) ([]model.User, int32, error) {
 	filter := bson.M{
+		"tenant_id":  tenantFilter(tenantID),
 		"is_deleted": bson.M{"$ne": true},
 	}
 	if name != "" {
@@ -77,6 +111,13 @@ func (r *UserRepository) FindUsers(ctx context.Context, name, email string, page
 	return users, int32(count), nil
 }
 
+func (r *UserRepository) CountByTenant(ctx context.Context, tenantID string) (int64, error) {
+	return r.collection.CountDocuments(ctx, bson.M{
+		"tenant_id":  tenantFilter(tenantID),
+		"is_deleted": bson.M{"$ne": true},
+	})
+}
+
 func (r *UserRepository) FindByID(ctx context.Context, id primitive.ObjectID) (*model.User, error) {
 	var user model.User
 	err := r.collection.FindOne(ctx, bson.M{
@@ -104,7 +145,59 @@ func (r *UserRepository) SoftDeleteUserByID(ctx context.Context, id primitive.Ob
 	_, err := r.collection.UpdateOne(
 		ctx,
 		bson.M{"_id": id},
-		bson.M{"$set": bson.M{"is_deleted": true}},
+		bson.M{"$set": bson.M{
+			"is_deleted": true,
+			"deleted_at": time.Now().Unix(),
+		}},
 	)
 	return err
 }
+
+// FindDeletedBefore returns soft-deleted users past the retention window that
+// still hold personal data. Users deleted before deleted_at was recorded are
+// treated as past the window.
+func (r *UserRepository) FindDeletedBefore(ctx context.Context, before int64, limit int64) ([]model.User, error) {
+	filter := bson.M{
+		"is_deleted": true,
+		"$or": bson.A{
+			bson.M{"deleted_at": bson.M{"$lte": before}},
+			bson.M{"deleted_at": bson.M{"$exists": false}},
+		},
+		"anonymized_at": bson.M{"$exists": false},
+	}
+	cursor, err := r.collection.Find(ctx, filter, options.Find().SetLimit(limit))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var users []model.User
+	if err := cursor.All(ctx, &users); err != nil {
+		return nil, err
+	}
+	return users, nil
+}
+
+// AnonymizeUserByID keeps the document for referential history but removes
+// every personal field from it.
+func (r *UserRepository) AnonymizeUserByID(ctx context.Context, id primitive.ObjectID) error {
+	_, err := r.collection.UpdateOne(
+		ctx,
+		bson.M{"_id": id, "is_deleted": true},
+		bson.M{
+			"$set": bson.M{
+				"name":          "",
+				"email":         "deleted-" + id.Hex() + "@invalid",
+				"password":      "",
+				"otp_factor":    nil,
+				"anonymized_at": time.Now().Unix(),
+			},
+		},
+	)
+	return err
+}
+
+func (r *UserRepository) HardDeleteUserByID(ctx context.Context, id primitive.ObjectID) error {
+	_, err := r.collection.DeleteOne(ctx, bson.M{"_id": id, "is_deleted": true})
+	return err
+}
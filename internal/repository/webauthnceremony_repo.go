@@ -0,0 +1,45 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/bekbek22/auth_service/internal/model"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type IWebAuthnCeremonyRepository interface {
+	Save(ctx context.Context, ceremony *model.WebAuthnCeremony) error
+	Consume(ctx context.Context, ceremonyHash, purpose string) (*model.WebAuthnCeremony, error)
+}
+
+type WebAuthnCeremonyRepository struct {
+	collection *mongo.Collection
+}
+
+func NewWebAuthnCeremonyRepository(db *mongo.Database) *WebAuthnCeremonyRepository {
+	return &WebAuthnCeremonyRepository{
+		collection: db.Collection("webauthn_ceremonies"),
+	}
+}
+
+func (r *WebAuthnCeremonyRepository) Save(ctx context.Context, ceremony *model.WebAuthnCeremony) error {
+	_, err := r.collection.InsertOne(ctx, ceremony)
+	return err
+}
+
+// Consume deletes and returns an unexpired ceremony so a challenge is only answered once.
+func (r *WebAuthnCeremonyRepository) Consume(ctx context.Context, ceremonyHash, purpose string) (*model.WebAuthnCeremony, error) {
+	var ceremony model.WebAuthnCeremony
+	err := r.collection.FindOneAndDelete(ctx, bson.M{
+		"ceremony_hash": ceremonyHash,
+		"purpose":       purpose,
+		"expires_at":    bson.M{"$gt": time.Now().Unix()},
+	}).Decode(&ceremony)
+	if err != nil {
+		return nil, err
+	}
+	return &ceremony, nil
+}
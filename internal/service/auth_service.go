@@ -3,15 +3,19 @@ package service
 import (
 	"context"
 	"errors"
+	"fmt"
 	"regexp"
 	"strings"
 	"time"
 
+	"github.com/bekbek22/auth_service/internal/audit"
 	"github.com/bekbek22/auth_service/internal/middleware"
 	"github.com/bekbek22/auth_service/internal/model"
+	"github.com/bekbek22/auth_service/internal/notifier"
+	"github.com/bekbek22/auth_service/internal/policy"
 	"github.com/bekbek22/auth_service/internal/repository"
 	"github.com/bekbek22/auth_service/internal/utils"
-	"github.com/golang-jwt/jwt/v5"
+	"github.com/go-webauthn/webauthn/webauthn"
 	"github.com/google/uuid"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
@@ -21,32 +25,183 @@ import (
 
 type IAuthService interface {
 	Register(ctx context.Context, name, email, password string) error
-	Login(ctx context.Context, email, password string) (string, error)
+	Login(ctx context.Context, email, password, deviceName, clientID string, scopes []string) (*LoginResult, error)
 	Logout(ctx context.Context, token string) error
-	ListUsers(ctx context.Context, name, email string, page, limit int32) ([]model.User, int32, error)
+	ListUsers(ctx context.Context, tenantID, name, email string, page, limit int32) ([]model.User, int32, error)
 	GetProfile(ctx context.Context, userID string) (*model.User, error)
-	UpdateProfile(ctx context.Context, userID, name, email string) error
+	UpdateProfile(ctx context.Context, userID, name, email string) (bool, error)
+	ConfirmEmailChange(ctx context.Context, token string) error
+	RevertEmailChange(ctx context.Context, token string) error
 	DeleteProfile(ctx context.Context, userID string) error
+	ExportMyData(ctx context.Context, userID string) ([]byte, error)
+	PurgeDeletedUsers(ctx context.Context) (int, error)
 	RequestPasswordReset(ctx context.Context, email string) (string, error)
 	ResetPassword(ctx context.Context, token, newPassword string) error
+	ListAuditEvents(ctx context.Context, filter repository.AuditFilter, cursor string, limit int64) ([]model.AuditEvent, string, error)
+	VerifyToken(ctx context.Context, token string, claims *utils.AuthClaims) error
+	ListMySessions(ctx context.Context, userID string) ([]model.Session, error)
+	RevokeSession(ctx context.Context, userID, sessionID string) error
+	CreateClient(ctx context.Context, actorID string, in ClientInput, confidential bool) (*model.Client, string, error)
+	GetClient(ctx context.Context, clientID string) (*model.Client, error)
+	ListClients(ctx context.Context, page, limit int32) ([]model.Client, int32, error)
+	UpdateClient(ctx context.Context, actorID, clientID string, in ClientInput) (*model.Client, error)
+	DeleteClient(ctx context.Context, actorID, clientID string) error
+	RotateClientSecret(ctx context.Context, actorID, clientID string) (string, error)
+	LoginWithExternalIdentity(ctx context.Context, provider, tenant, subject, email string, emailVerified bool, name, deviceName string) (*LoginResult, error)
+	LinkExternalIdentity(ctx context.Context, userID primitive.ObjectID, provider, tenant, subject, email string) error
+	ListLoginMethods(ctx context.Context, userID string) ([]LoginMethod, error)
+	SetPassword(ctx context.Context, userID, password string) error
+	StartIdentityLink(ctx context.Context, userID, provider string) (string, error)
+	UnlinkLoginMethod(ctx context.Context, userID, methodID string) error
+	RequiresSecondFactor(ctx context.Context, userID primitive.ObjectID) (bool, error)
+	BeginPasskeyRegistration(ctx context.Context, userID string) (string, []byte, error)
+	FinishPasskeyRegistration(ctx context.Context, userID, ceremonyID string, credentialJSON []byte, name string) (*model.Identity, error)
+	BeginPasskeyLogin(ctx context.Context, email, mfaToken string) (string, []byte, error)
+	FinishPasskeyLogin(ctx context.Context, ceremonyID string, credentialJSON []byte, mfaToken, deviceName, clientID string) (*LoginResult, error)
+	RequestMagicLink(ctx context.Context, email string) error
+	ConsumeMagicLink(ctx context.Context, token, deviceName, clientID string) (*LoginResult, error)
+	EnrollOTP(ctx context.Context, userID, channel, phoneNumber string) error
+	ConfirmOTPEnrollment(ctx context.Context, userID, code string) error
+	DisableOTP(ctx context.Context, userID string) error
+	SendLoginOTP(ctx context.Context, mfaToken string) (string, error)
+	VerifyLoginOTP(ctx context.Context, mfaToken, code string) (*LoginResult, error)
+	CreateServiceAccount(ctx context.Context, actorID, name, description string, scopes []string) (*model.ServiceAccount, error)
+	ListServiceAccounts(ctx context.Context) ([]model.ServiceAccount, error)
+	CreateAPIKey(ctx context.Context, actorID, accountID, name string, scopes []string, expiresInDays int32) (*model.APIKey, string, error)
+	ListAPIKeys(ctx context.Context, accountID string) ([]model.APIKey, error)
+	RotateAPIKey(ctx context.Context, actorID, keyID string) (*model.APIKey, string, error)
+	RevokeAPIKey(ctx context.Context, actorID, keyID string) error
+	AuthenticateAPIKey(ctx context.Context, plaintext string) (*utils.AuthClaims, error)
+	CreatePersonalAccessToken(ctx context.Context, userID, name string, scopes []string, expiresInDays int32) (*model.PersonalAccessToken, string, error)
+	ListPersonalAccessTokens(ctx context.Context, userID string) ([]model.PersonalAccessToken, error)
+	RevokePersonalAccessToken(ctx context.Context, userID, tokenID string) error
+	AuthenticateKey(ctx context.Context, plaintext string) (*utils.AuthClaims, error)
+	IntrospectToken(ctx context.Context, token string) (*TokenInfo, error)
+	ImpersonateUser(ctx context.Context, adminID, adminTenantID, userID, reason string) (*LoginResult, error)
+	RecordImpersonatedCall(ctx context.Context, claims *utils.AuthClaims, method string, allowed bool)
+	CreateTenant(ctx context.Context, actorID, name, slug string) (*model.Tenant, error)
+	GetTenant(ctx context.Context, tenantID string) (*model.Tenant, error)
+	ListTenants(ctx context.Context, page, limit int32) ([]model.Tenant, int32, error)
+	UpdateTenant(ctx context.Context, actorID, tenantID, name string) (*model.Tenant, error)
+	DeleteTenant(ctx context.Context, actorID, tenantID string) error
+	CreateOrganization(ctx context.Context, userID, name string) (*model.Organization, error)
+	ListMyOrganizations(ctx context.Context, userID string) ([]OrgMembership, error)
+	DeleteOrganization(ctx context.Context, actorID, orgID string) error
+	ListOrgMembers(ctx context.Context, actorID, orgID string) ([]OrgMember, error)
+	UpdateMemberRole(ctx context.Context, actorID, orgID, userID, role string) error
+	RemoveMember(ctx context.Context, actorID, orgID, userID string) error
+	InviteMember(ctx context.Context, actorID, orgID, email, role string) (*model.Invitation, error)
+	ListInvitations(ctx context.Context, actorID, orgID string) ([]model.Invitation, error)
+	RevokeInvitation(ctx context.Context, actorID, orgID, invitationID string) error
+	AcceptInvitation(ctx context.Context, token, name, password string) (*AcceptedInvitation, error)
+	SwitchOrganization(ctx context.Context, claims *utils.AuthClaims, orgID string) (*LoginResult, error)
+	CreateGroup(ctx context.Context, actorID, tenantID string, in GroupInput) (*model.Group, error)
+	ListGroups(ctx context.Context, tenantID string) ([]model.Group, error)
+	UpdateGroup(ctx context.Context, actorID, tenantID, groupID string, in GroupInput) (*model.Group, error)
+	DeleteGroup(ctx context.Context, actorID, tenantID, groupID string) error
+	AddGroupMember(ctx context.Context, actorID, tenantID, groupID, userID string) error
+	RemoveGroupMember(ctx context.Context, actorID, tenantID, groupID, userID string) error
+	ListGroupMembers(ctx context.Context, tenantID, groupID string) ([]GroupMemberInfo, error)
+	GetEffectivePermissions(ctx context.Context, adminTenantID, userID string) (*EffectivePermissions, error)
+	GroupRoles(ctx context.Context, userID primitive.ObjectID) ([]string, error)
+	ResolveSubject(ctx context.Context, userID string) (policy.Subject, error)
+	CheckPermissions(ctx context.Context, subject policy.Subject, checks []PermissionCheck) ([]policy.Decision, error)
+	CreatePolicy(ctx context.Context, actorID string, p model.Policy) (*model.Policy, error)
+	ListPolicies(ctx context.Context) ([]model.Policy, error)
+	UpdatePolicy(ctx context.Context, actorID, policyID string, p model.Policy) (*model.Policy, error)
+	DeletePolicy(ctx context.Context, actorID, policyID string) error
 }
 
+const emailChangeRevertTTL = 7 * 24 * time.Hour
+
 type AuthService struct {
-	repo              *repository.UserRepository
+	repo              repository.IUserRepository
 	tokenRepo         *repository.TokenRepository
 	passwordResetRepo *repository.PasswordResetRepository
+	magicLinkRepo     *repository.MagicLinkRepository
+	emailChangeRepo   *repository.EmailChangeRepository
+	sessionRepo       repository.ISessionRepository
+	refreshTokenRepo  *repository.RefreshTokenRepository
+	oauthCodeRepo     *repository.OAuthCodeRepository
+	revocations       *repository.RevocationCache
+	touches           *touchThrottle
+	introspections    *introspectionCache
+	clientRepo        *repository.ClientRepository
+	identityRepo      repository.IIdentityRepository
+	federationStates  *repository.FederationStateRepository
+	ceremonies        *repository.WebAuthnCeremonyRepository
+	mfaChallenges     *repository.MFAChallengeRepository
+	otpRepo           *repository.OTPCodeRepository
+	serviceAccounts   *repository.ServiceAccountRepository
+	apiKeyRepo        *repository.APIKeyRepository
+	patRepo           *repository.PersonalAccessTokenRepository
+	tenantRepo        *repository.TenantRepository
+	orgRepo           *repository.OrganizationRepository
+	membershipRepo    *repository.MembershipRepository
+	invitationRepo    *repository.InvitationRepository
+	groupRepo         repository.IGroupRepository
+	groupMemberRepo   repository.IGroupMemberRepository
+	policyRepo        *repository.PolicyRepository
+	policies          *policy.Engine
+	passkeys          *webauthn.WebAuthn
+	tokens            *utils.TokenIssuer
+	notifier          notifier.Notifier
+	audit             *audit.Logger
+	auditRepo         *repository.AuditRepository
 	Cfg               *config.Config
 	rateLimiter       *middleware.RateLimiter
+
+	// Sign-in links are limited per email and per client IP
+	magicLinkEmailLimiter *middleware.RateLimiter
+	magicLinkIPLimiter    *middleware.RateLimiter
+
+	// Limits how often a user can have a one-time passcode sent
+	otpSendLimiter *middleware.RateLimiter
 }
 
-func NewAuthService(userRepo *repository.UserRepository, tokenRepo *repository.TokenRepository, passwordResetRepo *repository.PasswordResetRepository, cfg *config.Config) *AuthService {
+func NewAuthService(userRepo *repository.UserRepository, tokenRepo *repository.TokenRepository, passwordResetRepo *repository.PasswordResetRepository, magicLinkRepo *repository.MagicLinkRepository, emailChangeRepo *repository.EmailChangeRepository, sessionRepo *repository.SessionRepository, refreshTokenRepo *repository.RefreshTokenRepository, oauthCodeRepo *repository.OAuthCodeRepository, revocations *repository.RevocationCache, clientRepo *repository.ClientRepository, identityRepo *repository.IdentityRepository, federationStates *repository.FederationStateRepository, ceremonies *repository.WebAuthnCeremonyRepository, mfaChallenges *repository.MFAChallengeRepository, otpRepo *repository.OTPCodeRepository, serviceAccounts *repository.ServiceAccountRepository, apiKeyRepo *repository.APIKeyRepository, patRepo *repository.PersonalAccessTokenRepository, tenantRepo *repository.TenantRepository, orgRepo *repository.OrganizationRepository, membershipRepo *repository.MembershipRepository, invitationRepo *repository.InvitationRepository, groupRepo *repository.GroupRepository, groupMemberRepo *repository.GroupMemberRepository, policyRepo *repository.PolicyRepository, policies *policy.Engine, passkeys *webauthn.WebAuthn, tokens *utils.TokenIssuer, auditRepo *repository.AuditRepository, auditLogger *audit.Logger, n notifier.Notifier, cfg *config.Config) *AuthService {
 	rl := middleware.NewRateLimiter(5, 60)
 	return &AuthService{
 		repo:              userRepo,
 		tokenRepo:         tokenRepo,
 		passwordResetRepo: passwordResetRepo,
+		magicLinkRepo:     magicLinkRepo,
+		emailChangeRepo:   emailChangeRepo,
+		sessionRepo:       sessionRepo,
+		refreshTokenRepo:  refreshTokenRepo,
+		oauthCodeRepo:     oauthCodeRepo,
+		revocations:       revocations,
+		touches:           newTouchThrottle(),
+		introspections:    newIntrospectionCache(cfg.IntrospectionCacheTTL),
+		clientRepo:        clientRepo,
+		identityRepo:      identityRepo,
+		federationStates:  federationStates,
+		ceremonies:        ceremonies,
+		mfaChallenges:     mfaChallenges,
+		otpRepo:           otpRepo,
+		serviceAccounts:   serviceAccounts,
+		apiKeyRepo:        apiKeyRepo,
+		patRepo:           patRepo,
+		tenantRepo:        tenantRepo,
+		orgRepo:           orgRepo,
+		membershipRepo:    membershipRepo,
+		invitationRepo:    invitationRepo,
+		groupRepo:         groupRepo,
+		groupMemberRepo:   groupMemberRepo,
+		policyRepo:        policyRepo,
+		policies:          policies,
+		passkeys:          passkeys,
+		tokens:            tokens,
+		notifier:          n,
+		audit:             auditLogger,
+		auditRepo:         auditRepo,
 		Cfg:               cfg,
 		rateLimiter:       rl,
+
+		magicLinkEmailLimiter: middleware.NewRateLimiter(3, 15*60),
+		magicLinkIPLimiter:    middleware.NewRateLimiter(20, 15*60),
+
+		otpSendLimiter: middleware.NewRateLimiter(3, 5*60),
 	}
 }
 
@@ -76,8 +231,13 @@ func (s *AuthService) Register(ctx context.Context, name, email, password string
 		return errors.New("password too weak (min 8 characters, mix letters & numbers)")
 	}
 
+	tenantID, err := s.requestTenant(ctx)
+	if err != nil {
+		return err
+	}
+
 	// Check if email already exists
-	existingUser, _ := s.repo.FindByEmail(ctx, email)
+	existingUser, _ := s.repo.FindByEmail(ctx, tenantID, email)
 	if existingUser != nil {
 		return errors.New("email already registered")
 	}
@@ -93,56 +253,199 @@ func (s *AuthService) Register(ctx context.Context, name, email, password string
 		Name:     name,
 		Email:    email,
 		Role:     "user", // default
+		TenantID: tenantID,
 		Password: hashedPassword,
 	}
-	return s.repo.CreateUser(ctx, user)
+	if err := s.repo.CreateUser(ctx, user); err != nil {
+		return err
+	}
+
+	s.audit.Record(ctx, model.AuditEvent{
+		ActorID:   user.ID.Hex(),
+		SubjectID: user.ID.Hex(),
+		Action:    audit.ActionRegister,
+		Outcome:   audit.OutcomeSuccess,
+	})
+	return nil
+}
+
+// LoginResult is either an access token or, when the user has a second factor
+// enrolled, an MFA challenge to complete first.
+type LoginResult struct {
+	Token       string
+	ExpiresIn   int64  // seconds
+	Scope       string // granted scopes, space separated; empty for an unscoped token
+	MFARequired bool
+	MFAToken    string
+	MFAMethods  []string
 }
 
-func (s *AuthService) Login(ctx context.Context, email, password string) (string, error) {
+// Login signs a user in. A non-empty clientID must name a registered client
+// and becomes the token's audience. Scopes, if any, limit the token to the
+// RPCs that require them.
+func (s *AuthService) Login(ctx context.Context, email, password, deviceName, clientID string, scopes []string) (*LoginResult, error) {
+	tenantID, err := s.loginTenant(ctx, clientID)
+	if err != nil {
+		return nil, err
+	}
+	scope, err := loginScope(scopes)
+	if err != nil {
+		return nil, err
+	}
+
 	if !s.rateLimiter.Allow(email) {
-		return "", errors.New("too many login attempts, please wait")
+		s.recordLoginFailure(ctx, "", email, "rate_limited")
+		return nil, errors.New("too many login attempts, please wait")
 	}
 
-	user, err := s.repo.FindByEmail(ctx, email)
+	user, err := s.repo.FindByEmail(ctx, tenantID, email)
 	if err != nil {
-		return "", errors.New("user not found")
+		s.recordLoginFailure(ctx, "", email, "user_not_found")
+		return nil, errors.New("user not found")
 	}
 
 	if !utils.CheckPasswordHash(password, user.Password) {
-		return "", errors.New("invalid password")
+		s.recordLoginFailure(ctx, user.ID.Hex(), email, "invalid_password")
+		return nil, errors.New("invalid password")
 	}
 
-	token, err := utils.GenerateJWT(user.ID.Hex(), user.Role, s.Cfg.JWTSecret)
+	methods, err := s.secondFactors(ctx, user)
 	if err != nil {
-		return "", errors.New("failed to generate token")
+		return nil, err
+	}
+	if len(methods) > 0 {
+		return s.startMFAChallenge(ctx, user, deviceName, clientID, scope, methods)
 	}
 
-	return token, nil
+	return s.completeLogin(ctx, user, deviceName, clientID, scope, nil)
 }
 
-func (s *AuthService) Logout(ctx context.Context, token string) error {
-	parsed, err := jwt.Parse(token, func(t *jwt.Token) (interface{}, error) {
-		return []byte(s.Cfg.JWTSecret), nil
+// loginTenant checks a client named by a first-party login and returns the
+// tenant the login is for: the client's, like in the OAuth flow, or the
+// x-tenant header's when no client is named. Only public clients can be
+// named: a confidential client's audience must only be reachable through its
+// own authorization flow, with its secret.
+func (s *AuthService) loginTenant(ctx context.Context, clientID string) (string, error) {
+	tenantID, err := s.requestTenant(ctx)
+	if err != nil {
+		return "", err
+	}
+	if clientID == "" {
+		return tenantID, nil
+	}
+	client, err := s.clientRepo.FindByClientID(ctx, clientID)
+	if err != nil {
+		return "", errors.New("unknown client")
+	}
+	if client.SecretHash != "" {
+		return "", errors.New("confidential clients must use the OAuth authorization flow")
+	}
+	if middleware.ExtractTenantFromContext(ctx) != "" && tenantID != client.TenantID {
+		return "", errors.New("client belongs to another tenant")
+	}
+	return client.TenantID, nil
+}
+
+// completeLogin opens a session for an authenticated user and issues its
+// token, limited to scope unless that is empty. The session lasts as long as
+// the token.
+func (s *AuthService) completeLogin(ctx context.Context, user *model.User, deviceName, clientID, scope string, details map[string]string) (*LoginResult, error) {
+	roles, err := s.GroupRoles(ctx, user.ID)
+	if err != nil {
+		return nil, errors.New("failed to resolve roles")
+	}
+	claims := &utils.AuthClaims{Role: user.Role, Roles: roles, Scope: scope, TenantID: user.TenantID}
+	ttl := s.Cfg.AccessTokenTTLFor(claims.AllRoles(), s.clientAccessTokenTTL(ctx, clientID))
+	session, err := s.createSession(ctx, user.ID, deviceName, ttl)
+	if err != nil {
+		return nil, errors.New("failed to create session")
+	}
+
+	claims.SessionID = session.ID.Hex()
+	claims.Subject = user.ID.Hex()
+	var audience []string
+	if clientID != "" {
+		audience = append(audience, clientID)
+	}
+	token, err := s.tokens.Issue(claims, ttl, audience...)
+	if err != nil {
+		return nil, errors.New("failed to generate token")
+	}
+
+	if details == nil {
+		details = map[string]string{}
+	}
+	details["session_id"] = session.ID.Hex()
+	if scope != "" {
+		details["scope"] = scope
+	}
+	s.audit.Record(ctx, model.AuditEvent{
+		ActorID:   user.ID.Hex(),
+		SubjectID: user.ID.Hex(),
+		Action:    audit.ActionLogin,
+		Outcome:   audit.OutcomeSuccess,
+		Details:   details,
 	})
-	if err != nil || !parsed.Valid {
-		return errors.New("invalid token")
+	return &LoginResult{Token: token, ExpiresIn: int64(ttl.Seconds()), Scope: scope}, nil
+}
+
+// clientAccessTokenTTL returns the access token lifetime configured for the
+// client a login names, or 0.
+func (s *AuthService) clientAccessTokenTTL(ctx context.Context, clientID string) time.Duration {
+	if clientID == "" {
+		return 0
+	}
+	client, err := s.clientRepo.FindByClientID(ctx, clientID)
+	if err != nil {
+		return 0
 	}
+	return time.Duration(client.AccessTokenTTL) * time.Second
+}
 
-	claims, ok := parsed.Claims.(jwt.MapClaims)
-	if !ok {
-		return errors.New("invalid claims")
+func (s *AuthService) recordLoginFailure(ctx context.Context, userID, email, reason string) {
+	s.audit.Record(ctx, model.AuditEvent{
+		SubjectID: userID,
+		Action:    audit.ActionLogin,
+		Outcome:   audit.OutcomeFailure,
+		Details:   map[string]string{"email": email, "reason": reason},
+	})
+}
+
+func (s *AuthService) Logout(ctx context.Context, token string) error {
+	claims, err := s.tokens.Validate(token)
+	if err != nil {
+		return errors.New("invalid token")
 	}
 
-	exp, ok := claims["exp"].(float64)
-	if !ok {
-		return errors.New("missing exp")
+	jti := claims.ID
+	expiresAt := claims.ExpiresAt.Time
+	if err := s.tokenRepo.BlacklistToken(ctx, jti, expiresAt); err != nil {
+		return err
+	}
+	s.revocations.AddToken(jti, expiresAt)
+
+	userID, sid := claims.Subject, claims.SessionID
+	uid, uidErr := primitive.ObjectIDFromHex(userID)
+	sessionID, sidErr := primitive.ObjectIDFromHex(sid)
+	if uidErr == nil && sidErr == nil {
+		// Already revoked is fine; the token itself is blacklisted above
+		if err := s.sessionRepo.Revoke(ctx, sessionID, uid); err == nil {
+			s.revocations.AddSession(sid, expiresAt)
+		}
 	}
 
-	return s.tokenRepo.BlacklistToken(ctx, token, int64(exp))
+	s.audit.Record(ctx, model.AuditEvent{
+		ActorID:   userID,
+		SubjectID: userID,
+		Action:    audit.ActionLogout,
+		Outcome:   audit.OutcomeSuccess,
+	})
+	return nil
 }
 
-func (s *AuthService) ListUsers(ctx context.Context, name, email string, page, limit int32) ([]model.User, int32, error) {
-	return s.repo.FindUsers(ctx, name, email, page, limit)
+// ListUsers lists the users of one tenant; "" is the default tenant.
+func (s *AuthService) ListUsers(ctx context.Context, tenantID, name, email string, page, limit int32) ([]model.User, int32, error) {
+	return s.repo.FindUsers(ctx, tenantID, name, email, page, limit)
 }
 
 func (s *AuthService) GetProfile(ctx context.Context, userID string) (*model.User, error) {
@@ -153,26 +456,153 @@ func (s *AuthService) GetProfile(ctx context.Context, userID string) (*model.Use
 	return s.repo.FindByID(ctx, oid)
 }
 
-func (s *AuthService) UpdateProfile(ctx context.Context, userID, name, email string) error {
+// UpdateProfile saves the name immediately. A new email is not applied until the
+// new address confirms it; the returned flag reports whether a change is pending.
+func (s *AuthService) UpdateProfile(ctx context.Context, userID, name, email string) (bool, error) {
 	if strings.TrimSpace(name) == "" || strings.TrimSpace(email) == "" {
-		return errors.New("name and email must not be empty")
+		return false, errors.New("name and email must not be empty")
 	}
 
 	if !isValidEmail(email) {
-		return errors.New("invalid email format")
+		return false, errors.New("invalid email format")
 	}
 
 	oid, err := primitive.ObjectIDFromHex(userID)
 	if err != nil {
-		return errors.New("invalid user ID")
+		return false, errors.New("invalid user ID")
+	}
+
+	user, err := s.repo.FindByID(ctx, oid)
+	if err != nil {
+		return false, errors.New("user not found")
+	}
+
+	if err := s.repo.UpdateUserByID(ctx, oid, bson.M{"name": name}); err != nil {
+		return false, err
+	}
+
+	if email == user.Email {
+		return false, nil
+	}
+
+	if err := s.requestEmailChange(ctx, user, email); err != nil {
+		return false, err
+	}
+
+	s.audit.Record(ctx, model.AuditEvent{
+		ActorID:   userID,
+		SubjectID: userID,
+		Action:    audit.ActionEmailChangeRequest,
+		Outcome:   audit.OutcomeSuccess,
+		Details:   map[string]string{"old_email": user.Email, "new_email": email},
+	})
+	return true, nil
+}
+
+func (s *AuthService) requestEmailChange(ctx context.Context, user *model.User, newEmail string) error {
+	existingUser, _ := s.repo.FindByEmail(ctx, user.TenantID, newEmail)
+	if existingUser != nil {
+		return errors.New("email already registered")
+	}
+
+	// Only the most recent request can be confirmed
+	if err := s.emailChangeRepo.DeletePendingByUser(ctx, user.ID); err != nil {
+		return errors.New("failed to save email change")
 	}
 
-	updates := bson.M{
-		"name":  name,
-		"email": email,
+	token := uuid.NewString()
+	change := &model.EmailChange{
+		UserID:           user.ID,
+		OldEmail:         user.Email,
+		NewEmail:         newEmail,
+		ConfirmTokenHash: utils.HashToken(token),
+		ConfirmExp:       time.Now().Add(s.Cfg.EmailVerificationTTL).Unix(),
+	}
+	if err := s.emailChangeRepo.CreatePending(ctx, change); err != nil {
+		return errors.New("failed to save email change")
 	}
 
-	return s.repo.UpdateUserByID(ctx, oid, updates)
+	body := fmt.Sprintf("Confirm your new email address:\n%s/confirm-email?token=%s", s.Cfg.AppBaseURL, token)
+	if err := s.notifier.Notify(ctx, newEmail, "Confirm your new email address", body); err != nil {
+		return errors.New("failed to send confirmation email")
+	}
+	return nil
+}
+
+// ConfirmEmailChange applies a pending email change and sends the old address
+// a link that can undo it.
+func (s *AuthService) ConfirmEmailChange(ctx context.Context, token string) error {
+	change, err := s.emailChangeRepo.FindPendingByConfirmToken(ctx, utils.HashToken(token))
+	if err != nil {
+		return errors.New("invalid or expired token")
+	}
+
+	user, err := s.repo.FindByID(ctx, change.UserID)
+	if err != nil {
+		return errors.New("user not found")
+	}
+	existingUser, _ := s.repo.FindByEmail(ctx, user.TenantID, change.NewEmail)
+	if existingUser != nil {
+		return errors.New("email already registered")
+	}
+
+	if err := s.repo.UpdateUserByID(ctx, change.UserID, bson.M{"email": change.NewEmail}); err != nil {
+		return err
+	}
+
+	revertToken := uuid.NewString()
+	revertExp := time.Now().Add(emailChangeRevertTTL).Unix()
+	if err := s.emailChangeRepo.MarkConfirmed(ctx, change.ID, utils.HashToken(revertToken), revertExp); err != nil {
+		return errors.New("failed to save email change")
+	}
+
+	body := fmt.Sprintf(
+		"The email on your account was changed to %s.\nIf this wasn't you, revert the change:\n%s/revert-email?token=%s",
+		change.NewEmail, s.Cfg.AppBaseURL, revertToken,
+	)
+	if err := s.notifier.Notify(ctx, change.OldEmail, "Your email address was changed", body); err != nil {
+		return errors.New("failed to notify previous email")
+	}
+
+	s.audit.Record(ctx, model.AuditEvent{
+		SubjectID: change.UserID.Hex(),
+		Action:    audit.ActionEmailChangeConfirm,
+		Outcome:   audit.OutcomeSuccess,
+		Details:   map[string]string{"old_email": change.OldEmail, "new_email": change.NewEmail},
+	})
+	return nil
+}
+
+// RevertEmailChange restores the previous email using the link sent to it.
+func (s *AuthService) RevertEmailChange(ctx context.Context, token string) error {
+	change, err := s.emailChangeRepo.FindByRevertToken(ctx, utils.HashToken(token))
+	if err != nil {
+		return errors.New("invalid or expired token")
+	}
+
+	if err := s.repo.UpdateUserByID(ctx, change.UserID, bson.M{"email": change.OldEmail}); err != nil {
+		return err
+	}
+
+	// Whoever made the change may still be signed in
+	if err := s.revokeAllSessions(ctx, change.UserID); err != nil {
+		return err
+	}
+
+	if err := s.emailChangeRepo.DeletePendingByUser(ctx, change.UserID); err != nil {
+		return err
+	}
+	if err := s.emailChangeRepo.DeleteByID(ctx, change.ID); err != nil {
+		return err
+	}
+
+	s.audit.Record(ctx, model.AuditEvent{
+		SubjectID: change.UserID.Hex(),
+		Action:    audit.ActionEmailChangeRevert,
+		Outcome:   audit.OutcomeSuccess,
+		Details:   map[string]string{"restored_email": change.OldEmail, "reverted_email": change.NewEmail},
+	})
+	return nil
 }
 
 func (s *AuthService) DeleteProfile(ctx context.Context, userID string) error {
@@ -180,22 +610,57 @@ func (s *AuthService) DeleteProfile(ctx context.Context, userID string) error {
 	if err != nil {
 		return errors.New("invalid user ID")
 	}
-	return s.repo.SoftDeleteUserByID(ctx, oid)
+	user, err := s.repo.FindByID(ctx, oid)
+	if err != nil || user.IsDeleted {
+		return errors.New("user not found")
+	}
+	if err := s.repo.SoftDeleteUserByID(ctx, oid); err != nil {
+		return err
+	}
+	// Pending reset and sign-in links are keyed by email, which a new account
+	// may register once this one is gone, so drop them now
+	if err := s.passwordResetRepo.DeleteByEmail(ctx, user.TenantID, user.Email); err != nil {
+		return err
+	}
+	if err := s.magicLinkRepo.DeleteByEmail(ctx, user.TenantID, user.Email); err != nil {
+		return err
+	}
+	if err := s.revokeAllSessions(ctx, oid); err != nil {
+		return err
+	}
+
+	s.audit.Record(ctx, model.AuditEvent{
+		ActorID:   userID,
+		SubjectID: userID,
+		Action:    audit.ActionAccountDelete,
+		Outcome:   audit.OutcomeSuccess,
+	})
+	return nil
 }
 
 func (s *AuthService) RequestPasswordReset(ctx context.Context, email string) (string, error) {
-	user, err := s.repo.FindByEmail(ctx, email)
+	tenantID, err := s.requestTenant(ctx)
+	if err != nil {
+		return "", err
+	}
+	user, err := s.repo.FindByEmail(ctx, tenantID, email)
 	if err != nil || user.IsDeleted {
 		return "", errors.New("user not found")
 	}
 
 	token := uuid.NewString()
-	exp := time.Now().Add(15 * time.Minute).Unix()
+	exp := time.Now().Add(s.Cfg.PasswordResetTTL).Unix()
 
-	err = s.passwordResetRepo.SaveToken(ctx, email, token, exp)
+	err = s.passwordResetRepo.SaveToken(ctx, tenantID, email, token, exp)
 	if err != nil {
 		return "", errors.New("failed to save reset token")
 	}
+
+	s.audit.Record(ctx, model.AuditEvent{
+		SubjectID: user.ID.Hex(),
+		Action:    audit.ActionPasswordResetRequest,
+		Outcome:   audit.OutcomeSuccess,
+	})
 	return token, nil
 }
 
@@ -204,12 +669,17 @@ func (s *AuthService) ResetPassword(ctx context.Context, token, newPassword stri
 		return errors.New("password too short")
 	}
 
-	email, err := s.passwordResetRepo.GetEmailByToken(ctx, token)
+	tenantID, email, err := s.passwordResetRepo.GetEmailByToken(ctx, token)
 	if err != nil {
+		s.audit.Record(ctx, model.AuditEvent{
+			Action:  audit.ActionPasswordReset,
+			Outcome: audit.OutcomeFailure,
+			Details: map[string]string{"reason": "invalid_token"},
+		})
 		return errors.New("invalid or expired token")
 	}
 
-	user, err := s.repo.FindByEmail(ctx, email)
+	user, err := s.repo.FindByEmail(ctx, tenantID, email)
 	if err != nil {
 		return errors.New("user not found")
 	}
@@ -224,5 +694,21 @@ func (s *AuthService) ResetPassword(ctx context.Context, token, newPassword stri
 		return err
 	}
 
-	return s.passwordResetRepo.DeleteToken(ctx, token)
+	if err := s.passwordResetRepo.DeleteToken(ctx, token); err != nil {
+		return err
+	}
+	if err := s.revokeAllSessions(ctx, user.ID); err != nil {
+		return err
+	}
+
+	s.audit.Record(ctx, model.AuditEvent{
+		SubjectID: user.ID.Hex(),
+		Action:    audit.ActionPasswordReset,
+		Outcome:   audit.OutcomeSuccess,
+	})
+	return nil
+}
+
+func (s *AuthService) ListAuditEvents(ctx context.Context, filter repository.AuditFilter, cursor string, limit int64) ([]model.AuditEvent, string, error) {
+	return s.auditRepo.List(ctx, filter, cursor, limit)
 }
@@ -0,0 +1,128 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/bekbek22/auth_service/internal/audit"
+	"github.com/bekbek22/auth_service/internal/model"
+	"github.com/bekbek22/auth_service/internal/policy"
+	"github.com/bekbek22/auth_service/internal/utils"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ScopeAuthzCheck lets a service account ask for other users' permissions.
+const ScopeAuthzCheck = "authz:check"
+
+// maxPermissionChecks bounds a CheckPermissions batch.
+const maxPermissionChecks = 100
+
+// PermissionCheck is one question in a CheckPermissions batch.
+type PermissionCheck struct {
+	Action   string
+	Resource policy.Resource
+}
+
+// SubjectFromClaims describes the caller to the policy engine.
+func SubjectFromClaims(claims *utils.AuthClaims) policy.Subject {
+	return policy.Subject{ID: claims.Subject, TenantID: claims.TenantID, Roles: claims.AllRoles()}
+}
+
+// ResolveSubject describes a user to the policy engine with their current
+// roles, including those from groups.
+func (s *AuthService) ResolveSubject(ctx context.Context, userID string) (policy.Subject, error) {
+	oid, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return policy.Subject{}, errors.New("invalid user ID")
+	}
+	user, err := s.repo.FindByID(ctx, oid)
+	if err != nil || user.IsDeleted {
+		return policy.Subject{}, errors.New("user not found")
+	}
+	roles, err := s.GroupRoles(ctx, oid)
+	if err != nil {
+		return policy.Subject{}, errors.New("failed to resolve roles")
+	}
+	claims := &utils.AuthClaims{Role: user.Role, Roles: roles, TenantID: user.TenantID}
+	claims.Subject = userID
+	return SubjectFromClaims(claims), nil
+}
+
+// CheckPermissions decides each check for subject, in order.
+func (s *AuthService) CheckPermissions(ctx context.Context, subject policy.Subject, checks []PermissionCheck) ([]policy.Decision, error) {
+	if len(checks) == 0 {
+		return nil, errors.New("at least one check is required")
+	}
+	if len(checks) > maxPermissionChecks {
+		return nil, errors.New("too many checks, at most 100 per call")
+	}
+	now := time.Now()
+	decisions := make([]policy.Decision, 0, len(checks))
+	for _, c := range checks {
+		if c.Action == "" || c.Resource.Type == "" {
+			return nil, errors.New("action and resource type are required")
+		}
+		decisions = append(decisions, s.policies.Decide(subject, c.Action, c.Resource, now))
+	}
+	return decisions, nil
+}
+
+func (s *AuthService) CreatePolicy(ctx context.Context, actorID string, p model.Policy) (*model.Policy, error) {
+	if err := policy.Validate(&p); err != nil {
+		return nil, err
+	}
+	if err := s.policyRepo.Create(ctx, &p); err != nil {
+		return nil, errors.New("failed to create policy")
+	}
+	s.policiesChanged(ctx, actorID, audit.ActionPolicyCreate, p.ID.Hex())
+	return &p, nil
+}
+
+func (s *AuthService) ListPolicies(ctx context.Context) ([]model.Policy, error) {
+	return s.policyRepo.ListAll(ctx)
+}
+
+// UpdatePolicy replaces a policy's rules.
+func (s *AuthService) UpdatePolicy(ctx context.Context, actorID, policyID string, p model.Policy) (*model.Policy, error) {
+	oid, err := primitive.ObjectIDFromHex(policyID)
+	if err != nil {
+		return nil, errors.New("invalid policy ID")
+	}
+	if err := policy.Validate(&p); err != nil {
+		return nil, err
+	}
+	p.ID = oid
+	if err := s.policyRepo.Replace(ctx, &p); err != nil {
+		return nil, err
+	}
+	s.policiesChanged(ctx, actorID, audit.ActionPolicyUpdate, policyID)
+	return s.policyRepo.FindByID(ctx, oid)
+}
+
+func (s *AuthService) DeletePolicy(ctx context.Context, actorID, policyID string) error {
+	oid, err := primitive.ObjectIDFromHex(policyID)
+	if err != nil {
+		return errors.New("invalid policy ID")
+	}
+	if err := s.policyRepo.Delete(ctx, oid); err != nil {
+		return err
+	}
+	s.policiesChanged(ctx, actorID, audit.ActionPolicyDelete, policyID)
+	return nil
+}
+
+// policiesChanged applies a policy change on this instance right away and
+// audits it; other instances pick it up on their next reload.
+func (s *AuthService) policiesChanged(ctx context.Context, actorID, action, policyID string) {
+	if err := s.policies.Reload(ctx); err != nil {
+		log.Printf("policy reload failed: %v", err)
+	}
+	s.audit.Record(ctx, model.AuditEvent{
+		ActorID: actorID,
+		Action:  action,
+		Outcome: audit.OutcomeSuccess,
+		Details: map[string]string{"policy_id": policyID},
+	})
+}
@@ -0,0 +1,241 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/bekbek22/auth_service/internal/audit"
+	"github.com/bekbek22/auth_service/internal/model"
+	"github.com/bekbek22/auth_service/internal/repository"
+	"github.com/bekbek22/auth_service/internal/utils"
+	"github.com/google/uuid"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+var supportedGrantTypes = map[string]bool{
+	"authorization_code": true,
+	"client_credentials": true,
+	"refresh_token":      true,
+}
+
+// ClientInput holds the editable fields of an OAuth client.
+type ClientInput struct {
+	Name         string
+	RedirectURIs []string
+	GrantTypes   []string
+	Scopes       []string
+
+	// Token lifetimes in seconds; 0 uses the server defaults
+	AccessTokenTTL  int64
+	RefreshTokenTTL int64
+
+	// TenantID is set on create only; "" is the default tenant
+	TenantID string
+}
+
+func validateClientInput(in ClientInput, confidential bool) error {
+	if strings.TrimSpace(in.Name) == "" {
+		return errors.New("name is required")
+	}
+	if len(in.GrantTypes) == 0 {
+		return errors.New("at least one grant type is required")
+	}
+	for _, g := range in.GrantTypes {
+		if !supportedGrantTypes[g] {
+			return errors.New("unsupported grant type: " + g)
+		}
+		if g == "client_credentials" && !confidential {
+			return errors.New("public clients can't use client_credentials")
+		}
+		if g == "authorization_code" && len(in.RedirectURIs) == 0 {
+			return errors.New("authorization_code requires a redirect URI")
+		}
+	}
+	// The OAuth server never issues a token without a scope
+	if len(in.Scopes) == 0 {
+		return errors.New("at least one scope is required")
+	}
+	for _, sc := range in.Scopes {
+		if !slices.Contains(ClientScopes, sc) {
+			return errors.New("unsupported scope: " + sc)
+		}
+	}
+	if in.AccessTokenTTL < 0 || in.RefreshTokenTTL < 0 {
+		return errors.New("token lifetimes can't be negative")
+	}
+	for _, uri := range in.RedirectURIs {
+		u, err := url.Parse(uri)
+		if err != nil || u.Scheme == "" || u.Host == "" || u.Fragment != "" {
+			return errors.New("invalid redirect URI: " + uri)
+		}
+	}
+	return nil
+}
+
+// CreateClient registers a client. For confidential clients the plaintext
+// secret is returned once and only its hash is stored.
+func (s *AuthService) CreateClient(ctx context.Context, actorID string, in ClientInput, confidential bool) (*model.Client, string, error) {
+	if err := validateClientInput(in, confidential); err != nil {
+		return nil, "", err
+	}
+
+	if in.TenantID != "" {
+		if _, err := s.GetTenant(ctx, in.TenantID); err != nil {
+			return nil, "", err
+		}
+	}
+
+	client := &model.Client{
+		ClientID:     uuid.NewString(),
+		Name:         in.Name,
+		RedirectURIs: in.RedirectURIs,
+		GrantTypes:   in.GrantTypes,
+		Scopes:       in.Scopes,
+
+		AccessTokenTTL:  in.AccessTokenTTL,
+		RefreshTokenTTL: in.RefreshTokenTTL,
+		TenantID:        in.TenantID,
+	}
+
+	secret := ""
+	if confidential {
+		var err error
+		secret, client.SecretHash, err = generateClientSecret()
+		if err != nil {
+			return nil, "", err
+		}
+	}
+
+	if err := s.clientRepo.Create(ctx, client); err != nil {
+		return nil, "", errors.New("failed to create client")
+	}
+
+	s.recordClientEvent(ctx, actorID, audit.ActionClientCreate, client.ClientID)
+	return client, secret, nil
+}
+
+func (s *AuthService) GetClient(ctx context.Context, clientID string) (*model.Client, error) {
+	client, err := s.clientRepo.FindByClientID(ctx, clientID)
+	if err != nil {
+		return nil, errors.New("client not found")
+	}
+	return client, nil
+}
+
+func (s *AuthService) ListClients(ctx context.Context, page, limit int32) ([]model.Client, int32, error) {
+	return s.clientRepo.List(ctx, page, limit)
+}
+
+func (s *AuthService) UpdateClient(ctx context.Context, actorID, clientID string, in ClientInput) (*model.Client, error) {
+	existing, err := s.clientRepo.FindByClientID(ctx, clientID)
+	if err != nil {
+		return nil, errors.New("client not found")
+	}
+	if err := validateClientInput(in, existing.SecretHash != ""); err != nil {
+		return nil, err
+	}
+
+	err = s.clientRepo.Update(ctx, clientID, bson.M{
+		"name":          in.Name,
+		"redirect_uris": in.RedirectURIs,
+		"grant_types":   in.GrantTypes,
+		"scopes":        in.Scopes,
+
+		"access_token_ttl":  in.AccessTokenTTL,
+		"refresh_token_ttl": in.RefreshTokenTTL,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.recordClientEvent(ctx, actorID, audit.ActionClientUpdate, clientID)
+	return s.clientRepo.FindByClientID(ctx, clientID)
+}
+
+// DeleteClient removes a client and everything it was issued: its access
+// tokens are revoked until the longest of them would have expired, and its
+// sessions, refresh tokens and pending authorization codes are dropped.
+func (s *AuthService) DeleteClient(ctx context.Context, actorID, clientID string) error {
+	client, err := s.clientRepo.FindByClientID(ctx, clientID)
+	if err != nil {
+		return errors.New("client not found")
+	}
+	if err := s.clientRepo.Delete(ctx, clientID); err != nil {
+		return err
+	}
+
+	ttl := s.Cfg.MaxAccessTokenTTL(time.Duration(client.AccessTokenTTL) * time.Second)
+	expiresAt := time.Now().Add(ttl + utils.TokenLeeway)
+	key := repository.ClientRevocationKey(clientID)
+	if err := s.tokenRepo.BlacklistToken(ctx, key, expiresAt); err != nil {
+		return err
+	}
+	s.revocations.AddToken(key, expiresAt)
+
+	if err := s.oauthCodeRepo.DeleteByClient(ctx, clientID); err != nil {
+		return err
+	}
+	tokens, err := s.refreshTokenRepo.ListByClient(ctx, clientID)
+	if err != nil {
+		return err
+	}
+	for _, t := range tokens {
+		// Already revoked sessions don't match
+		_ = s.sessionRepo.Revoke(ctx, t.SessionID, t.UserID)
+	}
+	if err := s.refreshTokenRepo.DeleteByClient(ctx, clientID); err != nil {
+		return err
+	}
+	s.syncRevocations(ctx)
+
+	s.recordClientEvent(ctx, actorID, audit.ActionClientDelete, clientID)
+	return nil
+}
+
+// RotateClientSecret replaces a confidential client's secret. The old secret
+// stops working immediately.
+func (s *AuthService) RotateClientSecret(ctx context.Context, actorID, clientID string) (string, error) {
+	client, err := s.clientRepo.FindByClientID(ctx, clientID)
+	if err != nil {
+		return "", errors.New("client not found")
+	}
+	if client.SecretHash == "" {
+		return "", errors.New("public clients have no secret")
+	}
+
+	secret, hash, err := generateClientSecret()
+	if err != nil {
+		return "", err
+	}
+	if err := s.clientRepo.UpdateSecret(ctx, clientID, hash); err != nil {
+		return "", err
+	}
+
+	s.recordClientEvent(ctx, actorID, audit.ActionClientSecretRotate, clientID)
+	return secret, nil
+}
+
+func generateClientSecret() (secret, hash string, err error) {
+	secret, err = utils.GenerateOpaqueToken(32)
+	if err != nil {
+		return "", "", errors.New("failed to generate secret")
+	}
+	hash, err = utils.HashPassword(secret)
+	if err != nil {
+		return "", "", errors.New("failed to hash secret")
+	}
+	return secret, hash, nil
+}
+
+func (s *AuthService) recordClientEvent(ctx context.Context, actorID, action, clientID string) {
+	s.audit.Record(ctx, model.AuditEvent{
+		ActorID: actorID,
+		Action:  action,
+		Outcome: audit.OutcomeSuccess,
+		Details: map[string]string{"client_id": clientID},
+	})
+}
@@ -0,0 +1,259 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+
+	"github.com/bekbek22/auth_service/internal/audit"
+	"github.com/bekbek22/auth_service/internal/model"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type exportedProfile struct {
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	Email       string `json:"email"`
+	Role        string `json:"role"`
+	HasPassword bool   `json:"has_password"`
+	CreatedAt   int64  `json:"created_at"`
+
+	OTPFactor *exportedOTPFactor `json:"otp_factor,omitempty"`
+}
+
+type exportedOTPFactor struct {
+	Channel     string `json:"channel"`
+	Destination string `json:"destination"`
+	EnabledAt   int64  `json:"enabled_at"`
+}
+
+type exportedEmailChange struct {
+	OldEmail    string `json:"old_email"`
+	NewEmail    string `json:"new_email"`
+	CreatedAt   int64  `json:"created_at"`
+	ConfirmedAt int64  `json:"confirmed_at,omitempty"`
+}
+
+type exportedPasswordReset struct {
+	ExpiresAt int64 `json:"expires_at"`
+}
+
+type exportedSession struct {
+	ID         string `json:"id"`
+	DeviceName string `json:"device_name"`
+	IP         string `json:"ip"`
+	UserAgent  string `json:"user_agent"`
+	CreatedAt  int64  `json:"created_at"`
+	LastSeenAt int64  `json:"last_seen_at"`
+	ExpiresAt  int64  `json:"expires_at"`
+	RevokedAt  int64  `json:"revoked_at,omitempty"`
+}
+
+type exportedIdentity struct {
+	Type        string `json:"type"`
+	Provider    string `json:"provider"`
+	Subject     string `json:"subject"`
+	Email       string `json:"email,omitempty"`
+	Name        string `json:"name,omitempty"`
+	CreatedAt   int64  `json:"created_at"`
+	LastLoginAt int64  `json:"last_login_at,omitempty"`
+}
+
+type exportedPersonalAccessToken struct {
+	Name       string   `json:"name"`
+	Prefix     string   `json:"prefix"`
+	Scopes     []string `json:"scopes"`
+	CreatedAt  int64    `json:"created_at"`
+	ExpiresAt  int64    `json:"expires_at"`
+	LastUsedAt int64    `json:"last_used_at,omitempty"`
+	RevokedAt  int64    `json:"revoked_at,omitempty"`
+}
+
+type exportedMembership struct {
+	OrganizationID   string `json:"organization_id"`
+	OrganizationName string `json:"organization_name"`
+	Role             string `json:"role"`
+}
+
+type exportedAuditEvent struct {
+	ActorID   string            `json:"actor_id,omitempty"`
+	SubjectID string            `json:"subject_id,omitempty"`
+	Action    string            `json:"action"`
+	Outcome   string            `json:"outcome"`
+	IP        string            `json:"ip,omitempty"`
+	UserAgent string            `json:"user_agent,omitempty"`
+	Details   map[string]string `json:"details,omitempty"`
+	Timestamp int64             `json:"timestamp"`
+}
+
+type userDataExport struct {
+	Profile        exportedProfile               `json:"profile"`
+	EmailChanges   []exportedEmailChange         `json:"email_changes"`
+	PasswordResets []exportedPasswordReset       `json:"password_resets"`
+	Sessions       []exportedSession             `json:"sessions"`
+	Identities     []exportedIdentity            `json:"identities"`
+	AccessTokens   []exportedPersonalAccessToken `json:"personal_access_tokens"`
+	Memberships    []exportedMembership          `json:"memberships"`
+	Groups         []string                      `json:"groups"`
+	AuditEvents    []exportedAuditEvent          `json:"audit_events"`
+}
+
+// ExportMyData collects everything stored about a user as JSON. Secrets such
+// as the password hash and one-time token hashes are never included.
+func (s *AuthService) ExportMyData(ctx context.Context, userID string) ([]byte, error) {
+	oid, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, errors.New("invalid user ID")
+	}
+
+	user, err := s.repo.FindByID(ctx, oid)
+	if err != nil {
+		return nil, errors.New("user not found")
+	}
+
+	export := userDataExport{
+		Profile: exportedProfile{
+			ID:          user.ID.Hex(),
+			Name:        user.Name,
+			Email:       user.Email,
+			Role:        user.Role,
+			HasPassword: user.Password != "",
+			CreatedAt:   user.CreatedAt,
+		},
+		EmailChanges:   []exportedEmailChange{},
+		PasswordResets: []exportedPasswordReset{},
+		Sessions:       []exportedSession{},
+		Identities:     []exportedIdentity{},
+		AccessTokens:   []exportedPersonalAccessToken{},
+		Memberships:    []exportedMembership{},
+		Groups:         []string{},
+		AuditEvents:    []exportedAuditEvent{},
+	}
+	if f := user.OTPFactor; f != nil {
+		export.Profile.OTPFactor = &exportedOTPFactor{
+			Channel:     f.Channel,
+			Destination: otpDestination(user),
+			EnabledAt:   f.EnabledAt,
+		}
+	}
+
+	changes, err := s.emailChangeRepo.FindByUser(ctx, oid)
+	if err != nil {
+		return nil, err
+	}
+	for _, c := range changes {
+		export.EmailChanges = append(export.EmailChanges, exportedEmailChange{
+			OldEmail:    c.OldEmail,
+			NewEmail:    c.NewEmail,
+			CreatedAt:   c.CreatedAt,
+			ConfirmedAt: c.ConfirmedAt,
+		})
+	}
+
+	exps, err := s.passwordResetRepo.FindExpiriesByEmail(ctx, user.TenantID, user.Email)
+	if err != nil {
+		return nil, err
+	}
+	for _, exp := range exps {
+		export.PasswordResets = append(export.PasswordResets, exportedPasswordReset{ExpiresAt: exp})
+	}
+
+	sessions, err := s.sessionRepo.ListByUser(ctx, oid)
+	if err != nil {
+		return nil, err
+	}
+	for _, ss := range sessions {
+		export.Sessions = append(export.Sessions, exportedSession{
+			ID:         ss.ID.Hex(),
+			DeviceName: ss.DeviceName,
+			IP:         ss.IP,
+			UserAgent:  ss.UserAgent,
+			CreatedAt:  ss.CreatedAt,
+			LastSeenAt: ss.LastSeenAt,
+			ExpiresAt:  ss.ExpiresAt,
+			RevokedAt:  ss.RevokedAt,
+		})
+	}
+
+	identities, err := s.identityRepo.ListByUser(ctx, oid)
+	if err != nil {
+		return nil, err
+	}
+	for _, id := range identities {
+		export.Identities = append(export.Identities, exportedIdentity{
+			Type:        id.Type,
+			Provider:    id.Provider,
+			Subject:     id.Subject,
+			Email:       id.Email,
+			Name:        id.Name,
+			CreatedAt:   id.CreatedAt,
+			LastLoginAt: id.LastLoginAt,
+		})
+	}
+
+	tokens, err := s.patRepo.ListByUser(ctx, oid)
+	if err != nil {
+		return nil, err
+	}
+	for _, t := range tokens {
+		export.AccessTokens = append(export.AccessTokens, exportedPersonalAccessToken{
+			Name:       t.Name,
+			Prefix:     t.Prefix,
+			Scopes:     t.Scopes,
+			CreatedAt:  t.CreatedAt,
+			ExpiresAt:  t.ExpiresAt,
+			LastUsedAt: t.LastUsedAt,
+			RevokedAt:  t.RevokedAt,
+		})
+	}
+
+	memberships, err := s.ListMyOrganizations(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	for _, m := range memberships {
+		export.Memberships = append(export.Memberships, exportedMembership{
+			OrganizationID:   m.Organization.ID.Hex(),
+			OrganizationName: m.Organization.Name,
+			Role:             m.Role,
+		})
+	}
+
+	groups, err := s.userGroups(ctx, oid)
+	if err != nil {
+		return nil, err
+	}
+	for _, g := range groups {
+		export.Groups = append(export.Groups, g.Name)
+	}
+
+	events, err := s.auditRepo.ListByUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	for _, e := range events {
+		export.AuditEvents = append(export.AuditEvents, exportedAuditEvent{
+			ActorID:   e.ActorID,
+			SubjectID: e.SubjectID,
+			Action:    e.Action,
+			Outcome:   e.Outcome,
+			IP:        e.IP,
+			UserAgent: e.UserAgent,
+			Details:   e.Details,
+			Timestamp: e.Timestamp,
+		})
+	}
+
+	data, err := json.MarshalIndent(export, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+
+	s.audit.Record(ctx, model.AuditEvent{
+		ActorID:   userID,
+		SubjectID: userID,
+		Action:    audit.ActionDataExport,
+		Outcome:   audit.OutcomeSuccess,
+	})
+	return data, nil
+}
@@ -0,0 +1,172 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/bekbek22/auth_service/internal/audit"
+	"github.com/bekbek22/auth_service/internal/model"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// LoginWithExternalIdentity signs in a user verified by an external OIDC
+// provider and returns our normal access token. Unknown identities are linked
+// to the account with the same email, or a new account is created just in
+// time, only when the provider verified the email. Users with a second factor
+// still have to complete it. The provider's tenant scopes the whole lookup.
+func (s *AuthService) LoginWithExternalIdentity(ctx context.Context, provider, tenant, subject, email string, emailVerified bool, name, deviceName string) (*LoginResult, error) {
+	tenantID, err := s.tenantBySlug(ctx, tenant)
+	if err != nil {
+		return nil, err
+	}
+	user, err := s.resolveExternalIdentity(ctx, tenantID, provider, subject, strings.ToLower(email), emailVerified, name)
+	if err != nil {
+		s.audit.Record(ctx, model.AuditEvent{
+			Action:  audit.ActionLogin,
+			Outcome: audit.OutcomeFailure,
+			Details: map[string]string{"provider": provider, "subject": subject, "reason": err.Error()},
+		})
+		return nil, err
+	}
+
+	methods, err := s.secondFactors(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+	if len(methods) > 0 {
+		return s.startMFAChallenge(ctx, user, deviceName, "", "", methods)
+	}
+	return s.completeLogin(ctx, user, deviceName, "", "", map[string]string{"provider": provider})
+}
+
+func (s *AuthService) resolveExternalIdentity(ctx context.Context, tenantID, provider, subject, email string, emailVerified bool, name string) (*model.User, error) {
+	identity, err := s.identityRepo.FindByProviderSubject(ctx, provider, subject)
+	if err == nil {
+		user, err := s.repo.FindByID(ctx, identity.UserID)
+		if err != nil {
+			return nil, errors.New("user not found")
+		}
+		if user.TenantID != tenantID {
+			return nil, errors.New("user belongs to another tenant")
+		}
+		_ = s.identityRepo.TouchLogin(ctx, identity.ID)
+		return user, nil
+	}
+
+	if email == "" || !isValidEmail(email) {
+		return nil, errors.New("provider did not return a usable email")
+	}
+
+	user, _ := s.repo.FindByEmail(ctx, tenantID, email)
+	if err := checkEmailLink(user, emailVerified); err != nil {
+		return nil, err
+	}
+
+	if user == nil {
+		if strings.TrimSpace(name) == "" {
+			name = email
+		}
+		// No password: the account can only sign in through the provider
+		user = &model.User{
+			TenantID: tenantID,
+			Name:     name,
+			Email:    email,
+			Role:     "user",
+		}
+		if err := s.repo.CreateUser(ctx, user); err != nil {
+			return nil, errors.New("failed to create user")
+		}
+		s.audit.Record(ctx, model.AuditEvent{
+			ActorID:   user.ID.Hex(),
+			SubjectID: user.ID.Hex(),
+			Action:    audit.ActionRegister,
+			Outcome:   audit.OutcomeSuccess,
+			Details:   map[string]string{"provider": provider},
+		})
+	}
+
+	identity = &model.Identity{
+		UserID:   user.ID,
+		Type:     model.LoginMethodOIDC,
+		Provider: provider,
+		Subject:  subject,
+		Email:    email,
+	}
+	if err := s.identityRepo.Create(ctx, identity); err != nil {
+		return nil, errors.New("failed to link identity")
+	}
+	s.audit.Record(ctx, model.AuditEvent{
+		ActorID:   user.ID.Hex(),
+		SubjectID: user.ID.Hex(),
+		Action:    audit.ActionIdentityLink,
+		Outcome:   audit.OutcomeSuccess,
+		Details:   map[string]string{"provider": provider, "subject": subject},
+	})
+	return user, nil
+}
+
+// checkEmailLink decides whether an unknown external identity may be linked
+// to existing, the account with the same email, or get a new account when
+// there is none. Linking on an unverified email would let anyone claim the
+// account; creating one would let them claim it before its owner signs up.
+func checkEmailLink(existing *model.User, emailVerified bool) error {
+	if emailVerified {
+		return nil
+	}
+	if existing != nil {
+		return errors.New("email already registered, sign in with your password")
+	}
+	return errors.New("provider did not verify the email")
+}
+
+// LinkExternalIdentity adds an external account to a signed-in user, finishing
+// a link started with StartIdentityLink. Only users of the provider's tenant
+// can link it.
+func (s *AuthService) LinkExternalIdentity(ctx context.Context, userID primitive.ObjectID, provider, tenant, subject, email string) error {
+	if existing, err := s.identityRepo.FindByProviderSubject(ctx, provider, subject); err == nil {
+		if existing.UserID == userID {
+			return nil
+		}
+		s.audit.Record(ctx, model.AuditEvent{
+			ActorID:   userID.Hex(),
+			SubjectID: userID.Hex(),
+			Action:    audit.ActionIdentityLink,
+			Outcome:   audit.OutcomeFailure,
+			Details:   map[string]string{"provider": provider, "subject": subject, "reason": "linked_to_other_user"},
+		})
+		return errors.New("this account is already linked to another user")
+	}
+
+	user, err := s.repo.FindByID(ctx, userID)
+	if err != nil {
+		return errors.New("user not found")
+	}
+	tenantID, err := s.tenantBySlug(ctx, tenant)
+	if err != nil {
+		return err
+	}
+	if user.TenantID != tenantID {
+		return errors.New("user belongs to another tenant")
+	}
+
+	identity := &model.Identity{
+		UserID:   userID,
+		Type:     model.LoginMethodOIDC,
+		Provider: provider,
+		Subject:  subject,
+		Email:    strings.ToLower(email),
+	}
+	if err := s.identityRepo.Create(ctx, identity); err != nil {
+		return errors.New("failed to link identity")
+	}
+
+	s.audit.Record(ctx, model.AuditEvent{
+		ActorID:   userID.Hex(),
+		SubjectID: userID.Hex(),
+		Action:    audit.ActionIdentityLink,
+		Outcome:   audit.OutcomeSuccess,
+		Details:   map[string]string{"provider": provider, "subject": subject},
+	})
+	return nil
+}
@@ -0,0 +1,144 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/bekbek22/auth_service/internal/audit"
+	"github.com/bekbek22/auth_service/internal/model"
+)
+
+func TestCheckEmailLink(t *testing.T) {
+	existing := &model.User{Email: "bob@example.com"}
+
+	tests := []struct {
+		name     string
+		existing *model.User
+		verified bool
+		wantErr  bool
+	}{
+		{name: "new account, verified", existing: nil, verified: true},
+		{name: "new account, unverified", existing: nil, verified: false, wantErr: true},
+		{name: "existing account, verified", existing: existing, verified: true},
+		{name: "existing account, unverified", existing: existing, verified: false, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkEmailLink(tt.existing, tt.verified)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("got error %v, want error %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func newFederationTestService() (*AuthService, *memoryUsers, *memoryIdentities) {
+	users := &memoryUsers{}
+	identities := &memoryIdentities{}
+	s := &AuthService{repo: users, identityRepo: identities, audit: audit.NewLogger()}
+	return s, users, identities
+}
+
+func TestResolveExternalIdentityCreatesUser(t *testing.T) {
+	s, users, identities := newFederationTestService()
+	ctx := context.Background()
+
+	user, err := s.resolveExternalIdentity(ctx, "", "google", "g-1", "bob@example.com", true, "Bob")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(users.users) != 1 || user.Email != "bob@example.com" || user.Name != "Bob" || user.Role != "user" || user.Password != "" {
+		t.Fatalf("unexpected user %+v", user)
+	}
+	if len(identities.identities) != 1 || identities.identities[0].UserID != user.ID {
+		t.Fatalf("identity not linked: %+v", identities.identities)
+	}
+}
+
+func TestResolveExternalIdentityReusesIdentity(t *testing.T) {
+	s, users, identities := newFederationTestService()
+	ctx := context.Background()
+
+	first, err := s.resolveExternalIdentity(ctx, "", "google", "g-1", "bob@example.com", true, "Bob")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The provider's email no longer matters once the identity is linked
+	again, err := s.resolveExternalIdentity(ctx, "", "google", "g-1", "other@example.com", false, "Bob")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again.ID != first.ID {
+		t.Fatalf("got user %s, want %s", again.ID.Hex(), first.ID.Hex())
+	}
+	if len(users.users) != 1 || len(identities.identities) != 1 {
+		t.Fatalf("got %d users and %d identities, want 1 of each", len(users.users), len(identities.identities))
+	}
+}
+
+func TestResolveExternalIdentityLinksVerifiedEmail(t *testing.T) {
+	s, users, identities := newFederationTestService()
+	ctx := context.Background()
+	existing := &model.User{Email: "bob@example.com", Role: "user"}
+	_ = users.CreateUser(ctx, existing)
+
+	user, err := s.resolveExternalIdentity(ctx, "", "google", "g-1", "bob@example.com", true, "Bob")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if user.ID != existing.ID || len(users.users) != 1 {
+		t.Fatalf("got user %+v, want the existing account", user)
+	}
+	if len(identities.identities) != 1 || identities.identities[0].UserID != existing.ID {
+		t.Fatalf("identity not linked: %+v", identities.identities)
+	}
+}
+
+func TestResolveExternalIdentityRefusesUnverifiedEmail(t *testing.T) {
+	tests := []struct {
+		name     string
+		existing bool
+	}{
+		{name: "link to existing account", existing: true},
+		{name: "create account", existing: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, users, identities := newFederationTestService()
+			ctx := context.Background()
+			wantUsers := 0
+			if tt.existing {
+				_ = users.CreateUser(ctx, &model.User{Email: "bob@example.com", Role: "user"})
+				wantUsers = 1
+			}
+
+			if _, err := s.resolveExternalIdentity(ctx, "", "google", "g-1", "bob@example.com", false, "Mallory"); err == nil {
+				t.Fatal("unverified email was accepted")
+			}
+			if len(users.users) != wantUsers || len(identities.identities) != 0 {
+				t.Fatalf("got %d users and %d identities, want %d and 0", len(users.users), len(identities.identities), wantUsers)
+			}
+		})
+	}
+}
+
+func TestResolveExternalIdentityStaysInTenant(t *testing.T) {
+	s, users, identities := newFederationTestService()
+	ctx := context.Background()
+	_ = users.CreateUser(ctx, &model.User{Email: "bob@example.com", Role: "user"})
+
+	user, err := s.resolveExternalIdentity(ctx, "acme", "acme-idp", "a-1", "bob@example.com", true, "Bob")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if user.TenantID != "acme" || len(users.users) != 2 {
+		t.Fatalf("got user in tenant %q and %d users, want a new user in acme", user.TenantID, len(users.users))
+	}
+	// The same identity can't sign into another tenant
+	if _, err := s.resolveExternalIdentity(ctx, "", "acme-idp", "a-1", "bob@example.com", true, "Bob"); err == nil {
+		t.Fatal("identity crossed tenants")
+	}
+	if len(identities.identities) != 1 {
+		t.Fatalf("got %d identities, want 1", len(identities.identities))
+	}
+}
@@ -0,0 +1,310 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+
+	"github.com/bekbek22/auth_service/internal/audit"
+	"github.com/bekbek22/auth_service/internal/model"
+	"github.com/bekbek22/auth_service/internal/utils"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// GroupInput holds the editable fields of a group.
+type GroupInput struct {
+	Name        string
+	Description string
+	Roles       []string
+}
+
+// GroupMemberInfo is a member of a group as listed to admins.
+type GroupMemberInfo struct {
+	UserID  string
+	Name    string
+	Email   string
+	AddedAt int64
+}
+
+// EffectivePermissions is what a user may do once their groups are counted.
+type EffectivePermissions struct {
+	Roles       []string // own role first, then the groups' roles
+	Permissions []string
+	Groups      []model.Group
+}
+
+func validateGroupInput(tenantID string, in GroupInput) error {
+	if strings.TrimSpace(in.Name) == "" {
+		return errors.New("name is required")
+	}
+	for _, role := range in.Roles {
+		if !IsGroupRole(tenantID, role) {
+			if IsGroupRole("", role) {
+				return errors.New("only platform admins can grant role: " + role)
+			}
+			return errors.New("unsupported role: " + role)
+		}
+	}
+	return nil
+}
+
+// CreateGroup creates a group in the admin's tenant.
+func (s *AuthService) CreateGroup(ctx context.Context, actorID, tenantID string, in GroupInput) (*model.Group, error) {
+	if err := validateGroupInput(tenantID, in); err != nil {
+		return nil, err
+	}
+
+	group := &model.Group{
+		TenantID:    tenantID,
+		Name:        strings.TrimSpace(in.Name),
+		Description: in.Description,
+		Roles:       compactRoles(in.Roles),
+	}
+	if err := s.groupRepo.Create(ctx, group); err != nil {
+		return nil, errors.New("failed to create group, the name may be taken")
+	}
+	s.recordGroupEvent(ctx, actorID, "", audit.ActionGroupCreate, group.ID.Hex())
+	return group, nil
+}
+
+func (s *AuthService) ListGroups(ctx context.Context, tenantID string) ([]model.Group, error) {
+	return s.groupRepo.ListByTenant(ctx, tenantID)
+}
+
+// UpdateGroup replaces a group's name, description and roles. Members get
+// added roles in tokens issued from now on; if a role is taken away they are
+// signed out, as their tokens still carry it.
+func (s *AuthService) UpdateGroup(ctx context.Context, actorID, tenantID, groupID string, in GroupInput) (*model.Group, error) {
+	if err := validateGroupInput(tenantID, in); err != nil {
+		return nil, err
+	}
+	group, err := s.findGroup(ctx, tenantID, groupID)
+	if err != nil {
+		return nil, err
+	}
+
+	roles := compactRoles(in.Roles)
+	err = s.groupRepo.Update(ctx, group.ID, bson.M{
+		"name":        strings.TrimSpace(in.Name),
+		"description": in.Description,
+		"roles":       roles,
+	})
+	if err != nil {
+		return nil, errors.New("failed to update group, the name may be taken")
+	}
+	if slices.ContainsFunc(group.Roles, func(r string) bool { return !slices.Contains(roles, r) }) {
+		if err := s.signOutGroupMembers(ctx, group.ID); err != nil {
+			return nil, err
+		}
+	}
+	s.recordGroupEvent(ctx, actorID, "", audit.ActionGroupUpdate, groupID)
+	return s.groupRepo.FindByID(ctx, group.ID)
+}
+
+func (s *AuthService) DeleteGroup(ctx context.Context, actorID, tenantID, groupID string) error {
+	group, err := s.findGroup(ctx, tenantID, groupID)
+	if err != nil {
+		return err
+	}
+	if len(group.Roles) > 0 {
+		if err := s.signOutGroupMembers(ctx, group.ID); err != nil {
+			return err
+		}
+	}
+	if err := s.groupMemberRepo.DeleteByGroup(ctx, group.ID); err != nil {
+		return err
+	}
+	if err := s.groupRepo.Delete(ctx, group.ID); err != nil {
+		return err
+	}
+	s.recordGroupEvent(ctx, actorID, "", audit.ActionGroupDelete, groupID)
+	return nil
+}
+
+// AddGroupMember puts a user of the group's tenant in the group.
+func (s *AuthService) AddGroupMember(ctx context.Context, actorID, tenantID, groupID, userID string) error {
+	group, err := s.findGroup(ctx, tenantID, groupID)
+	if err != nil {
+		return err
+	}
+	uid, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return errors.New("invalid user ID")
+	}
+	user, err := s.repo.FindByID(ctx, uid)
+	if err != nil || user.IsDeleted || user.TenantID != group.TenantID {
+		return errors.New("user not found")
+	}
+
+	addedBy, _ := primitive.ObjectIDFromHex(actorID)
+	if err := s.groupMemberRepo.Add(ctx, &model.GroupMember{GroupID: group.ID, UserID: uid, AddedBy: addedBy}); err != nil {
+		return errors.New("failed to add member")
+	}
+	s.recordGroupEvent(ctx, actorID, userID, audit.ActionGroupMemberAdd, groupID)
+	return nil
+}
+
+func (s *AuthService) RemoveGroupMember(ctx context.Context, actorID, tenantID, groupID, userID string) error {
+	group, err := s.findGroup(ctx, tenantID, groupID)
+	if err != nil {
+		return err
+	}
+	uid, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return errors.New("invalid user ID")
+	}
+	if err := s.groupMemberRepo.Remove(ctx, group.ID, uid); err != nil {
+		return err
+	}
+	if len(group.Roles) > 0 {
+		if err := s.signOutUsers(ctx, []primitive.ObjectID{uid}); err != nil {
+			return err
+		}
+	}
+	s.recordGroupEvent(ctx, actorID, userID, audit.ActionGroupMemberRemove, groupID)
+	return nil
+}
+
+func (s *AuthService) ListGroupMembers(ctx context.Context, tenantID, groupID string) ([]GroupMemberInfo, error) {
+	group, err := s.findGroup(ctx, tenantID, groupID)
+	if err != nil {
+		return nil, err
+	}
+	members, err := s.groupMemberRepo.ListByGroup(ctx, group.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]GroupMemberInfo, 0, len(members))
+	for _, m := range members {
+		user, err := s.repo.FindByID(ctx, m.UserID)
+		if err != nil || user.IsDeleted {
+			continue
+		}
+		result = append(result, GroupMemberInfo{
+			UserID:  m.UserID.Hex(),
+			Name:    user.Name,
+			Email:   user.Email,
+			AddedAt: m.CreatedAt,
+		})
+	}
+	return result, nil
+}
+
+// GetEffectivePermissions resolves a user's roles and permissions from their
+// own role and their groups, as of now rather than as of their last token.
+// Tenant admins only see users of their own tenant.
+func (s *AuthService) GetEffectivePermissions(ctx context.Context, adminTenantID, userID string) (*EffectivePermissions, error) {
+	uid, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, errors.New("invalid user ID")
+	}
+	user, err := s.repo.FindByID(ctx, uid)
+	if err != nil || user.IsDeleted || (adminTenantID != "" && user.TenantID != adminTenantID) {
+		return nil, errors.New("user not found")
+	}
+
+	groups, err := s.userGroups(ctx, uid)
+	if err != nil {
+		return nil, err
+	}
+	claims := &utils.AuthClaims{Role: user.Role, Roles: groupRoles(groups)}
+	return &EffectivePermissions{
+		Roles:       claims.AllRoles(),
+		Permissions: PermissionsForRoles(claims.AllRoles()),
+		Groups:      groups,
+	}, nil
+}
+
+// GroupRoles returns the roles a user has through groups, for embedding in
+// the tokens they are issued.
+func (s *AuthService) GroupRoles(ctx context.Context, userID primitive.ObjectID) ([]string, error) {
+	groups, err := s.userGroups(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return groupRoles(groups), nil
+}
+
+func (s *AuthService) userGroups(ctx context.Context, userID primitive.ObjectID) ([]model.Group, error) {
+	members, err := s.groupMemberRepo.ListByUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if len(members) == 0 {
+		return nil, nil
+	}
+	ids := make([]primitive.ObjectID, 0, len(members))
+	for _, m := range members {
+		ids = append(ids, m.GroupID)
+	}
+	return s.groupRepo.FindByIDs(ctx, ids)
+}
+
+// signOutGroupMembers revokes the sessions of a group's members, whose tokens
+// carry the group's roles.
+func (s *AuthService) signOutGroupMembers(ctx context.Context, groupID primitive.ObjectID) error {
+	members, err := s.groupMemberRepo.ListByGroup(ctx, groupID)
+	if err != nil {
+		return err
+	}
+	userIDs := make([]primitive.ObjectID, 0, len(members))
+	for _, m := range members {
+		userIDs = append(userIDs, m.UserID)
+	}
+	return s.signOutUsers(ctx, userIDs)
+}
+
+// signOutUsers revokes every session of the users after they lost a group
+// role. Personal access tokens resolve roles when used, so they are kept.
+func (s *AuthService) signOutUsers(ctx context.Context, userIDs []primitive.ObjectID) error {
+	if len(userIDs) == 0 {
+		return nil
+	}
+	for _, id := range userIDs {
+		if err := s.sessionRepo.RevokeAllByUser(ctx, id); err != nil {
+			return errors.New("failed to revoke sessions")
+		}
+	}
+	s.syncRevocations(ctx)
+	return nil
+}
+
+func (s *AuthService) findGroup(ctx context.Context, tenantID, groupID string) (*model.Group, error) {
+	oid, err := primitive.ObjectIDFromHex(groupID)
+	if err != nil {
+		return nil, errors.New("invalid group ID")
+	}
+	group, err := s.groupRepo.FindByID(ctx, oid)
+	if err != nil || group.TenantID != tenantID {
+		return nil, errors.New("group not found")
+	}
+	return group, nil
+}
+
+// groupRoles returns the union of the groups' roles, sorted.
+func groupRoles(groups []model.Group) []string {
+	var roles []string
+	for _, g := range groups {
+		roles = append(roles, g.Roles...)
+	}
+	return compactRoles(roles)
+}
+
+func compactRoles(roles []string) []string {
+	roles = slices.Clone(roles)
+	slices.Sort(roles)
+	return slices.Compact(roles)
+}
+
+func (s *AuthService) recordGroupEvent(ctx context.Context, actorID, subjectID, action, groupID string) {
+	s.audit.Record(ctx, model.AuditEvent{
+		ActorID:   actorID,
+		SubjectID: subjectID,
+		Action:    action,
+		Outcome:   audit.OutcomeSuccess,
+		Details:   map[string]string{"group_id": groupID},
+	})
+}
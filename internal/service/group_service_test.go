@@ -0,0 +1,180 @@
+package service
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"github.com/bekbek22/auth_service/internal/audit"
+	"github.com/bekbek22/auth_service/internal/model"
+	"github.com/bekbek22/auth_service/internal/repository"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type groupTestService struct {
+	*AuthService
+	users    *memoryUsers
+	groups   *memoryGroups
+	members  *memoryGroupMembers
+	sessions *memorySessions
+}
+
+func newGroupTestService() *groupTestService {
+	users := &memoryUsers{}
+	groups := &memoryGroups{}
+	members := &memoryGroupMembers{}
+	sessions := &memorySessions{}
+	return &groupTestService{
+		AuthService: &AuthService{
+			repo:            users,
+			groupRepo:       groups,
+			groupMemberRepo: members,
+			sessionRepo:     sessions,
+			revocations:     repository.NewRevocationCache(emptyBlacklist{}, sessions, 100),
+			audit:           audit.NewLogger(),
+		},
+		users:    users,
+		groups:   groups,
+		members:  members,
+		sessions: sessions,
+	}
+}
+
+// addMember creates a user of the group's tenant and puts them in the group.
+func (s *groupTestService) addMember(t *testing.T, group *model.Group) primitive.ObjectID {
+	t.Helper()
+	ctx := context.Background()
+	user := &model.User{TenantID: group.TenantID, Email: primitive.NewObjectID().Hex() + "@example.com", Role: "user"}
+	_ = s.users.CreateUser(ctx, user)
+	if err := s.AddGroupMember(ctx, "", group.TenantID, group.ID.Hex(), user.ID.Hex()); err != nil {
+		t.Fatal(err)
+	}
+	return user.ID
+}
+
+func TestGroupRolesByTenant(t *testing.T) {
+	tests := []struct {
+		tenantID string
+		role     string
+		wantErr  bool
+	}{
+		{tenantID: "", role: "admin"},
+		{tenantID: "", role: "support"},
+		{tenantID: "", role: "auditor"},
+		{tenantID: "", role: "user", wantErr: true},
+		{tenantID: "", role: "owner", wantErr: true},
+		{tenantID: "acme", role: "admin"},
+		{tenantID: "acme", role: "support", wantErr: true},
+		{tenantID: "acme", role: "auditor", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.tenantID+"/"+tt.role, func(t *testing.T) {
+			s := newGroupTestService()
+			ctx := context.Background()
+
+			_, err := s.CreateGroup(ctx, "", tt.tenantID, GroupInput{Name: "g", Roles: []string{tt.role}})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("CreateGroup: got error %v, want error %v", err, tt.wantErr)
+			}
+
+			group, _ := s.CreateGroup(ctx, "", tt.tenantID, GroupInput{Name: "h"})
+			_, err = s.UpdateGroup(ctx, "", tt.tenantID, group.ID.Hex(), GroupInput{Name: "h", Roles: []string{tt.role}})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("UpdateGroup: got error %v, want error %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestUpdateGroupSignsOutOnRoleRemoval(t *testing.T) {
+	tests := []struct {
+		name        string
+		before      []string
+		after       []string
+		wantSignOut bool
+	}{
+		{name: "role removed", before: []string{"admin", "auditor"}, after: []string{"admin"}, wantSignOut: true},
+		{name: "all roles removed", before: []string{"support"}, after: nil, wantSignOut: true},
+		{name: "role added", before: []string{"auditor"}, after: []string{"auditor", "support"}},
+		{name: "roles unchanged", before: []string{"admin"}, after: []string{"admin"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newGroupTestService()
+			ctx := context.Background()
+			group, err := s.CreateGroup(ctx, "", "", GroupInput{Name: "ops", Roles: tt.before})
+			if err != nil {
+				t.Fatal(err)
+			}
+			member := s.addMember(t, group)
+
+			if _, err := s.UpdateGroup(ctx, "", "", group.ID.Hex(), GroupInput{Name: "ops", Roles: tt.after}); err != nil {
+				t.Fatal(err)
+			}
+			if got := slices.Contains(s.sessions.signedOut, member); got != tt.wantSignOut {
+				t.Fatalf("member signed out: got %v, want %v", got, tt.wantSignOut)
+			}
+		})
+	}
+}
+
+func TestRemoveGroupMemberSignsOut(t *testing.T) {
+	tests := []struct {
+		name        string
+		roles       []string
+		wantSignOut bool
+	}{
+		{name: "group with roles", roles: []string{"support"}, wantSignOut: true},
+		{name: "group without roles", roles: nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newGroupTestService()
+			ctx := context.Background()
+			group, err := s.CreateGroup(ctx, "", "", GroupInput{Name: "ops", Roles: tt.roles})
+			if err != nil {
+				t.Fatal(err)
+			}
+			removed := s.addMember(t, group)
+			kept := s.addMember(t, group)
+
+			if err := s.RemoveGroupMember(ctx, "", "", group.ID.Hex(), removed.Hex()); err != nil {
+				t.Fatal(err)
+			}
+			if got := slices.Contains(s.sessions.signedOut, removed); got != tt.wantSignOut {
+				t.Fatalf("removed member signed out: got %v, want %v", got, tt.wantSignOut)
+			}
+			if slices.Contains(s.sessions.signedOut, kept) {
+				t.Fatal("remaining member was signed out")
+			}
+		})
+	}
+}
+
+func TestGetEffectivePermissionsUnionsGroups(t *testing.T) {
+	s := newGroupTestService()
+	ctx := context.Background()
+	support, _ := s.CreateGroup(ctx, "", "", GroupInput{Name: "support", Roles: []string{"support"}})
+	auditors, _ := s.CreateGroup(ctx, "", "", GroupInput{Name: "auditors", Roles: []string{"auditor", "support"}})
+	member := s.addMember(t, support)
+	_ = s.members.Add(ctx, &model.GroupMember{GroupID: auditors.ID, UserID: member})
+
+	perms, err := s.GetEffectivePermissions(ctx, "", member.Hex())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"user", "auditor", "support"}; !slices.Equal(perms.Roles, want) {
+		t.Fatalf("got roles %v, want %v", perms.Roles, want)
+	}
+	if want := []string{ScopeAuditRead, PermUsersImpersonate, ScopeUsersRead}; !slices.Equal(perms.Permissions, want) {
+		t.Fatalf("got permissions %v, want %v", perms.Permissions, want)
+	}
+	if len(perms.Groups) != 2 {
+		t.Fatalf("got %d groups, want 2", len(perms.Groups))
+	}
+
+	// Tenant admins can't see users of another tenant
+	if _, err := s.GetEffectivePermissions(ctx, "acme", member.Hex()); err == nil {
+		t.Fatal("tenant admin saw a default tenant user")
+	}
+}
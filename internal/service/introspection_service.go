@@ -0,0 +1,162 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bekbek22/auth_service/internal/utils"
+)
+
+// ScopeTokensIntrospect lets a service account check tokens presented to it.
+const ScopeTokensIntrospect = "tokens:introspect"
+
+// Kinds of token IntrospectToken understands.
+const (
+	TokenTypeAccess   = "access_token"
+	TokenTypeAPIKey   = "api_key"
+	TokenTypePersonal = "personal_access_token"
+)
+
+// TokenInfo describes an active token for services that received it.
+type TokenInfo struct {
+	TokenType string
+	Subject   string
+	Role      string
+	Roles     []string // granted through groups
+	Scopes    []string
+	ClientID  string
+	SessionID string
+	ExpiresAt int64  // 0 for API keys that never expire
+	ActorID   string // admin impersonating the subject
+	TenantID  string
+	OrgID     string
+	OrgRole   string
+}
+
+// Results are kept for a short time so a busy downstream service doesn't hit
+// the store for every request; the cache is emptied when it grows too large.
+const maxIntrospectionEntries = 10000
+
+type introspectionEntry struct {
+	info     *TokenInfo
+	err      error
+	cachedAt time.Time
+}
+
+type introspectionCache struct {
+	ttl time.Duration
+
+	mu      sync.Mutex
+	entries map[string]introspectionEntry
+}
+
+func newIntrospectionCache(ttl time.Duration) *introspectionCache {
+	return &introspectionCache{ttl: ttl, entries: make(map[string]introspectionEntry)}
+}
+
+func (c *introspectionCache) get(key string, now time.Time) (introspectionEntry, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	e, ok := c.entries[key]
+	if !ok || now.Sub(e.cachedAt) >= c.ttl {
+		return introspectionEntry{}, false
+	}
+	return e, true
+}
+
+func (c *introspectionCache) put(key string, e introspectionEntry) {
+	if c.ttl <= 0 {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if len(c.entries) >= maxIntrospectionEntries {
+		for k, v := range c.entries {
+			if e.cachedAt.Sub(v.cachedAt) >= c.ttl {
+				delete(c.entries, k)
+			}
+		}
+		if len(c.entries) >= maxIntrospectionEntries {
+			c.entries = make(map[string]introspectionEntry)
+		}
+	}
+	c.entries[key] = e
+}
+
+// IntrospectToken checks a token presented to another service: its signature
+// and expiry, and whether it or its session was revoked. It returns an error
+// describing why the token is not active.
+func (s *AuthService) IntrospectToken(ctx context.Context, token string) (*TokenInfo, error) {
+	if token == "" {
+		return nil, errors.New("token is required")
+	}
+
+	now := time.Now()
+	key := utils.HashToken(token)
+	if e, ok := s.introspections.get(key, now); ok {
+		if e.err == nil && e.info.ExpiresAt != 0 && now.Unix() >= e.info.ExpiresAt {
+			return nil, errors.New("token expired")
+		}
+		return e.info, e.err
+	}
+
+	info, err := s.introspect(ctx, token)
+	s.introspections.put(key, introspectionEntry{info: info, err: err, cachedAt: now})
+	return info, err
+}
+
+func (s *AuthService) introspect(ctx context.Context, token string) (*TokenInfo, error) {
+	// API keys and personal access tokens are opaque, not JWTs
+	if strings.Count(token, ".") != 2 {
+		claims, err := s.AuthenticateKey(ctx, token)
+		if err != nil {
+			return nil, err
+		}
+		info := tokenInfoFromClaims(claims)
+		info.TokenType = TokenTypeAPIKey
+		if claims.PersonalTokenID != "" {
+			info.TokenType = TokenTypePersonal
+		}
+		return info, nil
+	}
+
+	claims, err := s.tokens.Validate(token)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkRevoked(ctx, claims); err != nil {
+		return nil, err
+	}
+	if err := s.checkOrgClaims(ctx, claims); err != nil {
+		return nil, err
+	}
+	info := tokenInfoFromClaims(claims)
+	info.TokenType = TokenTypeAccess
+	return info, nil
+}
+
+func tokenInfoFromClaims(claims *utils.AuthClaims) *TokenInfo {
+	info := &TokenInfo{
+		Subject:   claims.Subject,
+		Role:      claims.Role,
+		Roles:     claims.Roles,
+		Scopes:    claims.Scopes(),
+		ClientID:  claims.ClientID,
+		SessionID: claims.SessionID,
+		TenantID:  claims.TenantID,
+		OrgID:     claims.OrgID,
+		OrgRole:   claims.OrgRole,
+	}
+	if claims.ExpiresAt != nil {
+		info.ExpiresAt = claims.ExpiresAt.Unix()
+	}
+	if claims.Impersonated() {
+		info.ActorID = claims.Actor.Subject
+	}
+	return info
+}
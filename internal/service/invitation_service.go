@@ -0,0 +1,167 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/bekbek22/auth_service/internal/audit"
+	"github.com/bekbek22/auth_service/internal/model"
+	"github.com/bekbek22/auth_service/internal/utils"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// AcceptedInvitation reports what accepting an invitation did.
+type AcceptedInvitation struct {
+	OrgID          string
+	Role           string
+	AccountCreated bool
+}
+
+// InviteMember emails a single-use invitation to join an organization. Admins
+// can invite admins and members, owners anyone. Inviting the same address
+// again replaces the earlier invitation.
+func (s *AuthService) InviteMember(ctx context.Context, actorID, orgID, email, role string) (*model.Invitation, error) {
+	email = strings.ToLower(strings.TrimSpace(email))
+	if !isValidEmail(email) {
+		return nil, errors.New("invalid email format")
+	}
+	if role == "" {
+		role = OrgRoleMember
+	}
+	if _, ok := orgRoleRank[role]; !ok {
+		return nil, errors.New("role must be owner, admin or member")
+	}
+	org, actorRole, err := s.requireOrgRole(ctx, actorID, orgID, OrgRoleAdmin)
+	if err != nil {
+		return nil, err
+	}
+	if role == OrgRoleOwner && actorRole != OrgRoleOwner {
+		return nil, errors.New("only owners can invite owners")
+	}
+	if user, err := s.repo.FindByEmail(ctx, org.TenantID, email); err == nil {
+		if _, err := s.membershipRepo.Find(ctx, org.ID, user.ID); err == nil {
+			return nil, errors.New("already a member")
+		}
+	}
+
+	token, err := utils.GenerateOpaqueToken(32)
+	if err != nil {
+		return nil, errors.New("failed to generate invitation")
+	}
+	inviter, _ := primitive.ObjectIDFromHex(actorID)
+	inv := &model.Invitation{
+		OrgID:     org.ID,
+		Email:     email,
+		Role:      role,
+		TokenHash: utils.HashToken(token),
+		InvitedBy: inviter,
+		ExpiresAt: time.Now().Add(s.Cfg.InvitationTTL).Unix(),
+	}
+	if err := s.invitationRepo.DeleteByEmail(ctx, org.ID, email); err != nil {
+		return nil, err
+	}
+	if err := s.invitationRepo.Create(ctx, inv); err != nil {
+		return nil, errors.New("failed to save invitation")
+	}
+
+	body := fmt.Sprintf("You have been invited to join %s as %s (valid for %d days):\n%s/invitations/accept?token=%s",
+		org.Name, role, int(s.Cfg.InvitationTTL.Hours()/24), s.Cfg.AppBaseURL, token)
+	if err := s.notifier.Notify(ctx, email, "Invitation to join "+org.Name, body); err != nil {
+		return nil, errors.New("failed to send invitation")
+	}
+
+	s.recordOrgEvent(ctx, actorID, "", audit.ActionOrgInvite, orgID, map[string]string{"email": email, "role": role})
+	return inv, nil
+}
+
+func (s *AuthService) ListInvitations(ctx context.Context, actorID, orgID string) ([]model.Invitation, error) {
+	org, _, err := s.requireOrgRole(ctx, actorID, orgID, OrgRoleAdmin)
+	if err != nil {
+		return nil, err
+	}
+	return s.invitationRepo.ListPendingByOrg(ctx, org.ID)
+}
+
+func (s *AuthService) RevokeInvitation(ctx context.Context, actorID, orgID, invitationID string) error {
+	org, _, err := s.requireOrgRole(ctx, actorID, orgID, OrgRoleAdmin)
+	if err != nil {
+		return err
+	}
+	oid, err := primitive.ObjectIDFromHex(invitationID)
+	if err != nil {
+		return errors.New("invalid invitation ID")
+	}
+	if err := s.invitationRepo.Delete(ctx, org.ID, oid); err != nil {
+		return err
+	}
+	s.recordOrgEvent(ctx, actorID, "", audit.ActionOrgInviteRevoke, orgID, map[string]string{"invitation_id": invitationID})
+	return nil
+}
+
+// AcceptInvitation adds the invited address to the organization. An existing
+// account in the organization's tenant is linked; otherwise an account is
+// created from name and password, with the invite proving the email.
+func (s *AuthService) AcceptInvitation(ctx context.Context, token, name, password string) (*AcceptedInvitation, error) {
+	inv, err := s.invitationRepo.FindPendingByHash(ctx, utils.HashToken(token))
+	if err != nil {
+		return nil, errors.New("invalid or expired invitation")
+	}
+	org, err := s.orgRepo.FindByID(ctx, inv.OrgID)
+	if err != nil {
+		return nil, errors.New("invalid or expired invitation")
+	}
+
+	user, err := s.repo.FindByEmail(ctx, org.TenantID, inv.Email)
+	if err != nil {
+		user = nil
+		if strings.TrimSpace(name) == "" {
+			return nil, errors.New("name is required to create an account")
+		}
+		if !isStrongPassword(password) {
+			return nil, errors.New("password too weak (min 8 characters, mix letters & numbers)")
+		}
+	}
+
+	// Claim the invitation before using it, so it works only once
+	if err := s.invitationRepo.Delete(ctx, inv.OrgID, inv.ID); err != nil {
+		return nil, errors.New("invalid or expired invitation")
+	}
+
+	result := &AcceptedInvitation{OrgID: org.ID.Hex(), Role: inv.Role}
+	if user == nil {
+		hashedPassword, err := utils.HashPassword(password)
+		if err != nil {
+			return nil, errors.New("failed to hash password")
+		}
+		user = &model.User{
+			Name:     name,
+			Email:    inv.Email,
+			Role:     "user",
+			TenantID: org.TenantID,
+			Password: hashedPassword,
+		}
+		if err := s.repo.CreateUser(ctx, user); err != nil {
+			return nil, err
+		}
+		result.AccountCreated = true
+		s.audit.Record(ctx, model.AuditEvent{
+			ActorID:   user.ID.Hex(),
+			SubjectID: user.ID.Hex(),
+			Action:    audit.ActionRegister,
+			Outcome:   audit.OutcomeSuccess,
+			Details:   map[string]string{"org_id": result.OrgID},
+		})
+	}
+
+	if _, err := s.membershipRepo.Find(ctx, org.ID, user.ID); err != nil {
+		if err := s.membershipRepo.Create(ctx, &model.Membership{OrgID: org.ID, UserID: user.ID, Role: inv.Role}); err != nil {
+			return nil, errors.New("failed to join organization")
+		}
+	}
+
+	s.recordOrgEvent(ctx, user.ID.Hex(), user.ID.Hex(), audit.ActionOrgInviteAccept, result.OrgID, map[string]string{"role": inv.Role})
+	return result, nil
+}
@@ -0,0 +1,185 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/bekbek22/auth_service/internal/audit"
+	"github.com/bekbek22/auth_service/internal/model"
+	"github.com/bekbek22/auth_service/internal/utils"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const linkTicketTTL = 10 * time.Minute
+
+// LoginMethod is one way a user can sign in: their password or a linked identity.
+type LoginMethod struct {
+	ID          string
+	Type        string
+	Provider    string
+	Email       string
+	Name        string
+	CreatedAt   int64
+	LastLoginAt int64
+}
+
+func (s *AuthService) ListLoginMethods(ctx context.Context, userID string) ([]LoginMethod, error) {
+	oid, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, errors.New("invalid user ID")
+	}
+	user, err := s.repo.FindByID(ctx, oid)
+	if err != nil {
+		return nil, errors.New("user not found")
+	}
+	return s.loginMethods(ctx, user)
+}
+
+func (s *AuthService) loginMethods(ctx context.Context, user *model.User) ([]LoginMethod, error) {
+	identities, err := s.identityRepo.ListByUser(ctx, user.ID)
+	if err != nil {
+		return nil, errors.New("failed to list login methods")
+	}
+
+	methods := make([]LoginMethod, 0, len(identities)+1)
+	if user.Password != "" {
+		methods = append(methods, LoginMethod{
+			ID:        model.LoginMethodPassword,
+			Type:      model.LoginMethodPassword,
+			Email:     user.Email,
+			CreatedAt: user.CreatedAt,
+		})
+	}
+	for _, identity := range identities {
+		methods = append(methods, LoginMethod{
+			ID:          identity.ID.Hex(),
+			Type:        identity.Type,
+			Provider:    identity.Provider,
+			Email:       identity.Email,
+			Name:        identity.Name,
+			CreatedAt:   identity.CreatedAt,
+			LastLoginAt: identity.LastLoginAt,
+		})
+	}
+	return methods, nil
+}
+
+// SetPassword adds a password to an account that signs in some other way,
+// e.g. one created through an external provider.
+func (s *AuthService) SetPassword(ctx context.Context, userID, password string) error {
+	oid, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return errors.New("invalid user ID")
+	}
+	user, err := s.repo.FindByID(ctx, oid)
+	if err != nil {
+		return errors.New("user not found")
+	}
+	if user.Password != "" {
+		return errors.New("password already set")
+	}
+	if !isStrongPassword(password) {
+		return errors.New("password too weak (min 8 characters, mix letters & numbers)")
+	}
+
+	hashed, err := utils.HashPassword(password)
+	if err != nil {
+		return errors.New("failed to hash password")
+	}
+	if err := s.repo.UpdateUserByID(ctx, oid, bson.M{"password": hashed}); err != nil {
+		return err
+	}
+
+	s.audit.Record(ctx, model.AuditEvent{
+		ActorID:   userID,
+		SubjectID: userID,
+		Action:    audit.ActionIdentityLink,
+		Outcome:   audit.OutcomeSuccess,
+		Details:   map[string]string{"type": model.LoginMethodPassword},
+	})
+	return nil
+}
+
+// StartIdentityLink issues a one-time ticket for linking an external account
+// and returns the URL the user opens in a browser to sign in at the provider.
+func (s *AuthService) StartIdentityLink(ctx context.Context, userID, provider string) (string, error) {
+	oid, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return "", errors.New("invalid user ID")
+	}
+	if strings.TrimSpace(provider) == "" {
+		return "", errors.New("provider is required")
+	}
+
+	ticket, err := utils.GenerateOpaqueToken(32)
+	if err != nil {
+		return "", errors.New("failed to generate link ticket")
+	}
+	err = s.federationStates.Save(ctx, &model.FederationState{
+		StateHash:  utils.HashToken(ticket),
+		Provider:   provider,
+		LinkUserID: oid,
+		ExpiresAt:  time.Now().Add(linkTicketTTL).Unix(),
+	})
+	if err != nil {
+		return "", errors.New("failed to start link")
+	}
+
+	return fmt.Sprintf("%s/federation/%s/login?link_ticket=%s",
+		strings.TrimRight(s.Cfg.AppBaseURL, "/"), url.PathEscape(provider), url.QueryEscape(ticket)), nil
+}
+
+// UnlinkLoginMethod removes the password or a linked identity, refusing to
+// remove the last method the user could sign in with.
+func (s *AuthService) UnlinkLoginMethod(ctx context.Context, userID, methodID string) error {
+	oid, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return errors.New("invalid user ID")
+	}
+	user, err := s.repo.FindByID(ctx, oid)
+	if err != nil {
+		return errors.New("user not found")
+	}
+
+	methods, err := s.loginMethods(ctx, user)
+	if err != nil {
+		return err
+	}
+	var target *LoginMethod
+	for i := range methods {
+		if methods[i].ID == methodID {
+			target = &methods[i]
+			break
+		}
+	}
+	if target == nil {
+		return errors.New("login method not found")
+	}
+	if len(methods) == 1 {
+		return errors.New("cannot remove your last login method")
+	}
+
+	if target.Type == model.LoginMethodPassword {
+		err = s.repo.UpdateUserByID(ctx, oid, bson.M{"password": ""})
+	} else {
+		identityID, _ := primitive.ObjectIDFromHex(target.ID)
+		err = s.identityRepo.DeleteByID(ctx, oid, identityID)
+	}
+	if err != nil {
+		return err
+	}
+
+	s.audit.Record(ctx, model.AuditEvent{
+		ActorID:   userID,
+		SubjectID: userID,
+		Action:    audit.ActionIdentityUnlink,
+		Outcome:   audit.OutcomeSuccess,
+		Details:   map[string]string{"type": target.Type, "provider": target.Provider},
+	})
+	return nil
+}
@@ -0,0 +1,98 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/bekbek22/auth_service/internal/audit"
+	"github.com/bekbek22/auth_service/internal/middleware"
+	"github.com/bekbek22/auth_service/internal/model"
+	"github.com/bekbek22/auth_service/internal/utils"
+)
+
+// RequestMagicLink emails a single-use sign-in link. Unknown emails get no
+// link but the same answer, so the call can't be used to find accounts.
+func (s *AuthService) RequestMagicLink(ctx context.Context, email string) error {
+	email = strings.ToLower(strings.TrimSpace(email))
+	if !isValidEmail(email) {
+		return errors.New("invalid email format")
+	}
+
+	ip, _ := middleware.ClientInfoFromContext(ctx)
+	if !s.magicLinkIPLimiter.Allow(ip) || !s.magicLinkEmailLimiter.Allow(email) {
+		s.audit.Record(ctx, model.AuditEvent{
+			Action:  audit.ActionMagicLinkRequest,
+			Outcome: audit.OutcomeFailure,
+			Details: map[string]string{"email": email, "reason": "rate_limited"},
+		})
+		return errors.New("too many sign-in link requests, please wait")
+	}
+
+	tenantID, err := s.requestTenant(ctx)
+	if err != nil {
+		return err
+	}
+	user, err := s.repo.FindByEmail(ctx, tenantID, email)
+	if err != nil || user.IsDeleted {
+		return nil
+	}
+
+	token, err := utils.GenerateOpaqueToken(32)
+	if err != nil {
+		return errors.New("failed to generate sign-in link")
+	}
+	exp := time.Now().Add(s.Cfg.MagicLinkTTL).Unix()
+	if err := s.magicLinkRepo.SaveToken(ctx, tenantID, email, utils.HashToken(token), exp); err != nil {
+		return errors.New("failed to save sign-in link")
+	}
+
+	body := fmt.Sprintf("Sign in to your account (valid for %d minutes):\n%s/magic-link?token=%s",
+		int(s.Cfg.MagicLinkTTL.Minutes()), s.Cfg.AppBaseURL, token)
+	if err := s.notifier.Notify(ctx, email, "Your sign-in link", body); err != nil {
+		return errors.New("failed to send sign-in link")
+	}
+
+	s.audit.Record(ctx, model.AuditEvent{
+		SubjectID: user.ID.Hex(),
+		Action:    audit.ActionMagicLinkRequest,
+		Outcome:   audit.OutcomeSuccess,
+	})
+	return nil
+}
+
+// ConsumeMagicLink exchanges a sign-in link token for a login. Users with a
+// second factor still have to complete it.
+func (s *AuthService) ConsumeMagicLink(ctx context.Context, token, deviceName, clientID string) (*LoginResult, error) {
+	clientTenantID, err := s.loginTenant(ctx, clientID)
+	if err != nil {
+		return nil, err
+	}
+
+	tenantID, email, err := s.magicLinkRepo.ConsumeToken(ctx, utils.HashToken(token))
+	if err != nil {
+		s.recordLoginFailure(ctx, "", "", "invalid_magic_link")
+		return nil, errors.New("invalid or expired sign-in link")
+	}
+	if clientID != "" && tenantID != clientTenantID {
+		s.recordLoginFailure(ctx, "", email, "wrong_tenant")
+		return nil, errors.New("client belongs to another tenant")
+	}
+
+	user, err := s.repo.FindByEmail(ctx, tenantID, email)
+	if err != nil || user.IsDeleted {
+		s.recordLoginFailure(ctx, "", email, "user_not_found")
+		return nil, errors.New("user not found")
+	}
+
+	methods, err := s.secondFactors(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+	if len(methods) > 0 {
+		return s.startMFAChallenge(ctx, user, deviceName, clientID, "", methods)
+	}
+	return s.completeLogin(ctx, user, deviceName, clientID, "", map[string]string{"method": "magic_link"})
+}
@@ -0,0 +1,99 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/bekbek22/auth_service/internal/audit"
+	"github.com/bekbek22/auth_service/internal/model"
+	"github.com/bekbek22/auth_service/internal/utils"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// secondFactors lists the factors a user has enrolled that can complete an
+// MFA challenge after their password was verified.
+func (s *AuthService) secondFactors(ctx context.Context, user *model.User) ([]string, error) {
+	identities, err := s.identityRepo.ListByUser(ctx, user.ID)
+	if err != nil {
+		return nil, errors.New("failed to load login methods")
+	}
+
+	var methods []string
+	for _, identity := range identities {
+		if identity.Type == model.LoginMethodPasskey {
+			methods = append(methods, model.LoginMethodPasskey)
+			break
+		}
+	}
+	if user.OTPFactor != nil {
+		methods = append(methods, model.MFAMethodOTP)
+	}
+	return methods, nil
+}
+
+// RequiresSecondFactor reports whether a password alone is not enough to sign
+// the user in.
+func (s *AuthService) RequiresSecondFactor(ctx context.Context, userID primitive.ObjectID) (bool, error) {
+	user, err := s.repo.FindByID(ctx, userID)
+	if err != nil {
+		return false, errors.New("user not found")
+	}
+	methods, err := s.secondFactors(ctx, user)
+	if err != nil {
+		return false, err
+	}
+	return len(methods) > 0, nil
+}
+
+func (s *AuthService) startMFAChallenge(ctx context.Context, user *model.User, deviceName, clientID, scope string, methods []string) (*LoginResult, error) {
+	token, err := utils.GenerateOpaqueToken(32)
+	if err != nil {
+		return nil, errors.New("failed to generate mfa token")
+	}
+
+	err = s.mfaChallenges.Create(ctx, &model.MFAChallenge{
+		TokenHash:  utils.HashToken(token),
+		UserID:     user.ID,
+		DeviceName: deviceName,
+		ClientID:   clientID,
+		Scope:      scope,
+		ExpiresAt:  time.Now().Add(s.Cfg.MFAChallengeTTL).Unix(),
+	})
+	if err != nil {
+		return nil, errors.New("failed to start mfa challenge")
+	}
+
+	s.audit.Record(ctx, model.AuditEvent{
+		ActorID:   user.ID.Hex(),
+		SubjectID: user.ID.Hex(),
+		Action:    audit.ActionMFAChallenge,
+		Outcome:   audit.OutcomeSuccess,
+	})
+	return &LoginResult{
+		MFARequired: true,
+		MFAToken:    token,
+		MFAMethods:  methods,
+	}, nil
+}
+
+func (s *AuthService) findMFAChallenge(ctx context.Context, mfaToken string) (*model.MFAChallenge, error) {
+	challenge, err := s.mfaChallenges.FindActive(ctx, utils.HashToken(mfaToken))
+	if err != nil {
+		return nil, errors.New("invalid or expired mfa token")
+	}
+	return challenge, nil
+}
+
+// completeMFAChallenge finishes a login once method has verified the second factor.
+func (s *AuthService) completeMFAChallenge(ctx context.Context, challenge *model.MFAChallenge, method string) (*LoginResult, error) {
+	if err := s.mfaChallenges.Delete(ctx, challenge.ID); err != nil {
+		return nil, errors.New("invalid or expired mfa token")
+	}
+
+	user, err := s.repo.FindByID(ctx, challenge.UserID)
+	if err != nil {
+		return nil, errors.New("user not found")
+	}
+	return s.completeLogin(ctx, user, challenge.DeviceName, challenge.ClientID, challenge.Scope, map[string]string{"mfa": method})
+}
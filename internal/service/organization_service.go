@@ -0,0 +1,324 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/bekbek22/auth_service/internal/audit"
+	"github.com/bekbek22/auth_service/internal/model"
+	"github.com/bekbek22/auth_service/internal/utils"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Roles a user can have in an organization, from most to least privileged.
+const (
+	OrgRoleOwner  = "owner"
+	OrgRoleAdmin  = "admin"
+	OrgRoleMember = "member"
+)
+
+var orgRoleRank = map[string]int{
+	OrgRoleOwner:  3,
+	OrgRoleAdmin:  2,
+	OrgRoleMember: 1,
+}
+
+// OrgMembership is an organization with the caller's role in it.
+type OrgMembership struct {
+	Organization model.Organization
+	Role         string
+}
+
+// OrgMember is a member of an organization as listed to other members.
+type OrgMember struct {
+	UserID   string
+	Name     string
+	Email    string
+	Role     string
+	JoinedAt int64
+}
+
+// CreateOrganization creates an organization in the user's tenant with the
+// user as its owner.
+func (s *AuthService) CreateOrganization(ctx context.Context, userID, name string) (*model.Organization, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, errors.New("name is required")
+	}
+	oid, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, errors.New("invalid user ID")
+	}
+	user, err := s.repo.FindByID(ctx, oid)
+	if err != nil {
+		return nil, errors.New("user not found")
+	}
+
+	org := &model.Organization{TenantID: user.TenantID, Name: name, CreatedBy: oid}
+	if err := s.orgRepo.Create(ctx, org); err != nil {
+		return nil, errors.New("failed to create organization")
+	}
+	if err := s.membershipRepo.Create(ctx, &model.Membership{OrgID: org.ID, UserID: oid, Role: OrgRoleOwner}); err != nil {
+		return nil, errors.New("failed to create organization")
+	}
+
+	s.recordOrgEvent(ctx, userID, "", audit.ActionOrgCreate, org.ID.Hex(), nil)
+	return org, nil
+}
+
+func (s *AuthService) ListMyOrganizations(ctx context.Context, userID string) ([]OrgMembership, error) {
+	oid, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, errors.New("invalid user ID")
+	}
+	memberships, err := s.membershipRepo.ListByUser(ctx, oid)
+	if err != nil {
+		return nil, err
+	}
+	if len(memberships) == 0 {
+		return nil, nil
+	}
+
+	ids := make([]primitive.ObjectID, 0, len(memberships))
+	for _, m := range memberships {
+		ids = append(ids, m.OrgID)
+	}
+	orgs, err := s.orgRepo.FindByIDs(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	byID := make(map[primitive.ObjectID]model.Organization, len(orgs))
+	for _, o := range orgs {
+		byID[o.ID] = o
+	}
+
+	result := make([]OrgMembership, 0, len(memberships))
+	for _, m := range memberships {
+		if org, ok := byID[m.OrgID]; ok {
+			result = append(result, OrgMembership{Organization: org, Role: m.Role})
+		}
+	}
+	return result, nil
+}
+
+// DeleteOrganization removes an organization with its memberships and
+// pending invitations. Only owners can delete it.
+func (s *AuthService) DeleteOrganization(ctx context.Context, actorID, orgID string) error {
+	org, _, err := s.requireOrgRole(ctx, actorID, orgID, OrgRoleOwner)
+	if err != nil {
+		return err
+	}
+	if err := s.membershipRepo.DeleteByOrg(ctx, org.ID); err != nil {
+		return err
+	}
+	if err := s.invitationRepo.DeleteByOrg(ctx, org.ID); err != nil {
+		return err
+	}
+	if err := s.orgRepo.Delete(ctx, org.ID); err != nil {
+		return err
+	}
+	s.recordOrgEvent(ctx, actorID, "", audit.ActionOrgDelete, orgID, nil)
+	return nil
+}
+
+// ListOrgMembers lists an organization's members to any of its members.
+func (s *AuthService) ListOrgMembers(ctx context.Context, actorID, orgID string) ([]OrgMember, error) {
+	org, _, err := s.requireOrgRole(ctx, actorID, orgID, OrgRoleMember)
+	if err != nil {
+		return nil, err
+	}
+	memberships, err := s.membershipRepo.ListByOrg(ctx, org.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	members := make([]OrgMember, 0, len(memberships))
+	for _, m := range memberships {
+		user, err := s.repo.FindByID(ctx, m.UserID)
+		if err != nil || user.IsDeleted {
+			continue
+		}
+		members = append(members, OrgMember{
+			UserID:   m.UserID.Hex(),
+			Name:     user.Name,
+			Email:    user.Email,
+			Role:     m.Role,
+			JoinedAt: m.CreatedAt,
+		})
+	}
+	return members, nil
+}
+
+// UpdateMemberRole changes a member's role. Admins manage admins and members;
+// only owners can make or unmake owners, and the last owner can't step down.
+func (s *AuthService) UpdateMemberRole(ctx context.Context, actorID, orgID, userID, role string) error {
+	if _, ok := orgRoleRank[role]; !ok {
+		return errors.New("role must be owner, admin or member")
+	}
+	org, actorRole, err := s.requireOrgRole(ctx, actorID, orgID, OrgRoleAdmin)
+	if err != nil {
+		return err
+	}
+	target, err := s.findMembership(ctx, org.ID, userID)
+	if err != nil {
+		return err
+	}
+	if (role == OrgRoleOwner || target.Role == OrgRoleOwner) && actorRole != OrgRoleOwner {
+		return errors.New("only owners can change owners")
+	}
+	if target.Role == OrgRoleOwner && role != OrgRoleOwner {
+		if err := s.keepAnOwner(ctx, org.ID); err != nil {
+			return err
+		}
+	}
+
+	if err := s.membershipRepo.UpdateRole(ctx, org.ID, target.UserID, role); err != nil {
+		return err
+	}
+	s.recordOrgEvent(ctx, actorID, userID, audit.ActionOrgMemberUpdate, orgID, map[string]string{"role": role})
+	return nil
+}
+
+// RemoveMember takes a user out of an organization. Members can always leave;
+// removing someone else takes an admin, and removing an owner an owner.
+func (s *AuthService) RemoveMember(ctx context.Context, actorID, orgID, userID string) error {
+	minRole := OrgRoleAdmin
+	if actorID == userID {
+		minRole = OrgRoleMember
+	}
+	org, actorRole, err := s.requireOrgRole(ctx, actorID, orgID, minRole)
+	if err != nil {
+		return err
+	}
+	target, err := s.findMembership(ctx, org.ID, userID)
+	if err != nil {
+		return err
+	}
+	if target.Role == OrgRoleOwner {
+		if actorRole != OrgRoleOwner {
+			return errors.New("only owners can remove owners")
+		}
+		if err := s.keepAnOwner(ctx, org.ID); err != nil {
+			return err
+		}
+	}
+
+	if err := s.membershipRepo.Delete(ctx, org.ID, target.UserID); err != nil {
+		return err
+	}
+	s.recordOrgEvent(ctx, actorID, userID, audit.ActionOrgMemberRemove, orgID, nil)
+	return nil
+}
+
+// SwitchOrganization issues a token for the same session, scoped to one of
+// the caller's organizations; an empty orgID drops the organization. The new
+// token expires with the current one.
+func (s *AuthService) SwitchOrganization(ctx context.Context, claims *utils.AuthClaims, orgID string) (*LoginResult, error) {
+	if claims.SessionID == "" {
+		return nil, errors.New("only session tokens can switch organization")
+	}
+
+	scoped := *claims
+	scoped.OrgID, scoped.OrgRole = "", ""
+	if orgID != "" {
+		org, role, err := s.requireOrgRole(ctx, claims.Subject, orgID, OrgRoleMember)
+		if err != nil {
+			return nil, err
+		}
+		scoped.OrgID, scoped.OrgRole = org.ID.Hex(), role
+	}
+
+	ttl := time.Until(claims.ExpiresAt.Time)
+	if ttl <= 0 {
+		return nil, errors.New("token expired")
+	}
+	var audience []string
+	for _, aud := range claims.Audience {
+		if aud != s.Cfg.TokenAudience {
+			audience = append(audience, aud)
+		}
+	}
+	token, err := s.tokens.Issue(&scoped, ttl, audience...)
+	if err != nil {
+		return nil, errors.New("failed to generate token")
+	}
+	return &LoginResult{Token: token, ExpiresIn: int64(ttl.Seconds()), Scope: scoped.Scope}, nil
+}
+
+// checkOrgClaims rejects a token whose org claims no longer hold because the
+// user was removed, their role changed or the organization was deleted.
+func (s *AuthService) checkOrgClaims(ctx context.Context, claims *utils.AuthClaims) error {
+	if claims.OrgID == "" {
+		return nil
+	}
+	oid, err := primitive.ObjectIDFromHex(claims.OrgID)
+	if err != nil {
+		return errors.New("invalid organization")
+	}
+	membership, err := s.findMembership(ctx, oid, claims.Subject)
+	if err != nil || membership.Role != claims.OrgRole {
+		return errors.New("organization membership changed, switch organization again")
+	}
+	return nil
+}
+
+// requireOrgRole loads an organization and checks the user is a member with
+// at least minRole, returning their actual role.
+func (s *AuthService) requireOrgRole(ctx context.Context, userID, orgID, minRole string) (*model.Organization, string, error) {
+	oid, err := primitive.ObjectIDFromHex(orgID)
+	if err != nil {
+		return nil, "", errors.New("invalid organization ID")
+	}
+	org, err := s.orgRepo.FindByID(ctx, oid)
+	if err != nil {
+		return nil, "", errors.New("organization not found")
+	}
+	membership, err := s.findMembership(ctx, org.ID, userID)
+	if err != nil {
+		// Outsiders can't tell the organization exists
+		return nil, "", errors.New("organization not found")
+	}
+	if orgRoleRank[membership.Role] < orgRoleRank[minRole] {
+		return nil, "", errors.New("requires organization role " + minRole)
+	}
+	return org, membership.Role, nil
+}
+
+func (s *AuthService) findMembership(ctx context.Context, orgID primitive.ObjectID, userID string) (*model.Membership, error) {
+	uid, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, errors.New("invalid user ID")
+	}
+	membership, err := s.membershipRepo.Find(ctx, orgID, uid)
+	if err != nil {
+		return nil, errors.New("not a member")
+	}
+	return membership, nil
+}
+
+func (s *AuthService) keepAnOwner(ctx context.Context, orgID primitive.ObjectID) error {
+	owners, err := s.membershipRepo.CountByRole(ctx, orgID, OrgRoleOwner)
+	if err != nil {
+		return err
+	}
+	if owners <= 1 {
+		return errors.New("an organization needs at least one owner")
+	}
+	return nil
+}
+
+func (s *AuthService) recordOrgEvent(ctx context.Context, actorID, subjectID, action, orgID string, details map[string]string) {
+	if details == nil {
+		details = map[string]string{}
+	}
+	details["org_id"] = orgID
+	s.audit.Record(ctx, model.AuditEvent{
+		ActorID:   actorID,
+		SubjectID: subjectID,
+		Action:    action,
+		Outcome:   audit.OutcomeSuccess,
+		Details:   details,
+	})
+}
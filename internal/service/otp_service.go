@@ -0,0 +1,220 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/bekbek22/auth_service/internal/audit"
+	"github.com/bekbek22/auth_service/internal/model"
+	"github.com/bekbek22/auth_service/internal/utils"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	otpDigits      = 6
+	otpMaxAttempts = 5
+)
+
+var phoneNumberRegex = regexp.MustCompile(`^\+[1-9][0-9]{6,14}$`)
+
+// sendOTP generates a code, stores its hash and delivers it through the notifier.
+func (s *AuthService) sendOTP(ctx context.Context, userID primitive.ObjectID, purpose string, challengeID primitive.ObjectID, channel, destination string) error {
+	if !s.otpSendLimiter.Allow(userID.Hex()) {
+		return errors.New("too many codes requested, please wait")
+	}
+
+	code, err := utils.GenerateNumericCode(otpDigits)
+	if err != nil {
+		return errors.New("failed to generate code")
+	}
+	hashed, err := utils.HashPassword(code)
+	if err != nil {
+		return errors.New("failed to hash code")
+	}
+
+	err = s.otpRepo.Replace(ctx, &model.OTPCode{
+		UserID:      userID,
+		Purpose:     purpose,
+		ChallengeID: challengeID,
+		CodeHash:    hashed,
+		Channel:     channel,
+		Destination: destination,
+		ExpiresAt:   time.Now().Add(s.Cfg.OTPCodeTTL).Unix(),
+	})
+	if err != nil {
+		return errors.New("failed to save code")
+	}
+
+	body := fmt.Sprintf("Your verification code is %s. It expires in %d minutes.", code, int(s.Cfg.OTPCodeTTL.Minutes()))
+	if err := s.notifier.Notify(ctx, destination, "Your verification code", body); err != nil {
+		return errors.New("failed to send code")
+	}
+	return nil
+}
+
+// checkOTP verifies an entered code and deletes it on success. Each code can
+// be guessed at most otpMaxAttempts times.
+func (s *AuthService) checkOTP(ctx context.Context, userID primitive.ObjectID, purpose string, challengeID primitive.ObjectID, code string) (*model.OTPCode, error) {
+	otp, err := s.otpRepo.FindActive(ctx, userID, purpose)
+	if err != nil || otp.ChallengeID != challengeID {
+		return nil, errors.New("no active code, request a new one")
+	}
+
+	if err := s.otpRepo.UseAttempt(ctx, otp.ID, otpMaxAttempts); err != nil {
+		_ = s.otpRepo.Delete(ctx, otp.ID)
+		return nil, errors.New("too many attempts, request a new code")
+	}
+	if !utils.CheckPasswordHash(strings.TrimSpace(code), otp.CodeHash) {
+		return nil, errors.New("invalid code")
+	}
+
+	if err := s.otpRepo.Delete(ctx, otp.ID); err != nil {
+		return nil, errors.New("no active code, request a new one")
+	}
+	return otp, nil
+}
+
+// EnrollOTP sends a code to the chosen destination. The factor is enabled once
+// the code comes back through ConfirmOTPEnrollment.
+func (s *AuthService) EnrollOTP(ctx context.Context, userID, channel, phoneNumber string) error {
+	oid, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return errors.New("invalid user ID")
+	}
+	user, err := s.repo.FindByID(ctx, oid)
+	if err != nil {
+		return errors.New("user not found")
+	}
+
+	var destination string
+	switch channel {
+	case model.OTPChannelEmail:
+		destination = user.Email
+	case model.OTPChannelSMS:
+		if !phoneNumberRegex.MatchString(phoneNumber) {
+			return errors.New("phone number must be in E.164 format, e.g. +15551234567")
+		}
+		destination = phoneNumber
+	default:
+		return errors.New("channel must be email or sms")
+	}
+
+	return s.sendOTP(ctx, oid, model.OTPPurposeEnroll, primitive.NilObjectID, channel, destination)
+}
+
+func (s *AuthService) ConfirmOTPEnrollment(ctx context.Context, userID, code string) error {
+	oid, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return errors.New("invalid user ID")
+	}
+
+	otp, err := s.checkOTP(ctx, oid, model.OTPPurposeEnroll, primitive.NilObjectID, code)
+	if err != nil {
+		return err
+	}
+
+	factor := &model.OTPFactor{
+		Channel:     otp.Channel,
+		Destination: otp.Destination,
+		EnabledAt:   time.Now().Unix(),
+	}
+	if err := s.repo.UpdateUserByID(ctx, oid, bson.M{"otp_factor": factor}); err != nil {
+		return err
+	}
+
+	s.audit.Record(ctx, model.AuditEvent{
+		ActorID:   userID,
+		SubjectID: userID,
+		Action:    audit.ActionOTPEnable,
+		Outcome:   audit.OutcomeSuccess,
+		Details:   map[string]string{"channel": otp.Channel},
+	})
+	return nil
+}
+
+func (s *AuthService) DisableOTP(ctx context.Context, userID string) error {
+	oid, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return errors.New("invalid user ID")
+	}
+	user, err := s.repo.FindByID(ctx, oid)
+	if err != nil {
+		return errors.New("user not found")
+	}
+	if user.OTPFactor == nil {
+		return errors.New("one-time passcodes are not enabled")
+	}
+
+	if err := s.repo.UpdateUserByID(ctx, oid, bson.M{"otp_factor": nil}); err != nil {
+		return err
+	}
+
+	s.audit.Record(ctx, model.AuditEvent{
+		ActorID:   userID,
+		SubjectID: userID,
+		Action:    audit.ActionOTPDisable,
+		Outcome:   audit.OutcomeSuccess,
+	})
+	return nil
+}
+
+// SendLoginOTP sends a code for a pending MFA challenge and returns the masked
+// destination it went to.
+func (s *AuthService) SendLoginOTP(ctx context.Context, mfaToken string) (string, error) {
+	challenge, err := s.findMFAChallenge(ctx, mfaToken)
+	if err != nil {
+		return "", err
+	}
+	user, err := s.repo.FindByID(ctx, challenge.UserID)
+	if err != nil {
+		return "", errors.New("user not found")
+	}
+	if user.OTPFactor == nil {
+		return "", errors.New("one-time passcodes are not enabled")
+	}
+
+	destination := otpDestination(user)
+	if err := s.sendOTP(ctx, user.ID, model.OTPPurposeLogin, challenge.ID, user.OTPFactor.Channel, destination); err != nil {
+		return "", err
+	}
+	return maskDestination(destination), nil
+}
+
+// otpDestination is where a user's login codes go. Email codes follow the
+// account's current address, so a confirmed email change also moves them.
+func otpDestination(user *model.User) string {
+	if user.OTPFactor.Channel == model.OTPChannelEmail {
+		return user.Email
+	}
+	return user.OTPFactor.Destination
+}
+
+// VerifyLoginOTP completes a pending MFA challenge with the code that was sent.
+func (s *AuthService) VerifyLoginOTP(ctx context.Context, mfaToken, code string) (*LoginResult, error) {
+	challenge, err := s.findMFAChallenge(ctx, mfaToken)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := s.checkOTP(ctx, challenge.UserID, model.OTPPurposeLogin, challenge.ID, code); err != nil {
+		s.recordLoginFailure(ctx, challenge.UserID.Hex(), "", "invalid_otp")
+		return nil, err
+	}
+	return s.completeMFAChallenge(ctx, challenge, model.MFAMethodOTP)
+}
+
+// maskDestination hides most of an email address or phone number.
+func maskDestination(destination string) string {
+	if at := strings.Index(destination, "@"); at > 0 {
+		return destination[:1] + "***" + destination[at:]
+	}
+	if len(destination) > 4 {
+		return "***" + destination[len(destination)-4:]
+	}
+	return "***"
+}
@@ -0,0 +1,394 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/bekbek22/auth_service/internal/audit"
+	"github.com/bekbek22/auth_service/internal/model"
+	"github.com/bekbek22/auth_service/internal/utils"
+	"github.com/go-webauthn/webauthn/protocol"
+	"github.com/go-webauthn/webauthn/webauthn"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const webauthnCeremonyTTL = 5 * time.Minute
+
+// passkeyUser adapts a user and their passkey identities to webauthn.User.
+type passkeyUser struct {
+	user       *model.User
+	identities []model.Identity
+}
+
+func (u *passkeyUser) WebAuthnID() []byte {
+	return u.user.ID[:]
+}
+
+func (u *passkeyUser) WebAuthnName() string {
+	return u.user.Email
+}
+
+func (u *passkeyUser) WebAuthnDisplayName() string {
+	return u.user.Name
+}
+
+func (u *passkeyUser) WebAuthnCredentials() []webauthn.Credential {
+	credentials := make([]webauthn.Credential, 0, len(u.identities))
+	for _, identity := range u.identities {
+		credentials = append(credentials, toWebAuthnCredential(identity))
+	}
+	return credentials
+}
+
+// identityFor returns the passkey identity holding the credential ID.
+func (u *passkeyUser) identityFor(credentialID []byte) *model.Identity {
+	for i := range u.identities {
+		id, err := base64.RawURLEncoding.DecodeString(u.identities[i].Subject)
+		if err == nil && bytes.Equal(id, credentialID) {
+			return &u.identities[i]
+		}
+	}
+	return nil
+}
+
+func toWebAuthnCredential(identity model.Identity) webauthn.Credential {
+	id, _ := base64.RawURLEncoding.DecodeString(identity.Subject)
+	pk := identity.Passkey
+	transports := make([]protocol.AuthenticatorTransport, 0, len(pk.Transports))
+	for _, t := range pk.Transports {
+		transports = append(transports, protocol.AuthenticatorTransport(t))
+	}
+	return webauthn.Credential{
+		ID:              id,
+		PublicKey:       pk.PublicKey,
+		AttestationType: pk.AttestationType,
+		Transport:       transports,
+		Flags:           webauthn.NewCredentialFlags(protocol.AuthenticatorFlags(pk.Flags)),
+		Authenticator: webauthn.Authenticator{
+			AAGUID:       pk.AAGUID,
+			SignCount:    pk.SignCount,
+			CloneWarning: pk.CloneWarning,
+		},
+	}
+}
+
+func (s *AuthService) loadPasskeyUser(ctx context.Context, user *model.User) (*passkeyUser, error) {
+	identities, err := s.identityRepo.ListByUser(ctx, user.ID)
+	if err != nil {
+		return nil, errors.New("failed to load passkeys")
+	}
+
+	pu := &passkeyUser{user: user}
+	for _, identity := range identities {
+		if identity.Type == model.LoginMethodPasskey && identity.Passkey != nil {
+			pu.identities = append(pu.identities, identity)
+		}
+	}
+	return pu, nil
+}
+
+func (s *AuthService) saveCeremony(ctx context.Context, purpose string, userID primitive.ObjectID, session *webauthn.SessionData) (string, error) {
+	data, err := json.Marshal(session)
+	if err != nil {
+		return "", errors.New("failed to encode ceremony")
+	}
+	ceremonyID, err := utils.GenerateOpaqueToken(32)
+	if err != nil {
+		return "", errors.New("failed to generate ceremony ID")
+	}
+
+	err = s.ceremonies.Save(ctx, &model.WebAuthnCeremony{
+		CeremonyHash: utils.HashToken(ceremonyID),
+		Purpose:      purpose,
+		UserID:       userID,
+		Session:      data,
+		ExpiresAt:    time.Now().Add(webauthnCeremonyTTL).Unix(),
+	})
+	if err != nil {
+		return "", errors.New("failed to save ceremony")
+	}
+	return ceremonyID, nil
+}
+
+func (s *AuthService) consumeCeremony(ctx context.Context, ceremonyID, purpose string) (*model.WebAuthnCeremony, webauthn.SessionData, error) {
+	var session webauthn.SessionData
+	ceremony, err := s.ceremonies.Consume(ctx, utils.HashToken(ceremonyID), purpose)
+	if err != nil {
+		return nil, session, errors.New("invalid or expired ceremony")
+	}
+	if err := json.Unmarshal(ceremony.Session, &session); err != nil {
+		return nil, session, errors.New("invalid or expired ceremony")
+	}
+	return ceremony, session, nil
+}
+
+// BeginPasskeyRegistration starts adding a passkey to the caller's account.
+// It returns the ceremony ID and the JSON options for
+// navigator.credentials.create().
+func (s *AuthService) BeginPasskeyRegistration(ctx context.Context, userID string) (string, []byte, error) {
+	oid, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return "", nil, errors.New("invalid user ID")
+	}
+	user, err := s.repo.FindByID(ctx, oid)
+	if err != nil {
+		return "", nil, errors.New("user not found")
+	}
+	pu, err := s.loadPasskeyUser(ctx, user)
+	if err != nil {
+		return "", nil, err
+	}
+
+	exclusions := make([]protocol.CredentialDescriptor, 0, len(pu.identities))
+	for _, c := range pu.WebAuthnCredentials() {
+		exclusions = append(exclusions, c.Descriptor())
+	}
+
+	creation, session, err := s.passkeys.BeginRegistration(pu,
+		webauthn.WithExclusions(exclusions),
+		webauthn.WithResidentKeyRequirement(protocol.ResidentKeyRequirementPreferred),
+	)
+	if err != nil {
+		return "", nil, errors.New("failed to start registration")
+	}
+
+	ceremonyID, err := s.saveCeremony(ctx, model.CeremonyRegistration, oid, session)
+	if err != nil {
+		return "", nil, err
+	}
+	options, err := json.Marshal(creation)
+	if err != nil {
+		return "", nil, errors.New("failed to encode options")
+	}
+	return ceremonyID, options, nil
+}
+
+// FinishPasskeyRegistration verifies the authenticator's response and stores
+// the new passkey.
+func (s *AuthService) FinishPasskeyRegistration(ctx context.Context, userID, ceremonyID string, credentialJSON []byte, name string) (*model.Identity, error) {
+	oid, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, errors.New("invalid user ID")
+	}
+	ceremony, session, err := s.consumeCeremony(ctx, ceremonyID, model.CeremonyRegistration)
+	if err != nil {
+		return nil, err
+	}
+	if ceremony.UserID != oid {
+		return nil, errors.New("invalid or expired ceremony")
+	}
+
+	user, err := s.repo.FindByID(ctx, oid)
+	if err != nil {
+		return nil, errors.New("user not found")
+	}
+	pu, err := s.loadPasskeyUser(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+
+	parsed, err := protocol.ParseCredentialCreationResponseBytes(credentialJSON)
+	if err != nil {
+		return nil, errors.New("invalid credential")
+	}
+	credential, err := s.passkeys.CreateCredential(pu, session, parsed)
+	if err != nil {
+		s.audit.Record(ctx, model.AuditEvent{
+			ActorID:   userID,
+			SubjectID: userID,
+			Action:    audit.ActionPasskeyRegister,
+			Outcome:   audit.OutcomeFailure,
+			Details:   map[string]string{"reason": err.Error()},
+		})
+		return nil, errors.New("passkey verification failed")
+	}
+
+	if strings.TrimSpace(name) == "" {
+		name = "Passkey"
+	}
+	transports := make([]string, 0, len(credential.Transport))
+	for _, t := range credential.Transport {
+		transports = append(transports, string(t))
+	}
+	identity := &model.Identity{
+		UserID:   oid,
+		Type:     model.LoginMethodPasskey,
+		Provider: model.PasskeyProvider,
+		Subject:  base64.RawURLEncoding.EncodeToString(credential.ID),
+		Name:     name,
+		Passkey: &model.PasskeyCredential{
+			PublicKey:       credential.PublicKey,
+			AttestationType: credential.AttestationType,
+			AAGUID:          credential.Authenticator.AAGUID,
+			SignCount:       credential.Authenticator.SignCount,
+			Flags:           byte(credential.Flags.ProtocolValue()),
+			Transports:      transports,
+		},
+	}
+	if err := s.identityRepo.Create(ctx, identity); err != nil {
+		return nil, errors.New("failed to save passkey")
+	}
+
+	s.audit.Record(ctx, model.AuditEvent{
+		ActorID:   userID,
+		SubjectID: userID,
+		Action:    audit.ActionPasskeyRegister,
+		Outcome:   audit.OutcomeSuccess,
+		Details:   map[string]string{"identity_id": identity.ID.Hex()},
+	})
+	return identity, nil
+}
+
+// BeginPasskeyLogin starts a passkey login and returns the ceremony ID and the
+// JSON options for navigator.credentials.get(). With an mfaToken the passkey
+// completes a password login; with an email only that user's passkeys are
+// allowed; with neither the browser offers any discoverable passkey.
+func (s *AuthService) BeginPasskeyLogin(ctx context.Context, email, mfaToken string) (string, []byte, error) {
+	var user *model.User
+	switch {
+	case mfaToken != "":
+		challenge, err := s.findMFAChallenge(ctx, mfaToken)
+		if err != nil {
+			return "", nil, err
+		}
+		if user, err = s.repo.FindByID(ctx, challenge.UserID); err != nil {
+			return "", nil, errors.New("user not found")
+		}
+	case email != "":
+		tenantID, err := s.requestTenant(ctx)
+		if err != nil {
+			return "", nil, err
+		}
+		if user, err = s.repo.FindByEmail(ctx, tenantID, strings.ToLower(email)); err != nil {
+			return "", nil, errors.New("user not found")
+		}
+	}
+
+	var (
+		assertion *protocol.CredentialAssertion
+		session   *webauthn.SessionData
+		userID    primitive.ObjectID
+		err       error
+	)
+	if user != nil {
+		pu, err := s.loadPasskeyUser(ctx, user)
+		if err != nil {
+			return "", nil, err
+		}
+		if len(pu.identities) == 0 {
+			return "", nil, errors.New("no passkeys registered")
+		}
+		userID = user.ID
+		assertion, session, err = s.passkeys.BeginLogin(pu)
+		if err != nil {
+			return "", nil, errors.New("failed to start login")
+		}
+	} else {
+		assertion, session, err = s.passkeys.BeginDiscoverableLogin()
+		if err != nil {
+			return "", nil, errors.New("failed to start login")
+		}
+	}
+
+	ceremonyID, err := s.saveCeremony(ctx, model.CeremonyLogin, userID, session)
+	if err != nil {
+		return "", nil, err
+	}
+	options, err := json.Marshal(assertion)
+	if err != nil {
+		return "", nil, errors.New("failed to encode options")
+	}
+	return ceremonyID, options, nil
+}
+
+// FinishPasskeyLogin verifies the authenticator's assertion. It completes the
+// MFA challenge named by mfaToken, or signs the user in directly.
+func (s *AuthService) FinishPasskeyLogin(ctx context.Context, ceremonyID string, credentialJSON []byte, mfaToken, deviceName, clientID string) (*LoginResult, error) {
+	var clientTenantID string
+	if mfaToken == "" {
+		var err error
+		if clientTenantID, err = s.loginTenant(ctx, clientID); err != nil {
+			return nil, err
+		}
+	}
+
+	ceremony, session, err := s.consumeCeremony(ctx, ceremonyID, model.CeremonyLogin)
+	if err != nil {
+		return nil, err
+	}
+	parsed, err := protocol.ParseCredentialRequestResponseBytes(credentialJSON)
+	if err != nil {
+		return nil, errors.New("invalid credential")
+	}
+
+	var (
+		pu         *passkeyUser
+		credential *webauthn.Credential
+	)
+	if !ceremony.UserID.IsZero() {
+		user, err := s.repo.FindByID(ctx, ceremony.UserID)
+		if err != nil {
+			return nil, errors.New("user not found")
+		}
+		if pu, err = s.loadPasskeyUser(ctx, user); err != nil {
+			return nil, err
+		}
+		credential, err = s.passkeys.ValidateLogin(pu, session, parsed)
+	} else {
+		credential, err = s.passkeys.ValidateDiscoverableLogin(func(rawID, userHandle []byte) (webauthn.User, error) {
+			if len(userHandle) != len(primitive.ObjectID{}) {
+				return nil, errors.New("unknown user handle")
+			}
+			user, err := s.repo.FindByID(ctx, primitive.ObjectID(userHandle))
+			if err != nil {
+				return nil, errors.New("user not found")
+			}
+			if pu, err = s.loadPasskeyUser(ctx, user); err != nil {
+				return nil, err
+			}
+			return pu, nil
+		}, session, parsed)
+	}
+	if err != nil || pu == nil {
+		subjectID := ""
+		if !ceremony.UserID.IsZero() {
+			subjectID = ceremony.UserID.Hex()
+		}
+		s.recordLoginFailure(ctx, subjectID, "", "invalid_passkey")
+		return nil, errors.New("passkey verification failed")
+	}
+
+	identity := pu.identityFor(credential.ID)
+	if identity == nil {
+		return nil, errors.New("passkey verification failed")
+	}
+	_ = s.identityRepo.UpdatePasskeyUsage(ctx, identity.ID, credential.Authenticator.SignCount,
+		credential.Authenticator.CloneWarning, byte(credential.Flags.ProtocolValue()))
+	if credential.Authenticator.CloneWarning {
+		// The sign counter went backwards: another copy of the key may exist
+		s.recordLoginFailure(ctx, pu.user.ID.Hex(), pu.user.Email, "passkey_clone_warning")
+		return nil, errors.New("passkey verification failed")
+	}
+
+	if mfaToken != "" {
+		challenge, err := s.findMFAChallenge(ctx, mfaToken)
+		if err != nil {
+			return nil, err
+		}
+		if challenge.UserID != pu.user.ID || ceremony.UserID != pu.user.ID {
+			return nil, errors.New("invalid or expired mfa token")
+		}
+		return s.completeMFAChallenge(ctx, challenge, model.LoginMethodPasskey)
+	}
+
+	if clientID != "" && pu.user.TenantID != clientTenantID {
+		s.recordLoginFailure(ctx, pu.user.ID.Hex(), "", "wrong_tenant")
+		return nil, errors.New("client belongs to another tenant")
+	}
+	return s.completeLogin(ctx, pu.user, deviceName, clientID, "", map[string]string{"method": model.LoginMethodPasskey})
+}
@@ -0,0 +1,57 @@
+package service
+
+import (
+	"slices"
+
+	"github.com/bekbek22/auth_service/internal/utils"
+)
+
+// PermUsersImpersonate allows ImpersonateUser. The other permissions share
+// their names with the service account scopes that open the same RPCs.
+const PermUsersImpersonate = "users:impersonate"
+
+// rolePermissions lists what each role may do beyond managing its own
+// account. Roles other than user can be granted through groups.
+var rolePermissions = map[string][]string{
+	"admin":   {ScopeUsersRead, ScopeAuditRead, ScopeClientsRead, ScopeTokensIntrospect, ScopeAuthzCheck, PermUsersImpersonate},
+	"support": {ScopeUsersRead, PermUsersImpersonate},
+	"auditor": {ScopeAuditRead},
+	"user":    {},
+}
+
+// platformGroupRoles can only be granted through groups of the default
+// tenant, whose admins are platform admins.
+var platformGroupRoles = []string{"support", "auditor"}
+
+// IsGroupRole reports whether role can be granted through a group of tenantID.
+func IsGroupRole(tenantID, role string) bool {
+	_, ok := rolePermissions[role]
+	if !ok || role == "user" {
+		return false
+	}
+	return tenantID == "" || !slices.Contains(platformGroupRoles, role)
+}
+
+// PermissionsForRoles returns the union of the permissions of roles, sorted.
+func PermissionsForRoles(roles []string) []string {
+	var perms []string
+	for _, role := range roles {
+		for _, p := range rolePermissions[role] {
+			if !slices.Contains(perms, p) {
+				perms = append(perms, p)
+			}
+		}
+	}
+	slices.Sort(perms)
+	return perms
+}
+
+// HasPermission reports whether any of the roles in claims grants perm.
+func HasPermission(claims *utils.AuthClaims, perm string) bool {
+	for _, role := range claims.AllRoles() {
+		if slices.Contains(rolePermissions[role], perm) {
+			return true
+		}
+	}
+	return false
+}
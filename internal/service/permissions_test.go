@@ -0,0 +1,51 @@
+package service
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/bekbek22/auth_service/internal/utils"
+)
+
+func TestPermissionsForRoles(t *testing.T) {
+	tests := []struct {
+		name  string
+		roles []string
+		want  []string
+	}{
+		{name: "user", roles: []string{"user"}, want: nil},
+		{name: "single role", roles: []string{"user", "auditor"}, want: []string{ScopeAuditRead}},
+		{name: "union", roles: []string{"user", "support", "auditor"}, want: []string{ScopeAuditRead, PermUsersImpersonate, ScopeUsersRead}},
+		{name: "overlap counted once", roles: []string{"admin", "support"}, want: PermissionsForRoles([]string{"admin"})},
+		{name: "unknown role", roles: []string{"user", "owner"}, want: nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := PermissionsForRoles(tt.roles); !slices.Equal(got, tt.want) {
+				t.Fatalf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHasPermission(t *testing.T) {
+	tests := []struct {
+		name   string
+		claims utils.AuthClaims
+		perm   string
+		want   bool
+	}{
+		{name: "own role", claims: utils.AuthClaims{Role: "admin"}, perm: ScopeAuditRead, want: true},
+		{name: "group role", claims: utils.AuthClaims{Role: "user", Roles: []string{"support"}}, perm: PermUsersImpersonate, want: true},
+		{name: "second group role", claims: utils.AuthClaims{Role: "user", Roles: []string{"support", "auditor"}}, perm: ScopeAuditRead, want: true},
+		{name: "not granted", claims: utils.AuthClaims{Role: "user", Roles: []string{"auditor"}}, perm: PermUsersImpersonate, want: false},
+		{name: "plain user", claims: utils.AuthClaims{Role: "user"}, perm: ScopeUsersRead, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := HasPermission(&tt.claims, tt.perm); got != tt.want {
+				t.Fatalf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
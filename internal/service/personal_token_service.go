@@ -0,0 +1,179 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/bekbek22/auth_service/internal/audit"
+	"github.com/bekbek22/auth_service/internal/model"
+	"github.com/bekbek22/auth_service/internal/utils"
+	"github.com/golang-jwt/jwt/v5"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ScopeAccount lets a personal access token use the caller's own account:
+// profile, sessions, login methods and so on. Admins can also grant their
+// tokens the read scopes service accounts use.
+const ScopeAccount = "account"
+
+const (
+	patPrefix               = "pat_"
+	patDefaultLifetimeDays  = 30
+	patMaxLifetimeDays      = 365
+	maxPersonalAccessTokens = 50
+)
+
+// CreatePersonalAccessToken issues a long-lived token for scripts. The
+// plaintext token is returned once and only its hash is stored.
+func (s *AuthService) CreatePersonalAccessToken(ctx context.Context, userID, name string, scopes []string, expiresInDays int32) (*model.PersonalAccessToken, string, error) {
+	oid, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, "", errors.New("invalid user ID")
+	}
+	user, err := s.repo.FindByID(ctx, oid)
+	if err != nil {
+		return nil, "", errors.New("user not found")
+	}
+
+	if strings.TrimSpace(name) == "" {
+		return nil, "", errors.New("name is required")
+	}
+	if len(scopes) == 0 {
+		return nil, "", errors.New("at least one scope is required")
+	}
+	roles, err := s.GroupRoles(ctx, oid)
+	if err != nil {
+		return nil, "", errors.New("failed to resolve roles")
+	}
+	holder := &utils.AuthClaims{Role: user.Role, Roles: roles}
+	for _, scope := range scopes {
+		switch {
+		case scope == ScopeAccount:
+		case slices.Contains(serviceAccountScopes, scope):
+			if !HasPermission(holder, scope) {
+				return nil, "", errors.New("scope not granted by your roles: " + scope)
+			}
+		default:
+			return nil, "", errors.New("unsupported scope: " + scope)
+		}
+	}
+	if expiresInDays == 0 {
+		expiresInDays = patDefaultLifetimeDays
+	}
+	if expiresInDays < 0 || expiresInDays > patMaxLifetimeDays {
+		return nil, "", errors.New("expires_in_days must be between 1 and 365")
+	}
+
+	existing, err := s.patRepo.ListByUser(ctx, oid)
+	if err != nil {
+		return nil, "", errors.New("failed to list tokens")
+	}
+	active := 0
+	for _, t := range existing {
+		if t.RevokedAt == 0 && t.ExpiresAt > time.Now().Unix() {
+			active++
+		}
+	}
+	if active >= maxPersonalAccessTokens {
+		return nil, "", errors.New("too many active tokens, revoke one first")
+	}
+
+	prefix, plaintext, err := newPrefixedSecret(patPrefix)
+	if err != nil {
+		return nil, "", errors.New("failed to generate token")
+	}
+	token := &model.PersonalAccessToken{
+		UserID:    oid,
+		Name:      name,
+		Prefix:    prefix,
+		TokenHash: utils.HashToken(plaintext),
+		Scopes:    scopes,
+		ExpiresAt: time.Now().Add(time.Duration(expiresInDays) * 24 * time.Hour).Unix(),
+	}
+	if err := s.patRepo.Create(ctx, token); err != nil {
+		return nil, "", errors.New("failed to save token")
+	}
+
+	s.audit.Record(ctx, model.AuditEvent{
+		ActorID:   userID,
+		SubjectID: userID,
+		Action:    audit.ActionPATCreate,
+		Outcome:   audit.OutcomeSuccess,
+		Details:   map[string]string{"token_id": token.ID.Hex(), "prefix": prefix, "scopes": strings.Join(scopes, " ")},
+	})
+	return token, plaintext, nil
+}
+
+func (s *AuthService) ListPersonalAccessTokens(ctx context.Context, userID string) ([]model.PersonalAccessToken, error) {
+	oid, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, errors.New("invalid user ID")
+	}
+	return s.patRepo.ListByUser(ctx, oid)
+}
+
+func (s *AuthService) RevokePersonalAccessToken(ctx context.Context, userID, tokenID string) error {
+	oid, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return errors.New("invalid user ID")
+	}
+	tid, err := primitive.ObjectIDFromHex(tokenID)
+	if err != nil {
+		return errors.New("invalid token ID")
+	}
+	if err := s.patRepo.Revoke(ctx, oid, tid); err != nil {
+		return err
+	}
+
+	s.audit.Record(ctx, model.AuditEvent{
+		ActorID:   userID,
+		SubjectID: userID,
+		Action:    audit.ActionPATRevoke,
+		Outcome:   audit.OutcomeSuccess,
+		Details:   map[string]string{"token_id": tokenID},
+	})
+	return nil
+}
+
+// AuthenticateKey resolves an opaque credential, either a service account's
+// API key or a user's personal access token, to claims for the interceptor.
+func (s *AuthService) AuthenticateKey(ctx context.Context, plaintext string) (*utils.AuthClaims, error) {
+	if strings.HasPrefix(plaintext, patPrefix) {
+		return s.authenticatePersonalAccessToken(ctx, plaintext)
+	}
+	return s.AuthenticateAPIKey(ctx, plaintext)
+}
+
+func (s *AuthService) authenticatePersonalAccessToken(ctx context.Context, plaintext string) (*utils.AuthClaims, error) {
+	token, err := s.patRepo.FindActiveByHash(ctx, utils.HashToken(plaintext))
+	if err != nil {
+		return nil, errors.New("unknown, expired or revoked token")
+	}
+	user, err := s.repo.FindByID(ctx, token.UserID)
+	if err != nil {
+		return nil, errors.New("user not found")
+	}
+
+	now := time.Now()
+	if s.touches.due("pat:"+token.ID.Hex(), now) {
+		_ = s.patRepo.TouchLastUsed(ctx, token.ID, now.Unix())
+	}
+
+	roles, err := s.GroupRoles(ctx, user.ID)
+	if err != nil {
+		return nil, errors.New("failed to resolve roles")
+	}
+	claims := &utils.AuthClaims{
+		Role:            user.Role,
+		Roles:           roles,
+		Scope:           strings.Join(token.Scopes, " "),
+		PersonalTokenID: token.ID.Hex(),
+		TenantID:        user.TenantID,
+	}
+	claims.Subject = user.ID.Hex()
+	claims.ExpiresAt = jwt.NewNumericDate(time.Unix(token.ExpiresAt, 0))
+	return claims, nil
+}
@@ -0,0 +1,216 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/bekbek22/auth_service/internal/model"
+	"github.com/bekbek22/auth_service/internal/repository"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// memoryUsers keeps users in memory. Methods the tests don't need panic
+// through the embedded nil interface.
+type memoryUsers struct {
+	repository.IUserRepository
+
+	mu    sync.Mutex
+	users []*model.User
+}
+
+func (m *memoryUsers) CreateUser(ctx context.Context, user *model.User) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	user.ID = primitive.NewObjectID()
+	m.users = append(m.users, user)
+	return nil
+}
+
+func (m *memoryUsers) FindByEmail(ctx context.Context, tenantID, email string) (*model.User, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, u := range m.users {
+		if u.TenantID == tenantID && u.Email == email && !u.IsDeleted {
+			return u, nil
+		}
+	}
+	return nil, errors.New("not found")
+}
+
+func (m *memoryUsers) FindByID(ctx context.Context, id primitive.ObjectID) (*model.User, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, u := range m.users {
+		if u.ID == id && !u.IsDeleted {
+			return u, nil
+		}
+	}
+	return nil, errors.New("not found")
+}
+
+// memoryIdentities keeps linked identities in memory.
+type memoryIdentities struct {
+	repository.IIdentityRepository
+
+	mu         sync.Mutex
+	identities []*model.Identity
+}
+
+func (m *memoryIdentities) Create(ctx context.Context, identity *model.Identity) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	identity.ID = primitive.NewObjectID()
+	m.identities = append(m.identities, identity)
+	return nil
+}
+
+func (m *memoryIdentities) FindByProviderSubject(ctx context.Context, provider, subject string) (*model.Identity, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, id := range m.identities {
+		if id.Provider == provider && id.Subject == subject {
+			return id, nil
+		}
+	}
+	return nil, errors.New("not found")
+}
+
+func (m *memoryIdentities) TouchLogin(ctx context.Context, id primitive.ObjectID) error {
+	return nil
+}
+
+// memoryGroups keeps groups in memory.
+type memoryGroups struct {
+	repository.IGroupRepository
+
+	mu     sync.Mutex
+	groups []*model.Group
+}
+
+func (m *memoryGroups) Create(ctx context.Context, group *model.Group) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	group.ID = primitive.NewObjectID()
+	m.groups = append(m.groups, group)
+	return nil
+}
+
+func (m *memoryGroups) FindByID(ctx context.Context, id primitive.ObjectID) (*model.Group, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, g := range m.groups {
+		if g.ID == id {
+			copied := *g
+			return &copied, nil
+		}
+	}
+	return nil, errors.New("not found")
+}
+
+func (m *memoryGroups) FindByIDs(ctx context.Context, ids []primitive.ObjectID) ([]model.Group, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var groups []model.Group
+	for _, g := range m.groups {
+		if slices.Contains(ids, g.ID) {
+			groups = append(groups, *g)
+		}
+	}
+	return groups, nil
+}
+
+// Update only applies the fields UpdateGroup sets.
+func (m *memoryGroups) Update(ctx context.Context, id primitive.ObjectID, updates bson.M) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, g := range m.groups {
+		if g.ID == id {
+			g.Name = updates["name"].(string)
+			g.Description = updates["description"].(string)
+			g.Roles = updates["roles"].([]string)
+			return nil
+		}
+	}
+	return errors.New("not found")
+}
+
+// memoryGroupMembers keeps group memberships in memory.
+type memoryGroupMembers struct {
+	repository.IGroupMemberRepository
+
+	mu      sync.Mutex
+	members []model.GroupMember
+}
+
+func (m *memoryGroupMembers) Add(ctx context.Context, member *model.GroupMember) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	member.ID = primitive.NewObjectID()
+	m.members = append(m.members, *member)
+	return nil
+}
+
+func (m *memoryGroupMembers) Remove(ctx context.Context, groupID, userID primitive.ObjectID) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.members = slices.DeleteFunc(m.members, func(gm model.GroupMember) bool {
+		return gm.GroupID == groupID && gm.UserID == userID
+	})
+	return nil
+}
+
+func (m *memoryGroupMembers) ListByGroup(ctx context.Context, groupID primitive.ObjectID) ([]model.GroupMember, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var members []model.GroupMember
+	for _, gm := range m.members {
+		if gm.GroupID == groupID {
+			members = append(members, gm)
+		}
+	}
+	return members, nil
+}
+
+func (m *memoryGroupMembers) ListByUser(ctx context.Context, userID primitive.ObjectID) ([]model.GroupMember, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var members []model.GroupMember
+	for _, gm := range m.members {
+		if gm.UserID == userID {
+			members = append(members, gm)
+		}
+	}
+	return members, nil
+}
+
+// memorySessions records which users were signed out.
+type memorySessions struct {
+	repository.ISessionRepository
+
+	mu        sync.Mutex
+	signedOut []primitive.ObjectID
+}
+
+func (m *memorySessions) RevokeAllByUser(ctx context.Context, userID primitive.ObjectID) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.signedOut = append(m.signedOut, userID)
+	return nil
+}
+
+func (m *memorySessions) FindRevokedSince(ctx context.Context, since int64, limit int64) ([]model.Session, error) {
+	return nil, nil
+}
+
+// emptyBlacklist is a token blacklist with nothing in it.
+type emptyBlacklist struct {
+	repository.ITokenRepository
+}
+
+func (emptyBlacklist) FindBlacklistedSince(ctx context.Context, since time.Time, limit int64) ([]model.BlacklistedToken, error) {
+	return nil, nil
+}
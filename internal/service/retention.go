@@ -0,0 +1,101 @@
+package service
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+const retentionBatchSize = 100
+
+// PurgeDeletedUsers anonymizes or removes users that were soft deleted longer
+// ago than the configured retention window. It returns how many were processed.
+func (s *AuthService) PurgeDeletedUsers(ctx context.Context) (int, error) {
+	before := time.Now().Add(-s.Cfg.DeletedUserRetention).Unix()
+	processed := 0
+
+	for {
+		users, err := s.repo.FindDeletedBefore(ctx, before, retentionBatchSize)
+		if err != nil {
+			return processed, err
+		}
+		if len(users) == 0 {
+			return processed, nil
+		}
+
+		for _, u := range users {
+			// Related records hold the same personal data
+			if err := s.emailChangeRepo.DeleteByUser(ctx, u.ID); err != nil {
+				return processed, err
+			}
+			if err := s.sessionRepo.DeleteByUser(ctx, u.ID); err != nil {
+				return processed, err
+			}
+			if err := s.refreshTokenRepo.DeleteByUser(ctx, u.ID); err != nil {
+				return processed, err
+			}
+			if err := s.identityRepo.DeleteByUser(ctx, u.ID); err != nil {
+				return processed, err
+			}
+			if err := s.mfaChallenges.DeleteByUser(ctx, u.ID); err != nil {
+				return processed, err
+			}
+			if err := s.otpRepo.DeleteByUser(ctx, u.ID); err != nil {
+				return processed, err
+			}
+			if err := s.patRepo.DeleteByUser(ctx, u.ID); err != nil {
+				return processed, err
+			}
+			if err := s.membershipRepo.DeleteByUser(ctx, u.ID); err != nil {
+				return processed, err
+			}
+			if err := s.groupMemberRepo.DeleteByUser(ctx, u.ID); err != nil {
+				return processed, err
+			}
+
+			// Events and invites naming the email after the deletion may be
+			// about a new account with the same email
+			deletedAt := u.DeletedAt
+			if deletedAt == 0 {
+				deletedAt = before
+			}
+			if err := s.auditRepo.AnonymizeByUser(ctx, u.ID.Hex(), u.Email, deletedAt); err != nil {
+				return processed, err
+			}
+			if err := s.invitationRepo.DeleteAllByEmail(ctx, u.Email, deletedAt); err != nil {
+				return processed, err
+			}
+
+			if s.Cfg.RetentionMode == "purge" {
+				err = s.repo.HardDeleteUserByID(ctx, u.ID)
+			} else {
+				err = s.repo.AnonymizeUserByID(ctx, u.ID)
+			}
+			if err != nil {
+				return processed, err
+			}
+			processed++
+		}
+	}
+}
+
+// RunRetentionJob calls PurgeDeletedUsers every interval until ctx is done.
+func (s *AuthService) RunRetentionJob(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		n, err := s.PurgeDeletedUsers(ctx)
+		if err != nil {
+			log.Printf("retention job failed: %v", err)
+		} else if n > 0 {
+			log.Printf("retention job processed %d deleted users (%s)", n, s.Cfg.RetentionMode)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,64 @@
+package service
+
+import (
+	"errors"
+	"slices"
+	"strings"
+)
+
+// Scopes a login can ask for. A token issued with scopes can only call the
+// RPCs that require one of them; a token without scopes can call everything
+// the user's roles allow. Scopes never grant more than the roles do.
+const (
+	ScopeProfileRead          = "profile:read"
+	ScopeProfileWrite         = "profile:write"
+	ScopeUsersList            = "users:list"
+	ScopeClientsWrite         = "clients:write"
+	ScopeServiceAccountsRead  = "service_accounts:read"
+	ScopeServiceAccountsWrite = "service_accounts:write"
+	ScopeTenantsRead          = "tenants:read"
+	ScopeTenantsWrite         = "tenants:write"
+	ScopeOrganizationsRead    = "organizations:read"
+	ScopeOrganizationsWrite   = "organizations:write"
+	ScopeGroupsRead           = "groups:read"
+	ScopeGroupsWrite          = "groups:write"
+	ScopePoliciesRead         = "policies:read"
+	ScopePoliciesWrite        = "policies:write"
+)
+
+// LoginScopes are the scopes Login accepts.
+var LoginScopes = []string{
+	ScopeProfileRead, ScopeProfileWrite,
+	ScopeUsersList, PermUsersImpersonate,
+	ScopeAuditRead,
+	ScopeClientsRead, ScopeClientsWrite,
+	ScopeTokensIntrospect,
+	ScopeServiceAccountsRead, ScopeServiceAccountsWrite,
+	ScopeTenantsRead, ScopeTenantsWrite,
+	ScopeOrganizationsRead, ScopeOrganizationsWrite,
+	ScopeGroupsRead, ScopeGroupsWrite,
+	ScopeAuthzCheck,
+	ScopePoliciesRead, ScopePoliciesWrite,
+}
+
+// OpenID Connect scopes. Only the OAuth server acts on them.
+const (
+	ScopeOpenID      = "openid"
+	ScopeOIDCProfile = "profile"
+	ScopeOIDCEmail   = "email"
+)
+
+// ClientScopes are the scopes an OAuth client can be allowed to request: the
+// login scopes, the OpenID Connect ones, and users:read for client_credentials.
+var ClientScopes = slices.Concat(LoginScopes, []string{ScopeOpenID, ScopeOIDCProfile, ScopeOIDCEmail, ScopeUsersRead})
+
+// loginScope validates the scopes a login asks for and returns them as a
+// token's scope claim.
+func loginScope(scopes []string) (string, error) {
+	for _, scope := range scopes {
+		if !slices.Contains(LoginScopes, scope) {
+			return "", errors.New("unsupported scope: " + scope)
+		}
+	}
+	return strings.Join(compactRoles(scopes), " "), nil
+}
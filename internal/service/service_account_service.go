@@ -0,0 +1,242 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/bekbek22/auth_service/internal/audit"
+	"github.com/bekbek22/auth_service/internal/model"
+	"github.com/bekbek22/auth_service/internal/utils"
+	"github.com/golang-jwt/jwt/v5"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Scopes a service account can be granted. Each one opens an admin RPC to
+// callers that aren't admin users.
+const (
+	ScopeUsersRead   = "users:read"
+	ScopeAuditRead   = "audit:read"
+	ScopeClientsRead = "clients:read"
+)
+
+var serviceAccountScopes = []string{ScopeUsersRead, ScopeAuditRead, ScopeClientsRead, ScopeTokensIntrospect, ScopeAuthzCheck}
+
+// apiKeyPrefix marks our API keys so they are easy to spot in logs and
+// secret scanners.
+const apiKeyPrefix = "ak_"
+
+// newPrefixedSecret generates a credential of the form <kind><8 hex>_<secret>.
+// The prefix part is stored in clear so keys can be told apart in listings.
+func newPrefixedSecret(kind string) (prefix, plaintext string, err error) {
+	public := make([]byte, 4)
+	if _, err := rand.Read(public); err != nil {
+		return "", "", err
+	}
+	secret, err := utils.GenerateOpaqueToken(32)
+	if err != nil {
+		return "", "", err
+	}
+	prefix = kind + hex.EncodeToString(public)
+	return prefix, prefix + "_" + secret, nil
+}
+
+func validateServiceScopes(scopes []string) error {
+	for _, scope := range scopes {
+		if !slices.Contains(serviceAccountScopes, scope) {
+			return errors.New("unsupported scope: " + scope)
+		}
+	}
+	return nil
+}
+
+func (s *AuthService) CreateServiceAccount(ctx context.Context, actorID, name, description string, scopes []string) (*model.ServiceAccount, error) {
+	if strings.TrimSpace(name) == "" {
+		return nil, errors.New("name is required")
+	}
+	if err := validateServiceScopes(scopes); err != nil {
+		return nil, err
+	}
+
+	account := &model.ServiceAccount{
+		Name:        name,
+		Description: description,
+		Scopes:      scopes,
+		CreatedBy:   actorID,
+	}
+	if err := s.serviceAccounts.Create(ctx, account); err != nil {
+		return nil, errors.New("failed to create service account")
+	}
+
+	s.audit.Record(ctx, model.AuditEvent{
+		ActorID: actorID,
+		Action:  audit.ActionServiceAccountCreate,
+		Outcome: audit.OutcomeSuccess,
+		Details: map[string]string{"service_account_id": account.ID.Hex()},
+	})
+	return account, nil
+}
+
+func (s *AuthService) ListServiceAccounts(ctx context.Context) ([]model.ServiceAccount, error) {
+	return s.serviceAccounts.List(ctx)
+}
+
+// CreateAPIKey issues a key for a service account. The key's scopes must be
+// a subset of the account's and default to all of them. The plaintext key is
+// returned once and only its hash is stored.
+func (s *AuthService) CreateAPIKey(ctx context.Context, actorID, accountID, name string, scopes []string, expiresInDays int32) (*model.APIKey, string, error) {
+	oid, err := primitive.ObjectIDFromHex(accountID)
+	if err != nil {
+		return nil, "", errors.New("invalid service account ID")
+	}
+	account, err := s.serviceAccounts.FindByID(ctx, oid)
+	if err != nil {
+		return nil, "", errors.New("service account not found")
+	}
+
+	if len(scopes) == 0 {
+		scopes = account.Scopes
+	}
+	for _, scope := range scopes {
+		if !slices.Contains(account.Scopes, scope) {
+			return nil, "", errors.New("scope not granted to the service account: " + scope)
+		}
+	}
+	if expiresInDays < 0 {
+		return nil, "", errors.New("expires_in_days can't be negative")
+	}
+
+	var expiresAt int64
+	if expiresInDays > 0 {
+		expiresAt = time.Now().Add(time.Duration(expiresInDays) * 24 * time.Hour).Unix()
+	}
+	key, plaintext, err := s.issueAPIKey(ctx, oid, name, scopes, expiresAt)
+	if err != nil {
+		return nil, "", err
+	}
+
+	s.recordAPIKeyEvent(ctx, actorID, audit.ActionAPIKeyCreate, key)
+	return key, plaintext, nil
+}
+
+func (s *AuthService) issueAPIKey(ctx context.Context, accountID primitive.ObjectID, name string, scopes []string, expiresAt int64) (*model.APIKey, string, error) {
+	prefix, plaintext, err := newPrefixedSecret(apiKeyPrefix)
+	if err != nil {
+		return nil, "", errors.New("failed to generate api key")
+	}
+
+	key := &model.APIKey{
+		ServiceAccountID: accountID,
+		Name:             name,
+		Prefix:           prefix,
+		KeyHash:          utils.HashToken(plaintext),
+		Scopes:           scopes,
+		ExpiresAt:        expiresAt,
+	}
+	if err := s.apiKeyRepo.Create(ctx, key); err != nil {
+		return nil, "", errors.New("failed to save api key")
+	}
+	return key, plaintext, nil
+}
+
+func (s *AuthService) ListAPIKeys(ctx context.Context, accountID string) ([]model.APIKey, error) {
+	oid, err := primitive.ObjectIDFromHex(accountID)
+	if err != nil {
+		return nil, errors.New("invalid service account ID")
+	}
+	return s.apiKeyRepo.ListByServiceAccount(ctx, oid)
+}
+
+// RotateAPIKey replaces a key with a new one of the same name, scopes and
+// lifetime, and revokes the old key immediately.
+func (s *AuthService) RotateAPIKey(ctx context.Context, actorID, keyID string) (*model.APIKey, string, error) {
+	oid, err := primitive.ObjectIDFromHex(keyID)
+	if err != nil {
+		return nil, "", errors.New("invalid api key ID")
+	}
+	old, err := s.apiKeyRepo.FindByID(ctx, oid)
+	if err != nil || old.RevokedAt != 0 {
+		return nil, "", errors.New("api key not found or already revoked")
+	}
+
+	var expiresAt int64
+	if old.ExpiresAt != 0 {
+		expiresAt = time.Now().Unix() + (old.ExpiresAt - old.CreatedAt)
+	}
+	key, plaintext, err := s.issueAPIKey(ctx, old.ServiceAccountID, old.Name, old.Scopes, expiresAt)
+	if err != nil {
+		return nil, "", err
+	}
+	if err := s.apiKeyRepo.Revoke(ctx, old.ID); err != nil {
+		return nil, "", err
+	}
+
+	s.recordAPIKeyEvent(ctx, actorID, audit.ActionAPIKeyRotate, key)
+	return key, plaintext, nil
+}
+
+func (s *AuthService) RevokeAPIKey(ctx context.Context, actorID, keyID string) error {
+	oid, err := primitive.ObjectIDFromHex(keyID)
+	if err != nil {
+		return errors.New("invalid api key ID")
+	}
+	key, err := s.apiKeyRepo.FindByID(ctx, oid)
+	if err != nil {
+		return errors.New("api key not found or already revoked")
+	}
+	if err := s.apiKeyRepo.Revoke(ctx, oid); err != nil {
+		return err
+	}
+
+	s.recordAPIKeyEvent(ctx, actorID, audit.ActionAPIKeyRevoke, key)
+	return nil
+}
+
+// AuthenticateAPIKey resolves an API key to claims for its service account.
+// It is called by the auth interceptor for requests carrying x-api-key.
+func (s *AuthService) AuthenticateAPIKey(ctx context.Context, plaintext string) (*utils.AuthClaims, error) {
+	if !strings.HasPrefix(plaintext, apiKeyPrefix) {
+		return nil, errors.New("malformed api key")
+	}
+	key, err := s.apiKeyRepo.FindActiveByHash(ctx, utils.HashToken(plaintext))
+	if err != nil {
+		return nil, errors.New("unknown, expired or revoked api key")
+	}
+	account, err := s.serviceAccounts.FindByID(ctx, key.ServiceAccountID)
+	if err != nil {
+		return nil, errors.New("service account not found")
+	}
+
+	now := time.Now()
+	if s.touches.due("ak:"+key.ID.Hex(), now) {
+		_ = s.apiKeyRepo.TouchLastUsed(ctx, key.ID, now.Unix())
+	}
+
+	claims := &utils.AuthClaims{
+		Role:     "service",
+		Scope:    strings.Join(key.Scopes, " "),
+		APIKeyID: key.ID.Hex(),
+	}
+	claims.Subject = account.ID.Hex()
+	if key.ExpiresAt != 0 {
+		claims.ExpiresAt = jwt.NewNumericDate(time.Unix(key.ExpiresAt, 0))
+	}
+	return claims, nil
+}
+
+func (s *AuthService) recordAPIKeyEvent(ctx context.Context, actorID, action string, key *model.APIKey) {
+	s.audit.Record(ctx, model.AuditEvent{
+		ActorID: actorID,
+		Action:  action,
+		Outcome: audit.OutcomeSuccess,
+		Details: map[string]string{
+			"service_account_id": key.ServiceAccountID.Hex(),
+			"api_key_id":         key.ID.Hex(),
+			"prefix":             key.Prefix,
+		},
+	})
+}
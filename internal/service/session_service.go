@@ -0,0 +1,179 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/bekbek22/auth_service/internal/audit"
+	"github.com/bekbek22/auth_service/internal/middleware"
+	"github.com/bekbek22/auth_service/internal/model"
+	"github.com/bekbek22/auth_service/internal/utils"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Avoid a write on every request; last-seen only needs minute precision
+const sessionTouchInterval = time.Minute
+
+// touchThrottle remembers when each session's last-seen time was last written.
+type touchThrottle struct {
+	mu   sync.Mutex
+	last map[string]time.Time
+}
+
+func newTouchThrottle() *touchThrottle {
+	return &touchThrottle{last: make(map[string]time.Time)}
+}
+
+// due reports whether sid should be written now and records it if so.
+func (t *touchThrottle) due(sid string, now time.Time) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if now.Sub(t.last[sid]) < sessionTouchInterval {
+		return false
+	}
+	// Forget idle sessions so the map stays small
+	if len(t.last) > 10000 {
+		for k, v := range t.last {
+			if now.Sub(v) >= sessionTouchInterval {
+				delete(t.last, k)
+			}
+		}
+	}
+	t.last[sid] = now
+	return true
+}
+
+func (s *AuthService) createSession(ctx context.Context, userID primitive.ObjectID, deviceName string, ttl time.Duration) (*model.Session, error) {
+	ip, userAgent := middleware.ClientInfoFromContext(ctx)
+	if deviceName == "" {
+		deviceName = userAgent
+	}
+
+	session := &model.Session{
+		UserID:     userID,
+		DeviceName: deviceName,
+		IP:         ip,
+		UserAgent:  userAgent,
+		ExpiresAt:  time.Now().Add(ttl).Unix(),
+	}
+	if err := s.sessionRepo.Create(ctx, session); err != nil {
+		return nil, err
+	}
+	return session, nil
+}
+
+// VerifyToken rejects revoked tokens and tokens whose session was revoked. It
+// is used by the auth interceptor on every protected RPC and is normally
+// answered from the in-memory revocation cache.
+func (s *AuthService) VerifyToken(ctx context.Context, token string, claims *utils.AuthClaims) error {
+	sid := claims.SessionID
+	oid, err := primitive.ObjectIDFromHex(sid)
+	if err != nil {
+		return errors.New("missing session")
+	}
+	if err := s.checkRevoked(ctx, claims); err != nil {
+		return err
+	}
+	if err := s.checkOrgClaims(ctx, claims); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	if s.touches.due(sid, now) {
+		_ = s.sessionRepo.Touch(ctx, oid, now.Unix())
+	}
+	return nil
+}
+
+// checkRevoked rejects a token that was revoked itself, or whose session or
+// client, if it has one, was revoked.
+func (s *AuthService) checkRevoked(ctx context.Context, claims *utils.AuthClaims) error {
+	revoked, err := s.revocations.IsTokenRevoked(ctx, claims.ID)
+	if err != nil {
+		return errors.New("failed to check token")
+	}
+	if revoked {
+		return errors.New("token revoked")
+	}
+
+	if claims.ClientID != "" {
+		revoked, err := s.revocations.IsClientRevoked(ctx, claims.ClientID)
+		if err != nil {
+			return errors.New("failed to check client")
+		}
+		if revoked {
+			return errors.New("client deleted")
+		}
+	}
+
+	sid := claims.SessionID
+	if sid == "" {
+		return nil
+	}
+	revoked, err = s.revocations.IsSessionRevoked(ctx, sid)
+	if err != nil {
+		return errors.New("failed to check session")
+	}
+	if revoked {
+		return errors.New("session expired or revoked")
+	}
+	return nil
+}
+
+func (s *AuthService) ListMySessions(ctx context.Context, userID string) ([]model.Session, error) {
+	oid, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, errors.New("invalid user ID")
+	}
+	return s.sessionRepo.ListActiveByUser(ctx, oid)
+}
+
+func (s *AuthService) RevokeSession(ctx context.Context, userID, sessionID string) error {
+	uid, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return errors.New("invalid user ID")
+	}
+	sid, err := primitive.ObjectIDFromHex(sessionID)
+	if err != nil {
+		return errors.New("invalid session ID")
+	}
+
+	if err := s.sessionRepo.Revoke(ctx, sid, uid); err != nil {
+		return err
+	}
+	s.syncRevocations(ctx)
+
+	s.audit.Record(ctx, model.AuditEvent{
+		ActorID:   userID,
+		SubjectID: userID,
+		Action:    audit.ActionSessionRevoke,
+		Outcome:   audit.OutcomeSuccess,
+		Details:   map[string]string{"session_id": sessionID},
+	})
+	return nil
+}
+
+// revokeAllSessions signs a user out everywhere, personal access tokens
+// included, after a security-sensitive change.
+func (s *AuthService) revokeAllSessions(ctx context.Context, userID primitive.ObjectID) error {
+	if err := s.sessionRepo.RevokeAllByUser(ctx, userID); err != nil {
+		return errors.New("failed to revoke sessions")
+	}
+	if err := s.patRepo.RevokeAllByUser(ctx, userID); err != nil {
+		return errors.New("failed to revoke personal access tokens")
+	}
+	s.syncRevocations(ctx)
+	return nil
+}
+
+// syncRevocations pulls a revocation made by this instance into the cache
+// right away instead of waiting for the next periodic refresh.
+func (s *AuthService) syncRevocations(ctx context.Context) {
+	if err := s.revocations.Refresh(ctx); err != nil {
+		log.Printf("revocation cache refresh failed: %v", err)
+	}
+}
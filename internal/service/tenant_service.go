@@ -0,0 +1,115 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"strings"
+
+	"github.com/bekbek22/auth_service/internal/audit"
+	"github.com/bekbek22/auth_service/internal/middleware"
+	"github.com/bekbek22/auth_service/internal/model"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var tenantSlugPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9-]{0,61}[a-z0-9]$`)
+
+// requestTenant resolves the x-tenant header of a public call to a tenant ID.
+// Calls without the header are for the default tenant, whose ID is "".
+func (s *AuthService) requestTenant(ctx context.Context) (string, error) {
+	return s.tenantBySlug(ctx, middleware.ExtractTenantFromContext(ctx))
+}
+
+// tenantBySlug resolves a tenant slug to its ID; "" is the default tenant.
+func (s *AuthService) tenantBySlug(ctx context.Context, slug string) (string, error) {
+	if slug == "" {
+		return "", nil
+	}
+	tenant, err := s.tenantRepo.FindBySlug(ctx, slug)
+	if err != nil {
+		return "", errors.New("unknown tenant")
+	}
+	return tenant.ID.Hex(), nil
+}
+
+func (s *AuthService) CreateTenant(ctx context.Context, actorID, name, slug string) (*model.Tenant, error) {
+	slug = strings.ToLower(strings.TrimSpace(slug))
+	if strings.TrimSpace(name) == "" {
+		return nil, errors.New("name is required")
+	}
+	if !tenantSlugPattern.MatchString(slug) {
+		return nil, errors.New("slug must be 2-63 lowercase letters, digits or dashes")
+	}
+	if _, err := s.tenantRepo.FindBySlug(ctx, slug); err == nil {
+		return nil, errors.New("slug already taken")
+	}
+
+	tenant := &model.Tenant{Name: name, Slug: slug}
+	if err := s.tenantRepo.Create(ctx, tenant); err != nil {
+		return nil, errors.New("failed to create tenant")
+	}
+	s.recordTenantEvent(ctx, actorID, audit.ActionTenantCreate, tenant.ID.Hex())
+	return tenant, nil
+}
+
+func (s *AuthService) GetTenant(ctx context.Context, tenantID string) (*model.Tenant, error) {
+	oid, err := primitive.ObjectIDFromHex(tenantID)
+	if err != nil {
+		return nil, errors.New("invalid tenant ID")
+	}
+	tenant, err := s.tenantRepo.FindByID(ctx, oid)
+	if err != nil {
+		return nil, errors.New("tenant not found")
+	}
+	return tenant, nil
+}
+
+func (s *AuthService) ListTenants(ctx context.Context, page, limit int32) ([]model.Tenant, int32, error) {
+	return s.tenantRepo.List(ctx, page, limit)
+}
+
+// UpdateTenant renames a tenant. The slug can't change because clients send it.
+func (s *AuthService) UpdateTenant(ctx context.Context, actorID, tenantID, name string) (*model.Tenant, error) {
+	if strings.TrimSpace(name) == "" {
+		return nil, errors.New("name is required")
+	}
+	oid, err := primitive.ObjectIDFromHex(tenantID)
+	if err != nil {
+		return nil, errors.New("invalid tenant ID")
+	}
+	if err := s.tenantRepo.Update(ctx, oid, bson.M{"name": name}); err != nil {
+		return nil, err
+	}
+	s.recordTenantEvent(ctx, actorID, audit.ActionTenantUpdate, tenantID)
+	return s.tenantRepo.FindByID(ctx, oid)
+}
+
+// DeleteTenant removes a tenant that no longer has users.
+func (s *AuthService) DeleteTenant(ctx context.Context, actorID, tenantID string) error {
+	oid, err := primitive.ObjectIDFromHex(tenantID)
+	if err != nil {
+		return errors.New("invalid tenant ID")
+	}
+	count, err := s.repo.CountByTenant(ctx, tenantID)
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return errors.New("tenant still has users")
+	}
+	if err := s.tenantRepo.Delete(ctx, oid); err != nil {
+		return err
+	}
+	s.recordTenantEvent(ctx, actorID, audit.ActionTenantDelete, tenantID)
+	return nil
+}
+
+func (s *AuthService) recordTenantEvent(ctx context.Context, actorID, action, tenantID string) {
+	s.audit.Record(ctx, model.AuditEvent{
+		ActorID: actorID,
+		Action:  action,
+		Outcome: audit.OutcomeSuccess,
+		Details: map[string]string{"tenant_id": tenantID},
+	})
+}
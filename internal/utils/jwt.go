@@ -1,17 +1,118 @@
 package utils
 
 import (
+	"errors"
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
 )
 
-func GenerateJWT(userID string, role string, secret string) (string, error) {
-	claims := jwt.MapClaims{
-		"user_id": userID,
-		"role":    role,
-		"exp":     time.Now().Add(time.Hour * 24).Unix(),
+// Clock skew tolerated between us and whoever checks exp, nbf and iat
+const TokenLeeway = 30 * time.Second
+
+// AuthClaims are the claims of the access tokens the service issues. The
+// subject is the user ID, or the client ID for client_credentials tokens.
+type AuthClaims struct {
+	jwt.RegisteredClaims
+	Role      string `json:"role,omitempty"`
+	Scope     string `json:"scope,omitempty"` // space separated
+	SessionID string `json:"sid,omitempty"`
+	ClientID  string `json:"client_id,omitempty"`
+	TenantID  string `json:"tid,omitempty"` // empty for the default tenant
+
+	// Active organization, set by SwitchOrganization, and the role in it
+	OrgID   string `json:"org,omitempty"`
+	OrgRole string `json:"org_role,omitempty"`
+
+	// Roles granted through groups, on top of Role
+	Roles []string `json:"roles,omitempty"`
+
+	// Actor is the admin acting as the subject in an impersonation token
+	Actor *Actor `json:"act,omitempty"`
+
+	// Set instead of a session when a call was made with an API key or a
+	// personal access token. Those claims are never signed into a JWT.
+	APIKeyID        string `json:"-"`
+	PersonalTokenID string `json:"-"`
+}
+
+// Actor identifies who is acting on behalf of the subject (RFC 8693 act).
+type Actor struct {
+	Subject string `json:"sub"`
+}
+
+func (c *AuthClaims) Impersonated() bool {
+	return c.Actor != nil
+}
+
+// HasRole reports whether the subject has role, directly or through a group.
+func (c *AuthClaims) HasRole(role string) bool {
+	return c.Role == role || slices.Contains(c.Roles, role)
+}
+
+// AllRoles returns Role followed by the roles granted through groups.
+func (c *AuthClaims) AllRoles() []string {
+	return append([]string{c.Role}, c.Roles...)
+}
+
+func (c *AuthClaims) Scopes() []string {
+	return strings.Fields(c.Scope)
+}
+
+func (c *AuthClaims) HasScope(scope string) bool {
+	return slices.Contains(c.Scopes(), scope)
+}
+
+// TokenIssuer signs access tokens with the service's RSA key and verifies
+// them, so other services can check the same tokens against the JWKS.
+type TokenIssuer struct {
+	key      *SigningKey
+	issuer   string
+	audience string
+}
+
+// NewTokenIssuer returns an issuer whose tokens carry iss issuer and are
+// always valid for audience, the identifier of this service.
+func NewTokenIssuer(key *SigningKey, issuer, audience string) *TokenIssuer {
+	return &TokenIssuer{key: key, issuer: issuer, audience: audience}
+}
+
+// Issue fills in the registered claims and signs claims. Every token gets a
+// unique jti so it can be revoked on its own. Extra audiences are the
+// registered clients the token was issued to.
+func (t *TokenIssuer) Issue(claims *AuthClaims, ttl time.Duration, audience ...string) (string, error) {
+	now := time.Now()
+	claims.Issuer = t.issuer
+	claims.Audience = append(jwt.ClaimStrings{t.audience}, audience...)
+	claims.ID = uuid.NewString()
+	claims.IssuedAt = jwt.NewNumericDate(now)
+	claims.NotBefore = jwt.NewNumericDate(now)
+	claims.ExpiresAt = jwt.NewNumericDate(now.Add(ttl))
+	return t.key.Sign(claims)
+}
+
+// Validate verifies an RS256 token's signature, issuer, audience and times.
+// Other algorithms are refused so a token can't pick how it is checked.
+func (t *TokenIssuer) Validate(token string) (*AuthClaims, error) {
+	claims := &AuthClaims{}
+	_, err := jwt.ParseWithClaims(token, claims, func(*jwt.Token) (interface{}, error) {
+		return t.key.PublicKey(), nil
+	},
+		jwt.WithValidMethods([]string{jwt.SigningMethodRS256.Alg()}),
+		jwt.WithIssuer(t.issuer),
+		jwt.WithAudience(t.audience),
+		jwt.WithExpirationRequired(),
+		jwt.WithIssuedAt(),
+		jwt.WithLeeway(TokenLeeway),
+	)
+	if err != nil {
+		return nil, errors.New("invalid or expired token")
+	}
+	if claims.Subject == "" || claims.ID == "" {
+		return nil, errors.New("invalid claims")
 	}
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString([]byte(secret))
+	return claims, nil
 }
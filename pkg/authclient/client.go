@@ -0,0 +1,168 @@
+// Package authclient is a Go client for the auth service. It wraps the
+// generated AuthServiceClient with an interceptor that attaches credentials,
+// refreshes them when the server rejects them and retries calls that failed
+// because the service was briefly unavailable.
+//
+//	c, err := authclient.New("auth:50051", authclient.WithCredentials(authclient.APIKey(key)),
+//		authclient.WithDialOptions(grpc.WithTransportCredentials(creds)))
+//	users, err := c.ListUsers(ctx, &pb.ListUsersRequest{})
+//
+// Services that already have a connection can use UnaryClientInterceptor
+// on their own.
+package authclient
+
+import (
+	"context"
+	"time"
+
+	pb "github.com/bekbek22/auth_service/api/proto"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// Calls that never carry credentials. Login must be among them so password
+// credentials can log in through the same connection.
+var publicMethods = map[string]bool{
+	pb.AuthService_Register_FullMethodName:             true,
+	pb.AuthService_Login_FullMethodName:                true,
+	pb.AuthService_Logout_FullMethodName:               true,
+	pb.AuthService_RequestPasswordReset_FullMethodName: true,
+	pb.AuthService_ResetPassword_FullMethodName:        true,
+	pb.AuthService_ConfirmEmailChange_FullMethodName:   true,
+	pb.AuthService_RevertEmailChange_FullMethodName:    true,
+	pb.AuthService_BeginPasskeyLogin_FullMethodName:    true,
+	pb.AuthService_FinishPasskeyLogin_FullMethodName:   true,
+	pb.AuthService_RequestMagicLink_FullMethodName:     true,
+	pb.AuthService_ConsumeMagicLink_FullMethodName:     true,
+	pb.AuthService_SendLoginOTP_FullMethodName:         true,
+	pb.AuthService_VerifyLoginOTP_FullMethodName:       true,
+	pb.AuthService_AcceptInvitation_FullMethodName:     true,
+}
+
+type options struct {
+	creds       Credentials
+	maxAttempts int
+	backoff     time.Duration
+	dialOpts    []grpc.DialOption
+	tenant      string
+}
+
+type Option func(*options)
+
+// WithCredentials sets what authenticated calls are made with. Without
+// credentials only the public RPCs work.
+func WithCredentials(c Credentials) Option {
+	return func(o *options) { o.creds = c }
+}
+
+// WithRetry retries calls that fail with Unavailable up to maxAttempts times
+// in total, doubling the wait from backoff after each try. The default is 3
+// attempts starting at 100ms; 1 disables retries.
+func WithRetry(maxAttempts int, backoff time.Duration) Option {
+	return func(o *options) {
+		o.maxAttempts = maxAttempts
+		o.backoff = backoff
+	}
+}
+
+// WithDialOptions adds options for the connection New opens, such as
+// transport credentials.
+func WithDialOptions(opts ...grpc.DialOption) Option {
+	return func(o *options) { o.dialOpts = append(o.dialOpts, opts...) }
+}
+
+// WithTenant sends the tenant's slug in the x-tenant header of every call,
+// including the logins made by Password credentials. Without it calls are for
+// the default tenant.
+func WithTenant(slug string) Option {
+	return func(o *options) { o.tenant = slug }
+}
+
+func newOptions(opts []Option) *options {
+	o := &options{maxAttempts: 3, backoff: 100 * time.Millisecond}
+	for _, opt := range opts {
+		opt(o)
+	}
+	if o.maxAttempts < 1 {
+		o.maxAttempts = 1
+	}
+	return o
+}
+
+// UnaryClientInterceptor attaches credentials to every call except the
+// public ones. When the server answers Unauthenticated it invalidates the
+// credentials and retries once; Unavailable is retried with backoff.
+func UnaryClientInterceptor(opts ...Option) grpc.UnaryClientInterceptor {
+	o := newOptions(opts)
+
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, callOpts ...grpc.CallOption) error {
+		attach := o.creds != nil && !publicMethods[method]
+		if o.tenant != "" {
+			ctx = metadata.AppendToOutgoingContext(ctx, "x-tenant", o.tenant)
+		}
+		refreshed := false
+		backoff := o.backoff
+
+		for attempt := 1; ; attempt++ {
+			callCtx := ctx
+			if attach {
+				md, err := o.creds.Metadata(ctx, cc)
+				if err != nil {
+					return err
+				}
+				for k, v := range md {
+					callCtx = metadata.AppendToOutgoingContext(callCtx, k, v)
+				}
+			}
+
+			err := invoker(callCtx, method, req, reply, cc, callOpts...)
+			switch status.Code(err) {
+			case codes.Unauthenticated:
+				if attach && !refreshed && o.creds.Invalidate() {
+					refreshed = true
+					continue
+				}
+			case codes.Unavailable:
+				if attempt < o.maxAttempts {
+					select {
+					case <-time.After(backoff):
+					case <-ctx.Done():
+						return err
+					}
+					backoff *= 2
+					continue
+				}
+			}
+			return err
+		}
+	}
+}
+
+// Client is an AuthServiceClient whose connection authenticates and retries
+// calls as configured.
+type Client struct {
+	pb.AuthServiceClient
+	conn *grpc.ClientConn
+}
+
+// New connects to the auth service at target. Transport credentials must be
+// passed with WithDialOptions.
+func New(target string, opts ...Option) (*Client, error) {
+	o := newOptions(opts)
+	dialOpts := append([]grpc.DialOption{grpc.WithChainUnaryInterceptor(UnaryClientInterceptor(opts...))}, o.dialOpts...)
+
+	conn, err := grpc.NewClient(target, dialOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return &Client{
+		AuthServiceClient: pb.NewAuthServiceClient(conn),
+		conn:              conn,
+	}, nil
+}
+
+func (c *Client) Close() error {
+	return c.conn.Close()
+}
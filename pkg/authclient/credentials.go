@@ -0,0 +1,125 @@
+package authclient
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	pb "github.com/bekbek22/auth_service/api/proto"
+	"github.com/golang-jwt/jwt/v5"
+	"google.golang.org/grpc"
+)
+
+// Log in again this long before the current token expires
+const refreshBefore = time.Minute
+
+// Credentials supply the metadata attached to authenticated calls.
+type Credentials interface {
+	// Metadata returns the headers for a call, obtaining a token through cc
+	// first if needed.
+	Metadata(ctx context.Context, cc grpc.ClientConnInterface) (map[string]string, error)
+
+	// Invalidate drops a token the server rejected. It reports whether new
+	// credentials can be obtained, i.e. whether retrying is worthwhile.
+	Invalidate() bool
+}
+
+type staticToken string
+
+// StaticToken sends token as a bearer token. It suits personal access tokens
+// and tokens obtained elsewhere; it is never refreshed.
+func StaticToken(token string) Credentials {
+	return staticToken(token)
+}
+
+func (t staticToken) Metadata(context.Context, grpc.ClientConnInterface) (map[string]string, error) {
+	return map[string]string{"authorization": "Bearer " + string(t)}, nil
+}
+
+func (t staticToken) Invalidate() bool { return false }
+
+type apiKey string
+
+// APIKey sends a service account's API key in the x-api-key header.
+func APIKey(key string) Credentials {
+	return apiKey(key)
+}
+
+func (k apiKey) Metadata(context.Context, grpc.ClientConnInterface) (map[string]string, error) {
+	return map[string]string{"x-api-key": string(k)}, nil
+}
+
+func (k apiKey) Invalidate() bool { return false }
+
+// PasswordCredentials log in with an email and password and log in again
+// shortly before the token expires or after the server rejects it. Accounts
+// with a second factor can't use them; use a personal access token instead.
+type PasswordCredentials struct {
+	email      string
+	password   string
+	deviceName string
+	scopes     []string
+
+	mu        sync.Mutex
+	token     string
+	expiresAt time.Time
+}
+
+func Password(email, password, deviceName string) *PasswordCredentials {
+	return &PasswordCredentials{email: email, password: password, deviceName: deviceName}
+}
+
+// WithScopes makes the credentials log in for a token limited to scopes.
+func (p *PasswordCredentials) WithScopes(scopes ...string) *PasswordCredentials {
+	p.scopes = scopes
+	return p
+}
+
+func (p *PasswordCredentials) Metadata(ctx context.Context, cc grpc.ClientConnInterface) (map[string]string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.token == "" || time.Until(p.expiresAt) < refreshBefore {
+		if err := p.login(ctx, cc); err != nil {
+			return nil, err
+		}
+	}
+	return map[string]string{"authorization": "Bearer " + p.token}, nil
+}
+
+func (p *PasswordCredentials) login(ctx context.Context, cc grpc.ClientConnInterface) error {
+	resp, err := pb.NewAuthServiceClient(cc).Login(ctx, &pb.LoginRequest{
+		Email:      p.email,
+		Password:   p.password,
+		DeviceName: p.deviceName,
+		Scopes:     p.scopes,
+	})
+	if err != nil {
+		return err
+	}
+	if resp.MfaRequired {
+		return errors.New("authclient: account requires a second factor, use a personal access token")
+	}
+
+	// The token is only read here to learn when to refresh it; the server
+	// verifies it on every call
+	claims := &jwt.RegisteredClaims{}
+	if _, _, err := jwt.NewParser().ParseUnverified(resp.AccessToken, claims); err != nil {
+		return err
+	}
+	if claims.ExpiresAt == nil {
+		return errors.New("authclient: token has no expiry")
+	}
+
+	p.token = resp.AccessToken
+	p.expiresAt = claims.ExpiresAt.Time
+	return nil
+}
+
+func (p *PasswordCredentials) Invalidate() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.token = ""
+	return true
+}
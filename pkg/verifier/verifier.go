@@ -0,0 +1,298 @@
+// Package verifier lets other services check access tokens issued by the
+// auth service without calling it. Tokens are verified against the public
+// keys the service publishes at /.well-known/jwks.json.
+//
+// Offline verification can't see revocations made after a token was issued.
+// Services that need that, e.g. before a sensitive operation, should call the
+// IntrospectToken RPC instead.
+package verifier
+
+import (
+	"context"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	defaultRefreshInterval = time.Hour
+	// An unknown kid triggers a fetch, but no more often than this
+	minFetchInterval = 10 * time.Second
+	// Clock skew tolerated when checking exp, nbf and iat
+	defaultLeeway = 30 * time.Second
+)
+
+// Claims are the verified contents of an access token. The subject is the
+// user ID, or the client ID for client_credentials tokens.
+type Claims struct {
+	jwt.RegisteredClaims
+	Role      string `json:"role,omitempty"`
+	Scope     string `json:"scope,omitempty"` // space separated
+	SessionID string `json:"sid,omitempty"`
+	ClientID  string `json:"client_id,omitempty"`
+	TenantID  string `json:"tid,omitempty"` // empty for the default tenant
+
+	// Roles granted to the subject through groups, on top of Role
+	Roles []string `json:"roles,omitempty"`
+
+	// Active organization of the subject and their role in it, if any
+	OrgID   string `json:"org,omitempty"`
+	OrgRole string `json:"org_role,omitempty"`
+
+	// Actor is set when an admin is impersonating the subject
+	Actor *Actor `json:"act,omitempty"`
+}
+
+// Actor identifies who is acting on behalf of the subject.
+type Actor struct {
+	Subject string `json:"sub"`
+}
+
+// Scopes returns the scopes the token was granted.
+func (c *Claims) Scopes() []string {
+	return strings.Fields(c.Scope)
+}
+
+// HasScope reports whether the token was granted scope.
+func (c *Claims) HasScope(scope string) bool {
+	return slices.Contains(c.Scopes(), scope)
+}
+
+// HasRole reports whether the subject has role, directly or through a group.
+func (c *Claims) HasRole(role string) bool {
+	return c.Role == role || slices.Contains(c.Roles, role)
+}
+
+// Verifier checks RS256 access tokens against a cached JWKS. It is safe for
+// concurrent use.
+type Verifier struct {
+	jwksURL         string
+	client          *http.Client
+	issuer          string
+	audience        string
+	leeway          time.Duration
+	refreshInterval time.Duration
+
+	mu        sync.RWMutex
+	keys      map[string]*rsa.PublicKey
+	fetchedAt time.Time
+	triedAt   time.Time
+}
+
+type Option func(*Verifier)
+
+// WithHTTPClient sets the client used to fetch the JWKS.
+func WithHTTPClient(c *http.Client) Option {
+	return func(v *Verifier) { v.client = c }
+}
+
+// WithIssuer only accepts tokens whose iss is issuer, the auth service's
+// OIDC_ISSUER.
+func WithIssuer(issuer string) Option {
+	return func(v *Verifier) { v.issuer = issuer }
+}
+
+// WithAudience only accepts tokens whose aud includes aud: the auth service's
+// TOKEN_AUDIENCE, or an OAuth client ID for tokens issued to that client.
+func WithAudience(aud string) Option {
+	return func(v *Verifier) { v.audience = aud }
+}
+
+// WithLeeway sets the clock skew tolerated when checking exp, nbf and iat.
+// The default is 30 seconds.
+func WithLeeway(d time.Duration) Option {
+	return func(v *Verifier) { v.leeway = d }
+}
+
+// WithRefreshInterval sets how long fetched keys are used before the JWKS is
+// fetched again. The default is an hour.
+func WithRefreshInterval(d time.Duration) Option {
+	return func(v *Verifier) { v.refreshInterval = d }
+}
+
+// New returns a Verifier for the JWKS at jwksURL, for example
+// "https://auth.example.com/.well-known/jwks.json". Keys are fetched on first use.
+func New(jwksURL string, opts ...Option) *Verifier {
+	v := &Verifier{
+		jwksURL:         jwksURL,
+		client:          &http.Client{Timeout: 10 * time.Second},
+		leeway:          defaultLeeway,
+		refreshInterval: defaultRefreshInterval,
+		keys:            make(map[string]*rsa.PublicKey),
+	}
+	for _, opt := range opts {
+		opt(v)
+	}
+	return v
+}
+
+// Verify checks the token's signature, times and, if configured, issuer and
+// audience, and returns its claims.
+func (v *Verifier) Verify(ctx context.Context, token string) (*Claims, error) {
+	opts := []jwt.ParserOption{
+		jwt.WithValidMethods([]string{jwt.SigningMethodRS256.Alg()}),
+		jwt.WithExpirationRequired(),
+		jwt.WithIssuedAt(),
+		jwt.WithLeeway(v.leeway),
+	}
+	if v.issuer != "" {
+		opts = append(opts, jwt.WithIssuer(v.issuer))
+	}
+	if v.audience != "" {
+		opts = append(opts, jwt.WithAudience(v.audience))
+	}
+
+	claims := &Claims{}
+	_, err := jwt.ParseWithClaims(token, claims, func(t *jwt.Token) (interface{}, error) {
+		kid, _ := t.Header["kid"].(string)
+		return v.key(ctx, kid)
+	}, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("invalid token: %w", err)
+	}
+	// ID tokens are signed with the same key but carry no jti
+	if claims.ID == "" {
+		return nil, errors.New("not an access token")
+	}
+	return claims, nil
+}
+
+// key returns the public key for kid, fetching the JWKS when the cached copy
+// is old or doesn't know kid.
+func (v *Verifier) key(ctx context.Context, kid string) (*rsa.PublicKey, error) {
+	v.mu.RLock()
+	key, ok := v.keys[kid]
+	fresh := time.Since(v.fetchedAt) < v.refreshInterval
+	v.mu.RUnlock()
+	if ok && fresh {
+		return key, nil
+	}
+
+	if err := v.refresh(ctx); err != nil {
+		// Keep using a known key while the JWKS endpoint is unreachable
+		if ok {
+			return key, nil
+		}
+		return nil, err
+	}
+
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+	if key, ok := v.keys[kid]; ok {
+		return key, nil
+	}
+	return nil, fmt.Errorf("unknown signing key %q", kid)
+}
+
+func (v *Verifier) refresh(ctx context.Context) error {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	if time.Since(v.triedAt) < minFetchInterval {
+		return nil
+	}
+	v.triedAt = time.Now()
+
+	keys, err := v.fetch(ctx)
+	if err != nil {
+		return err
+	}
+	v.keys = keys
+	v.fetchedAt = time.Now()
+	return nil
+}
+
+type jwks struct {
+	Keys []struct {
+		Kty string `json:"kty"`
+		Kid string `json:"kid"`
+		N   string `json:"n"`
+		E   string `json:"e"`
+	} `json:"keys"`
+}
+
+func (v *Verifier) fetch(ctx context.Context) (map[string]*rsa.PublicKey, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, v.jwksURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := v.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("fetch jwks: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("fetch jwks: status %d", resp.StatusCode)
+	}
+
+	var set jwks
+	if err := json.NewDecoder(resp.Body).Decode(&set); err != nil {
+		return nil, fmt.Errorf("decode jwks: %w", err)
+	}
+
+	keys := make(map[string]*rsa.PublicKey, len(set.Keys))
+	for _, k := range set.Keys {
+		if k.Kty != "RSA" {
+			continue
+		}
+		n, err1 := base64.RawURLEncoding.DecodeString(k.N)
+		e, err2 := base64.RawURLEncoding.DecodeString(k.E)
+		if err1 != nil || err2 != nil {
+			continue
+		}
+		keys[k.Kid] = &rsa.PublicKey{
+			N: new(big.Int).SetBytes(n),
+			E: int(new(big.Int).SetBytes(e).Int64()),
+		}
+	}
+	return keys, nil
+}
+
+type claimsKey struct{}
+
+// ClaimsFromContext returns the claims stored by UnaryServerInterceptor.
+func ClaimsFromContext(ctx context.Context) (*Claims, bool) {
+	claims, ok := ctx.Value(claimsKey{}).(*Claims)
+	return claims, ok
+}
+
+// UnaryServerInterceptor verifies the bearer token on every call except the
+// given full method names and stores the claims in the request context.
+func (v *Verifier) UnaryServerInterceptor(publicMethods ...string) grpc.UnaryServerInterceptor {
+	public := make(map[string]bool, len(publicMethods))
+	for _, m := range publicMethods {
+		public[m] = true
+	}
+
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if public[info.FullMethod] {
+			return handler(ctx, req)
+		}
+
+		md, _ := metadata.FromIncomingContext(ctx)
+		auth := md.Get("authorization")
+		if len(auth) == 0 {
+			return nil, status.Errorf(codes.Unauthenticated, "missing authorization header")
+		}
+
+		claims, err := v.Verify(ctx, strings.TrimPrefix(auth[0], "Bearer "))
+		if err != nil {
+			return nil, status.Errorf(codes.Unauthenticated, "%v", err)
+		}
+		return handler(context.WithValue(ctx, claimsKey{}, claims), req)
+	}
+}